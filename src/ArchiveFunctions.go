@@ -0,0 +1,204 @@
+package main
+
+/*This file contains functions for writing records to archive files*/
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+//Archive file formats
+const (
+	ArchiveFormatCSV   string = "csv"
+	ArchiveFormatJSON  string = "json"
+	ArchiveFormatJSONL string = "jsonl" // One JSON object per line, used for streamed archives
+)
+
+//Returns true if the archive format is one that HCC can write
+func ValidArchiveFormat(format string) bool {
+	switch format {
+	case ArchiveFormatCSV, ArchiveFormatJSON:
+		return true
+	}
+	return false
+}
+
+//Writes the records to w in the given format.  CSV files start with a header line, JSON files contain an array of
+//objects keyed on the header names.
+func WriteRecords(w io.Writer, format string, header []string, records [][]string) error {
+	switch format {
+	case ArchiveFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(records); err != nil {
+			return err
+		}
+		return cw.Error()
+	case ArchiveFormatJSON:
+		objs := make([]map[string]string, 0, len(records))
+		for _, r := range records {
+			if len(r) != len(header) {
+				return fmt.Errorf("record has %d fields, expected %d", len(r), len(header))
+			}
+			obj := make(map[string]string, len(header))
+			for i, h := range header {
+				obj[h] = r[i]
+			}
+			objs = append(objs, obj)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(objs)
+	}
+	return fmt.Errorf("unknown archive format '%s'", format)
+}
+
+//Writes the records to a new file at path.  An existing file is never overwritten and a partially written file is
+//removed, so if an error is returned no archive exists.
+func WriteArchiveFile(path, format string, header []string, records [][]string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return err
+	}
+	err = WriteRecords(f, format, header, records)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+//A RecordWriter writes archive records one at a time
+type RecordWriter interface {
+	Write(record []string) error
+	Flush() error
+}
+
+type csvRecordWriter struct {
+	cw *csv.Writer
+}
+
+func (c csvRecordWriter) Write(record []string) error {
+	return c.cw.Write(record)
+}
+
+func (c csvRecordWriter) Flush() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+type jsonlRecordWriter struct {
+	enc    *json.Encoder
+	header []string
+}
+
+func (j jsonlRecordWriter) Write(record []string) error {
+	if len(record) != len(j.header) {
+		return fmt.Errorf("record has %d fields, expected %d", len(record), len(j.header))
+	}
+	obj := make(map[string]string, len(j.header))
+	for i, h := range j.header {
+		obj[h] = record[i]
+	}
+	return j.enc.Encode(obj)
+}
+
+func (j jsonlRecordWriter) Flush() error {
+	return nil
+}
+
+//Returns a RecordWriter for the streamed formats, csv and jsonl.  CSV output starts with the header line.
+func NewRecordWriter(w io.Writer, format string, header []string) (RecordWriter, error) {
+	switch format {
+	case ArchiveFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return nil, err
+		}
+		return csvRecordWriter{cw}, nil
+	case ArchiveFormatJSONL:
+		return jsonlRecordWriter{json.NewEncoder(w), header}, nil
+	}
+	return nil, fmt.Errorf("unknown streamed archive format '%s'", format)
+}
+
+//RotatingArchive writes records to gzip compressed files in Dir, a new file is started whenever the key passed to
+//Write changes.  Files are named <Prefix>_<key>_<Suffix>.<Format>.gz and are never overwritten.
+type RotatingArchive struct {
+	Dir    string
+	Prefix string
+	Suffix string
+	Format string
+	Header []string
+	Files  []string // Every file written so far
+	Count  uint     // The number of records written
+
+	key string
+	f   *os.File
+	gz  *gzip.Writer
+	rw  RecordWriter
+}
+
+//Writes the record to the file for key, closing the current file if the key has changed
+func (ra *RotatingArchive) Write(key string, record []string) error {
+	if ra.f == nil || key != ra.key {
+		if err := ra.Close(); err != nil {
+			return err
+		}
+		path := filepath.Join(ra.Dir, fmt.Sprintf("%s_%s_%s.%s.gz", ra.Prefix, key, ra.Suffix, ra.Format))
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+		if err != nil {
+			return err
+		}
+		ra.Files = append(ra.Files, path)
+		ra.key, ra.f, ra.gz = key, f, gzip.NewWriter(f)
+		ra.rw, err = NewRecordWriter(ra.gz, ra.Format, ra.Header)
+		if err != nil {
+			return err
+		}
+	}
+	if err := ra.rw.Write(record); err != nil {
+		return err
+	}
+	ra.Count++
+	return nil
+}
+
+//Flushes and closes the current file, if any
+func (ra *RotatingArchive) Close() error {
+	if ra.f == nil {
+		return nil
+	}
+	var err error
+	if ra.rw != nil {
+		err = ra.rw.Flush()
+	}
+	if gerr := ra.gz.Close(); err == nil {
+		err = gerr
+	}
+	if ferr := ra.f.Close(); err == nil {
+		err = ferr
+	}
+	ra.f, ra.gz, ra.rw = nil, nil, nil
+	return err
+}
+
+//Closes and removes every file written, used when an archive cannot be completed
+func (ra *RotatingArchive) Remove() {
+	ra.Close()
+	for _, v := range ra.Files {
+		os.Remove(v)
+	}
+	ra.Files = nil
+	ra.Count = 0
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteRecords(t *testing.T) {
+	header := []string{"BACKUP_ID", "ENTRY_TYPE_NAME"}
+	records := [][]string{{"1000", "complete data backup"}, {"1001", "log backup"}}
+	type args struct {
+		format  string
+		header  []string
+		records [][]string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{"CSV", args{ArchiveFormatCSV, header, records}, "BACKUP_ID,ENTRY_TYPE_NAME\n1000,complete data backup\n1001,log backup\n", false},
+		{"CSVEmpty", args{ArchiveFormatCSV, header, [][]string{}}, "BACKUP_ID,ENTRY_TYPE_NAME\n", false},
+		{"JSON", args{ArchiveFormatJSON, header, records}, "[\n  {\n    \"BACKUP_ID\": \"1000\",\n    \"ENTRY_TYPE_NAME\": \"complete data backup\"\n  },\n  {\n    \"BACKUP_ID\": \"1001\",\n    \"ENTRY_TYPE_NAME\": \"log backup\"\n  }\n]\n", false},
+		{"JSONEmpty", args{ArchiveFormatJSON, header, [][]string{}}, "[]\n", false},
+		{"JSONShortRecord", args{ArchiveFormatJSON, header, [][]string{{"1000"}}}, "", true},
+		{"UnknownFormat", args{"xml", header, records}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := WriteRecords(w, tt.args.format, tt.args.header, tt.args.records); (err != nil) != tt.wantErr {
+				t.Errorf("WriteRecords() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && w.String() != tt.want {
+				t.Errorf("WriteRecords() = %v, want %v", w.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteArchiveFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "archive.csv")
+
+	/*The first write creates the file*/
+	if err := WriteArchiveFile(path, ArchiveFormatCSV, []string{"A"}, [][]string{{"1"}}); err != nil {
+		t.Fatalf("WriteArchiveFile() unexpected error = %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || string(b) != "A\n1\n" {
+		t.Errorf("WriteArchiveFile() wrote %q, err %v", string(b), err)
+	}
+
+	/*An existing archive must never be overwritten*/
+	if err := WriteArchiveFile(path, ArchiveFormatCSV, []string{"A"}, [][]string{{"2"}}); err == nil {
+		t.Errorf("WriteArchiveFile() expected an error when the file exists")
+	}
+
+	/*A failed write must not leave a partial file behind*/
+	bad := filepath.Join(dir, "bad.json")
+	if err := WriteArchiveFile(bad, ArchiveFormatJSON, []string{"A", "B"}, [][]string{{"1"}}); err == nil {
+		t.Errorf("WriteArchiveFile() expected an error for a short record")
+	}
+	if _, err := os.Stat(bad); !os.IsNotExist(err) {
+		t.Errorf("WriteArchiveFile() left a partial file behind")
+	}
+
+	/*Missing directories are an error*/
+	if err := WriteArchiveFile(filepath.Join(dir, "missing", "a.csv"), ArchiveFormatCSV, []string{"A"}, nil); err == nil {
+		t.Errorf("WriteArchiveFile() expected an error for a missing directory")
+	}
+}
+
+func TestNewRecordWriter(t *testing.T) {
+	header := []string{"TIMESTAMP", "USER_NAME"}
+	records := [][]string{{"2022-01-01 10:00:00", "SYSTEM"}, {"2022-01-02 10:00:00", "HCC"}}
+	tests := []struct {
+		name    string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"CSV", ArchiveFormatCSV, "TIMESTAMP,USER_NAME\n2022-01-01 10:00:00,SYSTEM\n2022-01-02 10:00:00,HCC\n", false},
+		{"JSONL", ArchiveFormatJSONL, "{\"TIMESTAMP\":\"2022-01-01 10:00:00\",\"USER_NAME\":\"SYSTEM\"}\n{\"TIMESTAMP\":\"2022-01-02 10:00:00\",\"USER_NAME\":\"HCC\"}\n", false},
+		{"JSONNotStreamed", ArchiveFormatJSON, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			rw, err := NewRecordWriter(w, tt.format, header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewRecordWriter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, r := range records {
+				if err := rw.Write(r); err != nil {
+					t.Fatalf("RecordWriter.Write() unexpected error = %v", err)
+				}
+			}
+			if err := rw.Flush(); err != nil {
+				t.Fatalf("RecordWriter.Flush() unexpected error = %v", err)
+			}
+			if w.String() != tt.want {
+				t.Errorf("RecordWriter wrote %v, want %v", w.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatingArchive(t *testing.T) {
+	dir := t.TempDir()
+	ra := &RotatingArchive{Dir: dir, Prefix: "db_audit", Suffix: "20220101000000", Format: ArchiveFormatCSV, Header: []string{"TIMESTAMP"}}
+	for _, v := range []string{"2022-01-01", "2022-01-01", "2022-01-02"} {
+		if err := ra.Write(v, []string{v}); err != nil {
+			t.Fatalf("RotatingArchive.Write() unexpected error = %v", err)
+		}
+	}
+	if err := ra.Close(); err != nil {
+		t.Fatalf("RotatingArchive.Close() unexpected error = %v", err)
+	}
+	if ra.Count != 3 || len(ra.Files) != 2 {
+		t.Fatalf("RotatingArchive wrote %d records to %d files, want 3 records in 2 files", ra.Count, len(ra.Files))
+	}
+	if ra.Files[0] != filepath.Join(dir, "db_audit_2022-01-01_20220101000000.csv.gz") {
+		t.Errorf("RotatingArchive unexpected file name %s", ra.Files[0])
+	}
+
+	/*Read back the first file*/
+	f, err := os.Open(ra.Files[0])
+	if err != nil {
+		t.Fatalf("could not open archive: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("archive is not gzip compressed: %v", err)
+	}
+	b, _ := io.ReadAll(gz)
+	if string(b) != "TIMESTAMP\n2022-01-01\n2022-01-01\n" {
+		t.Errorf("RotatingArchive first file contains %q", string(b))
+	}
+
+	/*Writing the same key again must not overwrite an existing file*/
+	ra2 := &RotatingArchive{Dir: dir, Prefix: "db_audit", Suffix: "20220101000000", Format: ArchiveFormatCSV, Header: []string{"TIMESTAMP"}}
+	if err := ra2.Write("2022-01-01", []string{"x"}); err == nil {
+		t.Errorf("RotatingArchive.Write() expected an error when the file exists")
+	}
+
+	ra.Remove()
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("RotatingArchive.Remove() left %d files", len(files))
+	}
+}
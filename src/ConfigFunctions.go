@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/Jeffail/gabs/v2"
 )
@@ -129,6 +131,92 @@ func GetConfigFromFile(lc chan<- LogMessage, path string) (*Config, error) {
 		return &mt, fmt.Errorf("config error")
 	}
 
+	/*Optional root parameters, these fall back to defaults when not set*/
+	cnf.TraceQuota, err = GetTraceQuotaConfig(lc, jp, "root config", TraceQuotaConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.Backup, err = GetBackupConfig(lc, jp, "root config", BackupConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.Audit, err = GetAuditConfig(lc, jp, "root config", AuditConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.DataVolume, err = GetDataVolumeConfig(lc, jp, "root config", DataVolumeConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.TableOptimise, err = GetTableOptimiseConfig(lc, jp, "root config", TableOptimiseConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.IdleSessions, err = GetIdleSessionConfig(lc, jp, "root config", IdleSessionConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.MonitoringReset, err = GetMonitoringResetConfig(lc, jp, "root config", MonitoringResetConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.TraceLevels, err = GetTraceLevelConfig(lc, jp, "root config", TraceLevelConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.PlanCache, err = GetPlanCacheConfig(lc, jp, "root config", PlanCacheConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.TableRetention, err = GetTableRetentionConfig(lc, jp, "root config", TableRetentionConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.Alerts, err = GetAlertConfig(lc, jp, "root config", AlertConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.LogVolume, err = GetLogVolumeConfig(lc, jp, "root config", LogVolumeConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.Connection, err = GetConnectionConfig(lc, jp, "root config", ConnectionConfig{}, 0)
+	if err != nil {
+		return &mt, err
+	}
+	if len(cnf.Connection.FailoverHosts) > 0 {
+		lc <- LogMessage{"HccConfig", "Parameter 'Connection.FailoverHosts' can only be set in a DB config.  Cannot continue", false}
+		return &mt, fmt.Errorf("config error")
+	}
+
+	cnf.Cloud, err = GetCloudConfig(lc, jp, "root config", CloudConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	cnf.Retry, err = GetRetryConfig(lc, jp, "root config", RetryConfig{})
+	if err != nil {
+		return &mt, err
+	}
+
+	/*The lock file is taken unless it is turned off*/
+	cnf.Lock, err = GetLockConfig(lc, jp, "root config", LockConfig{true, "", false, 0})
+	if err != nil {
+		return &mt, err
+	}
+
 	/*Now iterate over DBs*/
 	for k, child := range jp.S("Databases").Children() {
 		//Create an struct instance
@@ -260,9 +348,987 @@ func GetConfigFromFile(lc chan<- LogMessage, path string) (*Config, error) {
 			db.CleanDataVolume = cnf.CleanDataVolume
 		}
 
+		db.TraceQuota, err = GetTraceQuotaConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.TraceQuota)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Backup, err = GetBackupConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.Backup)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Audit, err = GetAuditConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.Audit)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.DataVolume, err = GetDataVolumeConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.DataVolume)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.TableOptimise, err = GetTableOptimiseConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.TableOptimise)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.IdleSessions, err = GetIdleSessionConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.IdleSessions)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.MonitoringReset, err = GetMonitoringResetConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.MonitoringReset)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.TraceLevels, err = GetTraceLevelConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.TraceLevels)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.PlanCache, err = GetPlanCacheConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.PlanCache)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.TableRetention, err = GetTableRetentionConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.TableRetention)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Alerts, err = GetAlertConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.Alerts)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.LogVolume, err = GetLogVolumeConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.LogVolume)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Replication, err = GetReplicationConfig(lc, child, fmt.Sprintf("DB config %d", k), db.Port)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Connection, err = GetConnectionConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.Connection, db.Port)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Cloud, err = GetCloudConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.Cloud)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Retry, err = GetRetryConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.Retry)
+		if err != nil {
+			return &mt, err
+		}
+
+		db.Lock, err = GetLockConfig(lc, child, fmt.Sprintf("DB config %d", k), cnf.Lock)
+		if err != nil {
+			return &mt, err
+		}
+
+		/*The root task settings must always be set so only the database's own settings count as set, the optional
+		table retention setting also counts when it is set at the root*/
+		for _, v := range []struct {
+			path  string
+			value bool
+			set   bool
+		}{
+			{"CleanAudit", db.CleanAudit, child.ExistsP("CleanAudit")},
+			{"CleanAlerts", db.CleanAlerts, child.ExistsP("CleanAlerts")},
+			{"TableRetention.Enabled", db.TableRetention.Enabled, child.ExistsP("TableRetention.Enabled") || jp.ExistsP("TableRetention.Enabled")},
+		} {
+			if v.set && !v.value {
+				if db.setOff == nil {
+					db.setOff = make(map[string]bool)
+				}
+				db.setOff[v.path] = true
+			}
+		}
+
 		//append to slice
 		cnf.Databases = append(cnf.Databases, db)
 	}
 
 	return &cnf, nil
 }
+
+/*The following functions read optional parameters.  Optional parameters may be left out of both the root
+and the database configuration.  When a parameter is not set the value is inherited, at the root level the
+inherited value is the default and at the database level it is the root configuration.  If a parameter is set
+but cannot be parsed an error is returned, a typo should never silently fall back to the inherited value.*/
+
+//Reads an optional bool from the given path, where describes the configuration being read for logging
+func getOptionalBool(lc chan<- LogMessage, c *gabs.Container, path, where string, inherit bool) (bool, error) {
+	if !c.ExistsP(path) {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("'%s' not set for %s.  Will inherit %v", path, where, inherit), true}
+		return inherit, nil
+	}
+	b, ok := c.Path(path).Data().(bool)
+	if !ok {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse '%s' for %s, it must be true or false.  Cannot continue", path, where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return b, nil
+}
+
+//Reads an optional uint from the given path, where describes the configuration being read for logging
+func getOptionalUint(lc chan<- LogMessage, c *gabs.Container, path, where string, inherit uint) (uint, error) {
+	if !c.ExistsP(path) {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("'%s' not set for %s.  Will inherit %d", path, where, inherit), true}
+		return inherit, nil
+	}
+	tf, ok := c.Path(path).Data().(float64)
+	if !ok {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse '%s' for %s, it must be a number.  Cannot continue", path, where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	if tf < 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter '%s' for %s must be 0 or higher.  Cannot continue", path, where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return uint(tf), nil
+}
+
+//Reads an optional string from the given path, where describes the configuration being read for logging
+func getOptionalString(lc chan<- LogMessage, c *gabs.Container, path, where string, inherit string) (string, error) {
+	if !c.ExistsP(path) {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("'%s' not set for %s.  Will inherit '%s'", path, where, inherit), true}
+		return inherit, nil
+	}
+	str, ok := c.Path(path).Data().(string)
+	if !ok {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse '%s' for %s, it must be a string.  Cannot continue", path, where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return str, nil
+}
+
+//Reads an optional list of strings from the given path, where describes the configuration being read for logging
+func getOptionalStrings(lc chan<- LogMessage, c *gabs.Container, path, where string, inherit []string) ([]string, error) {
+	if !c.ExistsP(path) {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("'%s' not set for %s.  Will inherit %v", path, where, inherit), true}
+		return inherit, nil
+	}
+	list, ok := c.Path(path).Data().([]interface{})
+	if !ok {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse '%s' for %s, it must be a list of strings.  Cannot continue", path, where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	strs := make([]string, 0, len(list))
+	for _, v := range list {
+		str, ok := v.(string)
+		if !ok {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse '%s' for %s, it must be a list of strings.  Cannot continue", path, where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
+
+//Reads the optional 'TraceQuota' object.  Fields that are not set are inherited individually.
+func GetTraceQuotaConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit TraceQuotaConfig) (TraceQuotaConfig, error) {
+	var tq TraceQuotaConfig
+	var err error
+
+	tq.Enabled, err = getOptionalBool(lc, c, "TraceQuota.Enabled", where, inherit.Enabled)
+	if err != nil {
+		return inherit, err
+	}
+	tq.MaxMiBPerHost, err = getOptionalUint(lc, c, "TraceQuota.MaxMiBPerHost", where, inherit.MaxMiBPerHost)
+	if err != nil {
+		return inherit, err
+	}
+	tq.MinAgeHours, err = getOptionalUint(lc, c, "TraceQuota.MinAgeHours", where, inherit.MinAgeHours)
+	if err != nil {
+		return inherit, err
+	}
+	tq.Exclusions, err = getOptionalStrings(lc, c, "TraceQuota.Exclusions", where, inherit.Exclusions)
+	if err != nil {
+		return inherit, err
+	}
+
+	/*A quota of zero would remove every closed trace file*/
+	if tq.Enabled && tq.MaxMiBPerHost == 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'TraceQuota.MaxMiBPerHost' for %s must be greater than 0 when the trace quota is enabled.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return tq, nil
+}
+
+//Reads the optional 'Backup' object.  Fields that are not set are inherited individually.
+func GetBackupConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit BackupConfig) (BackupConfig, error) {
+	var bc BackupConfig
+	var err error
+
+	bc.RetentionMode, err = getOptionalString(lc, c, "Backup.RetentionMode", where, inherit.RetentionMode)
+	if err != nil {
+		return inherit, err
+	}
+	bc.RetainFullBackups, err = getOptionalUint(lc, c, "Backup.RetainFullBackups", where, inherit.RetainFullBackups)
+	if err != nil {
+		return inherit, err
+	}
+
+	bc.SafetyCheck, err = getOptionalBool(lc, c, "Backup.SafetyCheck", where, inherit.SafetyCheck)
+	if err != nil {
+		return inherit, err
+	}
+	bc.SafetyWindowHours, err = getOptionalUint(lc, c, "Backup.SafetyWindowHours", where, inherit.SafetyWindowHours)
+	if err != nil {
+		return inherit, err
+	}
+	bc.ExportDir, err = getOptionalString(lc, c, "Backup.ExportDir", where, inherit.ExportDir)
+	if err != nil {
+		return inherit, err
+	}
+	bc.ExportFormat, err = getOptionalString(lc, c, "Backup.ExportFormat", where, inherit.ExportFormat)
+	if err != nil {
+		return inherit, err
+	}
+	if bc.ExportFormat != "" && !ValidArchiveFormat(bc.ExportFormat) {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Backup.ExportFormat' for %s must be one of '%s' or '%s'.  Cannot continue", where, ArchiveFormatCSV, ArchiveFormatJSON), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	bc.DeleteDestinationTypes, err = getOptionalStrings(lc, c, "Backup.DeleteDestinationTypes", where, inherit.DeleteDestinationTypes)
+	if err != nil {
+		return inherit, err
+	}
+	bc.DeleteEntryTypes, err = getOptionalStrings(lc, c, "Backup.DeleteEntryTypes", where, inherit.DeleteEntryTypes)
+	if err != nil {
+		return inherit, err
+	}
+	if bc.SafetyCheck && bc.SafetyWindowHours == 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Backup.SafetyWindowHours' for %s must be 1 or higher when 'Backup.SafetyCheck' is true.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+
+	switch bc.RetentionMode {
+	case "", BackupRetentionDays:
+	case BackupRetentionCount, BackupRetentionBoth:
+		/*Keeping zero full backups would allow the whole catalog to be removed*/
+		if bc.RetainFullBackups == 0 {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Backup.RetainFullBackups' for %s must be 1 or higher when 'Backup.RetentionMode' is '%s'.  Cannot continue", where, bc.RetentionMode), false}
+			return inherit, fmt.Errorf("config error")
+		}
+	default:
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Backup.RetentionMode' for %s must be one of '%s', '%s' or '%s'.  Cannot continue", where, BackupRetentionDays, BackupRetentionCount, BackupRetentionBoth), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return bc, nil
+}
+
+//Reads the optional 'Audit' object.  Fields that are not set are inherited individually.
+func GetAuditConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit AuditConfig) (AuditConfig, error) {
+	var ac AuditConfig
+	var err error
+
+	ac.ArchiveDir, err = getOptionalString(lc, c, "Audit.ArchiveDir", where, inherit.ArchiveDir)
+	if err != nil {
+		return inherit, err
+	}
+	ac.ArchiveFormat, err = getOptionalString(lc, c, "Audit.ArchiveFormat", where, inherit.ArchiveFormat)
+	if err != nil {
+		return inherit, err
+	}
+	ac.Rules = inherit.Rules
+	if c.ExistsP("Audit.Rules") {
+		ac.Rules, err = getAuditRules(lc, c.Path("Audit.Rules"), where)
+		if err != nil {
+			return inherit, err
+		}
+	}
+	if ac.ArchiveFormat != "" && ac.ArchiveFormat != ArchiveFormatJSONL && ac.ArchiveFormat != ArchiveFormatCSV {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Audit.ArchiveFormat' for %s must be one of '%s' or '%s'.  Cannot continue", where, ArchiveFormatJSONL, ArchiveFormatCSV), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return ac, nil
+}
+
+//Reads a list of audit retention rules.  Every rule must have a unique name, a retention and at least one field to match on.
+func getAuditRules(lc chan<- LogMessage, c *gabs.Container, where string) ([]AuditRule, error) {
+	if _, ok := c.Data().([]interface{}); !ok {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse 'Audit.Rules' for %s, it must be a list.  Cannot continue", where), false}
+		return nil, fmt.Errorf("config error")
+	}
+
+	rules := []AuditRule{}
+	names := make(map[string]bool)
+	for k, child := range c.Children() {
+		var r AuditRule
+		var err error
+		rw := fmt.Sprintf("%s audit rule %d", where, k)
+
+		if r.Name, err = getOptionalString(lc, child, "Name", rw, ""); err != nil {
+			return nil, err
+		}
+		if r.PolicyName, err = getOptionalString(lc, child, "PolicyName", rw, ""); err != nil {
+			return nil, err
+		}
+		if r.EventAction, err = getOptionalString(lc, child, "EventAction", rw, ""); err != nil {
+			return nil, err
+		}
+		if r.EventLevel, err = getOptionalString(lc, child, "EventLevel", rw, ""); err != nil {
+			return nil, err
+		}
+		if r.RetainDays, err = getOptionalUint(lc, child, "RetainDays", rw, 0); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case r.Name == "" || names[r.Name]:
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Name' for %s must be set and unique.  Cannot continue", rw), false}
+			return nil, fmt.Errorf("config error")
+		case !child.ExistsP("RetainDays"):
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'RetainDays' for %s must be set.  Cannot continue", rw), false}
+			return nil, fmt.Errorf("config error")
+		case r.PolicyName == "" && r.EventAction == "" && r.EventLevel == "":
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("One of 'PolicyName', 'EventAction' or 'EventLevel' must be set for %s.  Cannot continue", rw), false}
+			return nil, fmt.Errorf("config error")
+		}
+		names[r.Name] = true
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+//Reads the optional 'DataVolume' object.  Fields that are not set are inherited individually, volumes inherit unset
+//thresholds from the database.
+func GetDataVolumeConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit DataVolumeConfig) (DataVolumeConfig, error) {
+	var dc DataVolumeConfig
+	var err error
+
+	dc.FreeTriggerPercent, dc.MinFreeMiB, dc.ReclaimTargetPercent, err = getDataVolumeThresholds(lc, c, "DataVolume.", where, inherit.FreeTriggerPercent, inherit.MinFreeMiB, inherit.ReclaimTargetPercent)
+	if err != nil {
+		return inherit, err
+	}
+
+	dc.Gates, err = getDataVolumeGates(lc, c, where, inherit.Gates)
+	if err != nil {
+		return inherit, err
+	}
+
+	dc.Volumes = inherit.Volumes
+	if c.ExistsP("DataVolume.Volumes") {
+		if _, ok := c.Path("DataVolume.Volumes").Data().([]interface{}); !ok {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse 'DataVolume.Volumes' for %s, it must be a list.  Cannot continue", where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+		dc.Volumes = []DataVolumeOverride{}
+		for k, child := range c.Path("DataVolume.Volumes").Children() {
+			var v DataVolumeOverride
+			vw := fmt.Sprintf("%s data volume %d", where, k)
+			v.Volume, err = getOptionalString(lc, child, "Volume", vw, "")
+			if err != nil {
+				return inherit, err
+			}
+			parts := strings.Split(v.Volume, ":")
+			if len(parts) != 2 || parts[0] == "" {
+				lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Volume' for %s must be set as host:port.  Cannot continue", vw), false}
+				return inherit, fmt.Errorf("config error")
+			}
+			if _, err := strconv.ParseUint(parts[1], 10, 16); err != nil {
+				lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Volume' for %s must be set as host:port.  Cannot continue", vw), false}
+				return inherit, fmt.Errorf("config error")
+			}
+			v.FreeTriggerPercent, v.MinFreeMiB, v.ReclaimTargetPercent, err = getDataVolumeThresholds(lc, child, "", vw, dc.FreeTriggerPercent, dc.MinFreeMiB, dc.ReclaimTargetPercent)
+			if err != nil {
+				return inherit, err
+			}
+			dc.Volumes = append(dc.Volumes, v)
+		}
+	}
+	return dc, nil
+}
+
+//Reads and validates the data volume thresholds found under prefix
+func getDataVolumeThresholds(lc chan<- LogMessage, c *gabs.Container, prefix, where string, trigger, minFree, target uint) (uint, uint, uint, error) {
+	var err error
+	trigger, err = getOptionalUint(lc, c, prefix+"FreeTriggerPercent", where, trigger)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	minFree, err = getOptionalUint(lc, c, prefix+"MinFreeMiB", where, minFree)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	target, err = getOptionalUint(lc, c, prefix+"ReclaimTargetPercent", where, target)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if trigger > 99 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter '%sFreeTriggerPercent' for %s must be between 1 and 99.  Cannot continue", prefix, where), false}
+		return 0, 0, 0, fmt.Errorf("config error")
+	}
+	/*HANA cannot shrink a data volume below its used size*/
+	if target != 0 && target <= 100 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter '%sReclaimTargetPercent' for %s must be greater than 100.  Cannot continue", prefix, where), false}
+		return 0, 0, 0, fmt.Errorf("config error")
+	}
+	return trigger, minFree, target, nil
+}
+
+//Reads the optional data volume gates, fields that are not set are inherited individually
+func getDataVolumeGates(lc chan<- LogMessage, c *gabs.Container, where string, inherit DataVolumeGates) (DataVolumeGates, error) {
+	var g DataVolumeGates
+	var err error
+
+	if g.Window, err = getOptionalString(lc, c, "DataVolume.Gates.Window", where, inherit.Window); err != nil {
+		return inherit, err
+	}
+	if g.MaxCPUPercent, err = getOptionalUint(lc, c, "DataVolume.Gates.MaxCPUPercent", where, inherit.MaxCPUPercent); err != nil {
+		return inherit, err
+	}
+	if g.MaxMemoryPercent, err = getOptionalUint(lc, c, "DataVolume.Gates.MaxMemoryPercent", where, inherit.MaxMemoryPercent); err != nil {
+		return inherit, err
+	}
+	if g.NoRunningBackup, err = getOptionalBool(lc, c, "DataVolume.Gates.NoRunningBackup", where, inherit.NoRunningBackup); err != nil {
+		return inherit, err
+	}
+	if g.MaxSavepointSeconds, err = getOptionalUint(lc, c, "DataVolume.Gates.MaxSavepointSeconds", where, inherit.MaxSavepointSeconds); err != nil {
+		return inherit, err
+	}
+
+	if g.Window != "" {
+		if _, _, err := ParseWindow(g.Window); err != nil {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'DataVolume.Gates.Window' for %s is invalid, %s.  Cannot continue", where, err.Error()), false}
+			return inherit, fmt.Errorf("config error")
+		}
+	}
+	if g.MaxCPUPercent > 100 || g.MaxMemoryPercent > 100 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameters 'DataVolume.Gates.MaxCPUPercent' and 'DataVolume.Gates.MaxMemoryPercent' for %s must be 100 or lower.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return g, nil
+}
+
+//Reads the optional 'TableOptimise' object.  Fields that are not set are inherited individually.
+func GetTableOptimiseConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit TableOptimiseConfig) (TableOptimiseConfig, error) {
+	var to TableOptimiseConfig
+	var err error
+
+	if to.Enabled, err = getOptionalBool(lc, c, "TableOptimise.Enabled", where, inherit.Enabled); err != nil {
+		return inherit, err
+	}
+	if to.DeltaTriggerMiB, err = getOptionalUint(lc, c, "TableOptimise.DeltaTriggerMiB", where, inherit.DeltaTriggerMiB); err != nil {
+		return inherit, err
+	}
+	if to.CompressionTriggerPercent, err = getOptionalUint(lc, c, "TableOptimise.CompressionTriggerPercent", where, inherit.CompressionTriggerPercent); err != nil {
+		return inherit, err
+	}
+	if to.MinTableMiB, err = getOptionalUint(lc, c, "TableOptimise.MinTableMiB", where, inherit.MinTableMiB); err != nil {
+		return inherit, err
+	}
+	if to.MaxTables, err = getOptionalUint(lc, c, "TableOptimise.MaxTables", where, inherit.MaxTables); err != nil {
+		return inherit, err
+	}
+	if to.IncludeSchemas, err = getOptionalStrings(lc, c, "TableOptimise.IncludeSchemas", where, inherit.IncludeSchemas); err != nil {
+		return inherit, err
+	}
+	if to.ExcludeSchemas, err = getOptionalStrings(lc, c, "TableOptimise.ExcludeSchemas", where, inherit.ExcludeSchemas); err != nil {
+		return inherit, err
+	}
+
+	/*The trigger is a percentage of the uncompressed size*/
+	if to.CompressionTriggerPercent > 100 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'TableOptimise.CompressionTriggerPercent' for %s must be between 0 and 100.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	for _, v := range to.IncludeSchemas {
+		if len(to.ExcludeSchemas) > 0 && matchesAny(to.ExcludeSchemas, v) {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Schema %s for %s is in both 'TableOptimise.IncludeSchemas' and 'TableOptimise.ExcludeSchemas'.  Cannot continue", v, where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+	}
+	return to, nil
+}
+
+//Reads the optional 'IdleSessions' object.  Fields that are not set are inherited individually.
+func GetIdleSessionConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit IdleSessionConfig) (IdleSessionConfig, error) {
+	var is IdleSessionConfig
+	var err error
+
+	if is.Enabled, err = getOptionalBool(lc, c, "IdleSessions.Enabled", where, inherit.Enabled); err != nil {
+		return inherit, err
+	}
+	if is.IdleMinutes, err = getOptionalUint(lc, c, "IdleSessions.IdleMinutes", where, inherit.IdleMinutes); err != nil {
+		return inherit, err
+	}
+	if is.Users, err = getOptionalStrings(lc, c, "IdleSessions.Users", where, inherit.Users); err != nil {
+		return inherit, err
+	}
+	if is.Applications, err = getOptionalStrings(lc, c, "IdleSessions.Applications", where, inherit.Applications); err != nil {
+		return inherit, err
+	}
+	if is.ClientHosts, err = getOptionalStrings(lc, c, "IdleSessions.ClientHosts", where, inherit.ClientHosts); err != nil {
+		return inherit, err
+	}
+	if is.ProtectedUsers, err = getOptionalStrings(lc, c, "IdleSessions.ProtectedUsers", where, inherit.ProtectedUsers); err != nil {
+		return inherit, err
+	}
+
+	/*Without a threshold every idle session would be disconnected*/
+	if is.Enabled && is.IdleMinutes == 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'IdleSessions.IdleMinutes' for %s must be greater than 0 when idle session disconnection is enabled.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return is, nil
+}
+
+//Reads the optional 'MonitoringReset' object.  Fields that are not set are inherited individually.
+func GetMonitoringResetConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit MonitoringResetConfig) (MonitoringResetConfig, error) {
+	var mr MonitoringResetConfig
+	var err error
+
+	if mr.Enabled, err = getOptionalBool(lc, c, "MonitoringReset.Enabled", where, inherit.Enabled); err != nil {
+		return inherit, err
+	}
+	if mr.Views, err = getOptionalStrings(lc, c, "MonitoringReset.Views", where, inherit.Views); err != nil {
+		return inherit, err
+	}
+	if mr.MinRows, err = getOptionalUint(lc, c, "MonitoringReset.MinRows", where, inherit.MinRows); err != nil {
+		return inherit, err
+	}
+	if mr.MinAgeDays, err = getOptionalUint(lc, c, "MonitoringReset.MinAgeDays", where, inherit.MinAgeDays); err != nil {
+		return inherit, err
+	}
+
+	/*View names are used in SQL, only the resettable views are accepted*/
+	for _, v := range mr.Views {
+		if !isResetViewName(v) {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("View %s in 'MonitoringReset.Views' for %s is not a resettable monitoring view, the name must start with M_ and end with _RESET.  Cannot continue", v, where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+	}
+	return mr, nil
+}
+
+//Returns true if the name is an upper case monitoring view name ending in _RESET
+func isResetViewName(name string) bool {
+	if !strings.HasPrefix(name, "M_") || !strings.HasSuffix(name, "_RESET") {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+//Reads the optional 'TraceLevels' object.  Fields that are not set are inherited individually.
+func GetTraceLevelConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit TraceLevelConfig) (TraceLevelConfig, error) {
+	var tl TraceLevelConfig
+	var err error
+
+	if tl.Enabled, err = getOptionalBool(lc, c, "TraceLevels.Enabled", where, inherit.Enabled); err != nil {
+		return inherit, err
+	}
+	if tl.Baseline, err = getOptionalString(lc, c, "TraceLevels.Baseline", where, inherit.Baseline); err != nil {
+		return inherit, err
+	}
+	if tl.GraceHours, err = getOptionalUint(lc, c, "TraceLevels.GraceHours", where, inherit.GraceHours); err != nil {
+		return inherit, err
+	}
+	if tl.Files, err = getOptionalStrings(lc, c, "TraceLevels.Files", where, inherit.Files); err != nil {
+		return inherit, err
+	}
+
+	/*Debug is the highest level, so there would be nothing to reset*/
+	if tl.Baseline != "" && len(tl.ElevatedLevels()) == 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'TraceLevels.Baseline' for %s must be one of none, fatal, error, warning or info.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return tl, nil
+}
+
+//Reads the optional 'PlanCache' object.  Fields that are not set are inherited individually.
+func GetPlanCacheConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit PlanCacheConfig) (PlanCacheConfig, error) {
+	var pc PlanCacheConfig
+	var err error
+
+	if pc.Enabled, err = getOptionalBool(lc, c, "PlanCache.Enabled", where, inherit.Enabled); err != nil {
+		return inherit, err
+	}
+	if pc.MaxUsagePercent, err = getOptionalUint(lc, c, "PlanCache.MaxUsagePercent", where, inherit.MaxUsagePercent); err != nil {
+		return inherit, err
+	}
+	if pc.MaxSizeMiB, err = getOptionalUint(lc, c, "PlanCache.MaxSizeMiB", where, inherit.MaxSizeMiB); err != nil {
+		return inherit, err
+	}
+	if pc.MaxEvictionsPerHour, err = getOptionalUint(lc, c, "PlanCache.MaxEvictionsPerHour", where, inherit.MaxEvictionsPerHour); err != nil {
+		return inherit, err
+	}
+	if pc.Schemas, err = getOptionalStrings(lc, c, "PlanCache.Schemas", where, inherit.Schemas); err != nil {
+		return inherit, err
+	}
+	if pc.Users, err = getOptionalStrings(lc, c, "PlanCache.Users", where, inherit.Users); err != nil {
+		return inherit, err
+	}
+	if pc.ClearResultCache, err = getOptionalBool(lc, c, "PlanCache.ClearResultCache", where, inherit.ClearResultCache); err != nil {
+		return inherit, err
+	}
+
+	/*Without a limit the plan cache would be cleared on every run*/
+	if pc.Enabled && pc.MaxUsagePercent == 0 && pc.MaxSizeMiB == 0 && pc.MaxEvictionsPerHour == 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("At least one of 'PlanCache.MaxUsagePercent', 'PlanCache.MaxSizeMiB' or 'PlanCache.MaxEvictionsPerHour' must be set for %s when plan cache clearing is enabled.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	if pc.MaxUsagePercent > 100 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'PlanCache.MaxUsagePercent' for %s must be between 0 and 100.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return pc, nil
+}
+
+//Reads the optional 'TableRetention' object.  Fields that are not set are inherited individually, a list of tables
+//replaces the inherited list rather than adding to it.
+func GetTableRetentionConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit TableRetentionConfig) (TableRetentionConfig, error) {
+	var tr TableRetentionConfig
+	var err error
+
+	if tr.Enabled, err = getOptionalBool(lc, c, "TableRetention.Enabled", where, inherit.Enabled); err != nil {
+		return inherit, err
+	}
+	if tr.BatchSize, err = getOptionalUint(lc, c, "TableRetention.BatchSize", where, inherit.BatchSize); err != nil {
+		return inherit, err
+	}
+	tr.Tables = inherit.Tables
+	if c.ExistsP("TableRetention.Tables") {
+		tr.Tables, err = getRetentionTables(lc, c.Path("TableRetention.Tables"), where)
+		if err != nil {
+			return inherit, err
+		}
+	}
+
+	if tr.Enabled && len(tr.Tables) == 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'TableRetention.Tables' for %s must list at least one table when table retention is enabled.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return tr, nil
+}
+
+//Reads a list of retention tables.  Every field must be set, the retention must be at least one day and each table
+//may only be listed once.
+func getRetentionTables(lc chan<- LogMessage, c *gabs.Container, where string) ([]RetentionTable, error) {
+	if _, ok := c.Data().([]interface{}); !ok {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse 'TableRetention.Tables' for %s, it must be a list.  Cannot continue", where), false}
+		return nil, fmt.Errorf("config error")
+	}
+
+	tables := []RetentionTable{}
+	seen := make(map[string]bool)
+	for k, child := range c.Children() {
+		var t RetentionTable
+		var err error
+		tw := fmt.Sprintf("%s retention table %d", where, k)
+
+		if t.Schema, err = getOptionalString(lc, child, "Schema", tw, ""); err != nil {
+			return nil, err
+		}
+		if t.Table, err = getOptionalString(lc, child, "Table", tw, ""); err != nil {
+			return nil, err
+		}
+		if t.TimestampColumn, err = getOptionalString(lc, child, "TimestampColumn", tw, ""); err != nil {
+			return nil, err
+		}
+		if t.RetainDays, err = getOptionalUint(lc, child, "RetainDays", tw, 0); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case t.Schema == "" || t.Table == "" || t.TimestampColumn == "":
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameters 'Schema', 'Table' and 'TimestampColumn' must be set for %s.  Cannot continue", tw), false}
+			return nil, fmt.Errorf("config error")
+		case t.RetainDays == 0:
+			/*A retention of zero days would empty the table*/
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'RetainDays' for %s must be at least 1.  Cannot continue", tw), false}
+			return nil, fmt.Errorf("config error")
+		case seen[t.String()]:
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("The table %s is listed more than once for %s.  Cannot continue", t, where), false}
+			return nil, fmt.Errorf("config error")
+		}
+		seen[t.String()] = true
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
+
+//Reads the optional 'Alerts' object.  Fields that are not set are inherited individually.
+func GetAlertConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit AlertConfig) (AlertConfig, error) {
+	var ac AlertConfig
+	var err error
+
+	if ac.BatchRows, err = getOptionalUint(lc, c, "Alerts.BatchRows", where, inherit.BatchRows); err != nil {
+		return inherit, err
+	}
+	if ac.SliceHours, err = getOptionalUint(lc, c, "Alerts.SliceHours", where, inherit.SliceHours); err != nil {
+		return inherit, err
+	}
+	if ac.MaxRunMinutes, err = getOptionalUint(lc, c, "Alerts.MaxRunMinutes", where, inherit.MaxRunMinutes); err != nil {
+		return inherit, err
+	}
+	if ac.RetainDaysByID, err = getOptionalDaysMap(lc, c, "Alerts.RetainDaysByID", where, inherit.RetainDaysByID); err != nil {
+		return inherit, err
+	}
+	if ac.RetainDaysByRating, err = getOptionalDaysMap(lc, c, "Alerts.RetainDaysByRating", where, inherit.RetainDaysByRating); err != nil {
+		return inherit, err
+	}
+
+	if ac.BatchRows > 0 && ac.SliceHours > 0 {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Only one of 'Alerts.BatchRows' or 'Alerts.SliceHours' may be set for %s.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	for rating := range ac.RetainDaysByRating {
+		if rating < 1 || rating > 5 {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Alert rating %d in 'Alerts.RetainDaysByRating' for %s must be between 1 and 5.  Cannot continue", rating, where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+	}
+	/*The budget is checked between batches, a single delete can't be stopped*/
+	if ac.MaxRunMinutes > 0 && !ac.Batched() {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Alerts.MaxRunMinutes' for %s requires 'Alerts.BatchRows' or 'Alerts.SliceHours' to be set.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return ac, nil
+}
+
+//Reads an optional object that maps numbers, such as alert IDs, to a number of days to retain.  Every key must be a
+//whole number and every retention at least one day.  A map that is set replaces the inherited map.
+func getOptionalDaysMap(lc chan<- LogMessage, c *gabs.Container, path, where string, inherit map[uint]uint) (map[uint]uint, error) {
+	if !c.ExistsP(path) {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("'%s' not set for %s.  Will inherit %v", path, where, inherit), true}
+		return inherit, nil
+	}
+	if _, ok := c.Path(path).Data().(map[string]interface{}); !ok {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse '%s' for %s, it must be an object.  Cannot continue", path, where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+
+	days := make(map[uint]uint)
+	for k, child := range c.Path(path).ChildrenMap() {
+		key, err := strconv.ParseUint(k, 10, 32)
+		if err != nil {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Key '%s' in '%s' for %s must be a whole number.  Cannot continue", k, path, where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+		tf, ok := child.Data().(float64)
+		if !ok || tf < 1 {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Retention for '%s' in '%s' for %s must be a number of at least 1.  Cannot continue", k, path, where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+		days[uint(key)] = uint(tf)
+	}
+	return days, nil
+}
+
+//Reads the optional 'LogVolume' object.  Fields that are not set are inherited individually.
+func GetLogVolumeConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit LogVolumeConfig) (LogVolumeConfig, error) {
+	var lv LogVolumeConfig
+	var err error
+
+	if lv.RequireLogMode, err = getOptionalString(lc, c, "LogVolume.RequireLogMode", where, inherit.RequireLogMode); err != nil {
+		return inherit, err
+	}
+	if lv.MaxLogBackupAgeHours, err = getOptionalUint(lc, c, "LogVolume.MaxLogBackupAgeHours", where, inherit.MaxLogBackupAgeHours); err != nil {
+		return inherit, err
+	}
+	if lv.MinFreeSegments, err = getOptionalUint(lc, c, "LogVolume.MinFreeSegments", where, inherit.MinFreeSegments); err != nil {
+		return inherit, err
+	}
+	if lv.MinFreeMiB, err = getOptionalUint(lc, c, "LogVolume.MinFreeMiB", where, inherit.MinFreeMiB); err != nil {
+		return inherit, err
+	}
+
+	lv.RequireLogMode = strings.ToLower(lv.RequireLogMode)
+	if lv.RequireLogMode != "" && lv.RequireLogMode != LogModeNormal && lv.RequireLogMode != LogModeOverwrite {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'LogVolume.RequireLogMode' for %s must be one of '%s' or '%s'.  Cannot continue", where, LogModeNormal, LogModeOverwrite), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return lv, nil
+}
+
+//Reads the optional 'Replication' object.  Candidate hosts are specific to a database so nothing is inherited
+//from the root config.  Port is the database port that candidate hosts without a port default to.
+func GetReplicationConfig(lc chan<- LogMessage, c *gabs.Container, where string, port uint) (ReplicationConfig, error) {
+	var rc ReplicationConfig
+	var err error
+
+	if rc.CandidateHosts, err = getHostList(lc, c, "Replication.CandidateHosts", where, port); err != nil {
+		return ReplicationConfig{}, err
+	}
+	return rc, nil
+}
+
+//Reads the optional 'Connection' object.  Fields that are not set are inherited individually, except failover hosts
+//which are specific to a database.  Port is the database port that failover hosts without a port default to.
+func GetConnectionConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit ConnectionConfig, port uint) (ConnectionConfig, error) {
+	var cc ConnectionConfig
+	var err error
+
+	if cc.FailoverHosts, err = getHostList(lc, c, "Connection.FailoverHosts", where, port); err != nil {
+		return inherit, err
+	}
+	if cc.TLS, err = getOptionalString(lc, c, "Connection.TLS", where, inherit.TLS); err != nil {
+		return inherit, err
+	}
+	if cc.TLSRootCAFile, err = getOptionalString(lc, c, "Connection.TLSRootCAFile", where, inherit.TLSRootCAFile); err != nil {
+		return inherit, err
+	}
+	if cc.MaxOpenConnections, err = getOptionalUint(lc, c, "Connection.MaxOpenConnections", where, inherit.MaxOpenConnections); err != nil {
+		return inherit, err
+	}
+	if cc.MaxIdleConnections, err = getOptionalUint(lc, c, "Connection.MaxIdleConnections", where, inherit.MaxIdleConnections); err != nil {
+		return inherit, err
+	}
+	if cc.ConnectionLifetimeMinutes, err = getOptionalUint(lc, c, "Connection.ConnectionLifetimeMinutes", where, inherit.ConnectionLifetimeMinutes); err != nil {
+		return inherit, err
+	}
+	if cc.ConnectTimeoutSeconds, err = getOptionalUint(lc, c, "Connection.ConnectTimeoutSeconds", where, inherit.ConnectTimeoutSeconds); err != nil {
+		return inherit, err
+	}
+	if cc.ConnectAttempts, err = getOptionalUint(lc, c, "Connection.ConnectAttempts", where, inherit.ConnectAttempts); err != nil {
+		return inherit, err
+	}
+	if cc.ConnectBackoffSeconds, err = getOptionalUint(lc, c, "Connection.ConnectBackoffSeconds", where, inherit.ConnectBackoffSeconds); err != nil {
+		return inherit, err
+	}
+
+	cc.TLS = strings.ToLower(cc.TLS)
+	if cc.TLS != "" && cc.TLS != TLSModeAuto && cc.TLS != TLSModeOn && cc.TLS != TLSModeOff {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Connection.TLS' for %s must be one of '%s', '%s' or '%s'.  Cannot continue", where, TLSModeAuto, TLSModeOn, TLSModeOff), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	if cc.TLSRootCAFile != "" && cc.TLS == TLSModeOff {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Connection.TLSRootCAFile' for %s cannot be used with TLS off.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	if cc.MaxOpenConnections > 0 && cc.MaxIdleConnections > cc.MaxOpenConnections {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter 'Connection.MaxIdleConnections' for %s cannot be more than 'Connection.MaxOpenConnections'.  Cannot continue", where), false}
+		return inherit, fmt.Errorf("config error")
+	}
+	return cc, nil
+}
+
+//Reads the optional 'Cloud' object.  Fields that are not set are inherited individually.
+func GetCloudConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit CloudConfig) (CloudConfig, error) {
+	var cc CloudConfig
+	var err error
+
+	if cc.EnableTasks, err = getOptionalBool(lc, c, "Cloud.EnableTasks", where, inherit.EnableTasks); err != nil {
+		return inherit, err
+	}
+	return cc, nil
+}
+
+//Reads the optional 'Retry' object.  Fields that are not set are inherited individually, including the fields of
+//each task in 'Retry.Tasks'.  Only the tasks in RetryTasks can be given their own policy.
+func GetRetryConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit RetryConfig) (RetryConfig, error) {
+	var rc RetryConfig
+	var err error
+
+	if rc.MaxAttempts, rc.BackoffSeconds, rc.MaxBackoffSeconds, err = getRetryPolicy(lc, c, "Retry", where, inherit); err != nil {
+		return inherit, err
+	}
+
+	if c.ExistsP("Retry.Tasks") {
+		if _, ok := c.Path("Retry.Tasks").Data().(map[string]interface{}); !ok {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Could not parse 'Retry.Tasks' for %s, it must be an object.  Cannot continue", where), false}
+			return inherit, fmt.Errorf("config error")
+		}
+		for k := range c.Path("Retry.Tasks").ChildrenMap() {
+			known := false
+			for _, task := range RetryTasks {
+				known = known || k == task
+			}
+			if !known {
+				lc <- LogMessage{"HccConfig", fmt.Sprintf("Key '%s' in 'Retry.Tasks' for %s must be one of %s.  Cannot continue", k, where, strings.Join(RetryTasks, ", ")), false}
+				return inherit, fmt.Errorf("config error")
+			}
+		}
+	}
+	for _, task := range RetryTasks {
+		var t RetryConfig
+		if t.MaxAttempts, t.BackoffSeconds, t.MaxBackoffSeconds, err = getRetryPolicy(lc, c, fmt.Sprintf("Retry.Tasks.%s", task), where, inherit.Tasks[task]); err != nil {
+			return inherit, err
+		}
+		if t.MaxAttempts > 0 || t.BackoffSeconds > 0 || t.MaxBackoffSeconds > 0 {
+			if rc.Tasks == nil {
+				rc.Tasks = make(map[string]RetryConfig)
+			}
+			rc.Tasks[task] = t
+		}
+	}
+	return rc, nil
+}
+
+//Reads the attempts and backoff of a retry policy at path
+func getRetryPolicy(lc chan<- LogMessage, c *gabs.Container, path, where string, inherit RetryConfig) (uint, uint, uint, error) {
+	attempts, err := getOptionalUint(lc, c, path+".MaxAttempts", where, inherit.MaxAttempts)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	backoff, err := getOptionalUint(lc, c, path+".BackoffSeconds", where, inherit.BackoffSeconds)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	maxBackoff, err := getOptionalUint(lc, c, path+".MaxBackoffSeconds", where, inherit.MaxBackoffSeconds)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if backoff > 0 && maxBackoff > 0 && backoff > maxBackoff {
+		lc <- LogMessage{"HccConfig", fmt.Sprintf("Parameter '%s.BackoffSeconds' for %s cannot be more than '%s.MaxBackoffSeconds'.  Cannot continue", path, where, path), false}
+		return 0, 0, 0, fmt.Errorf("config error")
+	}
+	return attempts, backoff, maxBackoff, nil
+}
+
+//Reads the optional 'Lock' object.  Fields that are not set are inherited individually.
+func GetLockConfig(lc chan<- LogMessage, c *gabs.Container, where string, inherit LockConfig) (LockConfig, error) {
+	var lk LockConfig
+	var err error
+
+	if lk.File, err = getOptionalBool(lc, c, "Lock.File", where, inherit.File); err != nil {
+		return inherit, err
+	}
+	if lk.Dir, err = getOptionalString(lc, c, "Lock.Dir", where, inherit.Dir); err != nil {
+		return inherit, err
+	}
+	if lk.Database, err = getOptionalBool(lc, c, "Lock.Database", where, inherit.Database); err != nil {
+		return inherit, err
+	}
+	if lk.TTLMinutes, err = getOptionalUint(lc, c, "Lock.TTLMinutes", where, inherit.TTLMinutes); err != nil {
+		return inherit, err
+	}
+	return lk, nil
+}
+
+//Reads an optional list of hosts given as "host" or "host:port".  Each host must be valid and listed only once.
+func getHostList(lc chan<- LogMessage, c *gabs.Container, path, where string, port uint) ([]string, error) {
+	hosts, err := getOptionalStrings(lc, c, path, where, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range hosts {
+		h, err := parseCandidateHost(v, port)
+		if err != nil {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Host '%s' in '%s' for %s is not valid, %s.  Cannot continue", v, path, where, err.Error()), false}
+			return nil, fmt.Errorf("config error")
+		}
+		if seen[h.String()] {
+			lc <- LogMessage{"HccConfig", fmt.Sprintf("Host '%s' is listed more than once in '%s' for %s.  Cannot continue", v, path, where), false}
+			return nil, fmt.Errorf("config error")
+		}
+		seen[h.String()] = true
+	}
+	return hosts, nil
+}
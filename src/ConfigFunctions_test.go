@@ -24,8 +24,8 @@ func TestGetConfigFromFile(t *testing.T) {
 		want    *Config
 		wantErr bool
 	}{
-		{"GoodFile01", args{lc, "testFiles/configtest01.json"}, &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "sstringer", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}}}, false},
-		{"GoodFile02", args{lc, "testFiles/configtest02.json"}, &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "sstringer", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}, {"Ten01_TST", "hanadb.mydomain.int", 30041, "sstringer", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, false, 0, true, nil, CleanResults{}}}}, false},
+		{"GoodFile01", args{lc, "testFiles/configtest01.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}}}}, false},
+		{"GoodFile02", args{lc, "testFiles/configtest02.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanDataVolume: true, Lock: LockConfig{File: true}, setOff: map[string]bool{"CleanAudit": true}}}}, false},
 		{"NoRootCleanTrace", args{lc, "testFiles/NoRootCleanTrace.json"}, &Config{}, true},
 		{"NoRootRetainTraceDays", args{lc, "testFiles/NoRootRetainTraceDays.json"}, &Config{}, true},
 		{"NoRootCleanBackupCatalog", args{lc, "testFiles/NoRootCleanBackupCatalog.json"}, &Config{}, true},
@@ -44,14 +44,76 @@ func TestGetConfigFromFile(t *testing.T) {
 		{"NoDbHostname", args{lc, "testFiles/NoDbHostname.json"}, &Config{}, true},
 		{"NoDbPort", args{lc, "testFiles/NoDbPort.json"}, &Config{}, true},
 		{"NoDbUsername", args{lc, "testFiles/NoDbUsername.json"}, &Config{}, true},
-		{"NoDbPassword", args{lc, "testFiles/NoDbPassword.json"}, &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "sstringer", "", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}}}, false},
+		{"NoDbPassword", args{lc, "testFiles/NoDbPassword.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}}}}, false},
 		{"NegativeDbPort", args{lc, "testFiles/NegativeDbPort.json"}, &Config{}, true},
 		{"NegativeDbRetainTraceDays", args{lc, "testFiles/NegativeDbRetainTraceDays.json"}, &Config{}, true},
 		{"NegativeDbRetainAlertsDays", args{lc, "testFiles/NegativeDbRetainAlertsDays.json"}, &Config{}, true},
 		{"NegativeDbRetainBackupCatalogDays", args{lc, "testFiles/NegativeDbRetainBackupCatalogDays.json"}, &Config{}, true},
 		{"NegativeDbRetainAuditDays", args{lc, "testFiles/NegativeDbRetainAuditDays.json"}, &Config{}, true},
 		{"NoDbUsername", args{lc, "testFiles/NoDbUsername.json"}, &Config{}, true},
-		{"DbOveride", args{lc, "testFiles/DbOverride.json"}, &Config{false, 0, false, 0, false, false, 0, false, false, 0, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "sstringer", "ReallyCoolPassw0rd", true, 30, true, 30, true, true, 30, true, true, 30, true, nil, CleanResults{}}}}, false},
+		{"DbOveride", args{lc, "testFiles/DbOverride.json"}, &Config{CleanDataVolume: true, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 30, CleanBackupCatalog: true, RetainBackupCatalogDays: 30, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 30, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 30, CleanDataVolume: true, Lock: LockConfig{File: true}}}}, false},
+		{"TraceQuota", args{lc, "testFiles/TraceQuota.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TraceQuota: TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 2048, MinAgeHours: 24, Exclusions: []string{"*_alert_*.trc"}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TraceQuota: TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 2048, MinAgeHours: 24, Exclusions: []string{"*_alert_*.trc"}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TraceQuota: TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 512, MinAgeHours: 24, Exclusions: []string{"*_alert_*.trc"}}, Lock: LockConfig{File: true}}}}, false},
+		{"TraceQuotaNoMax", args{lc, "testFiles/TraceQuotaNoMax.json"}, &Config{}, true},
+		{"NegativeDbTraceQuota", args{lc, "testFiles/NegativeDbTraceQuota.json"}, &Config{}, true},
+		{"InvalidTraceQuotaExclusions", args{lc, "testFiles/InvalidTraceQuotaExclusions.json"}, &Config{}, true},
+		{"BackupCount", args{lc, "testFiles/BackupCount.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{RetentionMode: "count", RetainFullBackups: 3}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{RetentionMode: "count", RetainFullBackups: 3}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{RetentionMode: "both", RetainFullBackups: 3}, Lock: LockConfig{File: true}}}}, false},
+		{"BackupInvalidMode", args{lc, "testFiles/BackupInvalidMode.json"}, &Config{}, true},
+		{"BackupCountNoRetain", args{lc, "testFiles/BackupCountNoRetain.json"}, &Config{}, true},
+		{"BackupSafety", args{lc, "testFiles/BackupSafety.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{SafetyCheck: true, SafetyWindowHours: 48}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{SafetyCheck: true, SafetyWindowHours: 48}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{SafetyCheck: true, SafetyWindowHours: 24}, Lock: LockConfig{File: true}}}}, false},
+		{"BackupSafetyNoWindow", args{lc, "testFiles/BackupSafetyNoWindow.json"}, &Config{}, true},
+		{"BackupExport", args{lc, "testFiles/BackupExport.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{ExportDir: "/var/lib/hcc/catalog", ExportFormat: "csv"}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{ExportDir: "/var/lib/hcc/catalog", ExportFormat: "csv"}, Lock: LockConfig{File: true}}}}, false},
+		{"BackupInvalidExportFormat", args{lc, "testFiles/BackupInvalidExportFormat.json"}, &Config{}, true},
+		{"BackupDeleteTypes", args{lc, "testFiles/BackupDeleteTypes.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{DeleteDestinationTypes: []string{"file"}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Backup: BackupConfig{DeleteDestinationTypes: []string{"file"}, DeleteEntryTypes: []string{"log backup"}}, Lock: LockConfig{File: true}}}}, false},
+		{"AuditArchive", args{lc, "testFiles/AuditArchive.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Audit: AuditConfig{ArchiveDir: "/var/lib/hcc/audit"}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Audit: AuditConfig{ArchiveDir: "/var/lib/hcc/audit"}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Audit: AuditConfig{ArchiveDir: "/var/lib/hcc/audit", ArchiveFormat: "csv"}, Lock: LockConfig{File: true}}}}, false},
+		{"AuditInvalidFormat", args{lc, "testFiles/AuditInvalidFormat.json"}, &Config{}, true},
+		{"AuditRules", args{lc, "testFiles/AuditRules.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Audit: AuditConfig{Rules: []AuditRule{{Name: "Logins", PolicyName: "LOGIN_FAILURES", RetainDays: 365}, {Name: "Selects", EventAction: "SELECT", EventLevel: "INFO", RetainDays: 7}}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Audit: AuditConfig{Rules: []AuditRule{{Name: "Logins", PolicyName: "LOGIN_FAILURES", RetainDays: 365}, {Name: "Selects", EventAction: "SELECT", EventLevel: "INFO", RetainDays: 7}}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Audit: AuditConfig{Rules: []AuditRule{}}, Lock: LockConfig{File: true}}}}, false},
+		{"AuditRuleNoFilter", args{lc, "testFiles/AuditRuleNoFilter.json"}, &Config{}, true},
+		{"AuditRuleNoDays", args{lc, "testFiles/AuditRuleNoDays.json"}, &Config{}, true},
+		{"DataVolume", args{lc, "testFiles/DataVolume.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, DataVolume: DataVolumeConfig{FreeTriggerPercent: 30, MinFreeMiB: 10240, ReclaimTargetPercent: 130}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, DataVolume: DataVolumeConfig{FreeTriggerPercent: 30, MinFreeMiB: 10240, ReclaimTargetPercent: 130}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, DataVolume: DataVolumeConfig{FreeTriggerPercent: 30, MinFreeMiB: 10240, ReclaimTargetPercent: 150, Volumes: []DataVolumeOverride{{"hanadb.mydomain.int:30044", 40, 10240, 150}}}, Lock: LockConfig{File: true}}}}, false},
+		{"DataVolumeBadTarget", args{lc, "testFiles/DataVolumeBadTarget.json"}, &Config{}, true},
+		{"DataVolumeBadTrigger", args{lc, "testFiles/DataVolumeBadTrigger.json"}, &Config{}, true},
+		{"DataVolumeBadVolume", args{lc, "testFiles/DataVolumeBadVolume.json"}, &Config{}, true},
+		{"DataVolumeGates", args{lc, "testFiles/DataVolumeGates.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, DataVolume: DataVolumeConfig{Gates: DataVolumeGates{Window: "22:00-05:00", MaxCPUPercent: 70, MaxMemoryPercent: 90, NoRunningBackup: true, MaxSavepointSeconds: 10}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, DataVolume: DataVolumeConfig{Gates: DataVolumeGates{Window: "22:00-05:00", MaxCPUPercent: 70, MaxMemoryPercent: 90, NoRunningBackup: true, MaxSavepointSeconds: 10}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, DataVolume: DataVolumeConfig{Gates: DataVolumeGates{Window: "01:00-03:00", MaxCPUPercent: 70, MaxMemoryPercent: 90, MaxSavepointSeconds: 10}}, Lock: LockConfig{File: true}}}}, false},
+		{"DataVolumeBadWindow", args{lc, "testFiles/DataVolumeBadWindow.json"}, &Config{}, true},
+		{"DataVolumeBadCPU", args{lc, "testFiles/DataVolumeBadCPU.json"}, &Config{}, true},
+		{"TableOptimise", args{lc, "testFiles/TableOptimise.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TableOptimise: TableOptimiseConfig{Enabled: true, DeltaTriggerMiB: 2048, CompressionTriggerPercent: 40, MinTableMiB: 512, MaxTables: 5, ExcludeSchemas: []string{"SYS"}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TableOptimise: TableOptimiseConfig{Enabled: true, DeltaTriggerMiB: 2048, CompressionTriggerPercent: 40, MinTableMiB: 512, MaxTables: 5, ExcludeSchemas: []string{"SYS"}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TableOptimise: TableOptimiseConfig{Enabled: true, DeltaTriggerMiB: 2048, CompressionTriggerPercent: 40, MinTableMiB: 512, MaxTables: 20, IncludeSchemas: []string{"APP"}, ExcludeSchemas: []string{"SYS"}}, Lock: LockConfig{File: true}}}}, false},
+		{"TableOptimiseBadPercent", args{lc, "testFiles/TableOptimiseBadPercent.json"}, &Config{}, true},
+		{"TableOptimiseSchemaClash", args{lc, "testFiles/TableOptimiseSchemaClash.json"}, &Config{}, true},
+		{"IdleSessions", args{lc, "testFiles/IdleSessions.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, IdleSessions: IdleSessionConfig{Enabled: true, IdleMinutes: 240, Applications: []string{"HDBStudio"}, ProtectedUsers: []string{"SYSTEM"}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, IdleSessions: IdleSessionConfig{Enabled: true, IdleMinutes: 240, Applications: []string{"HDBStudio"}, ProtectedUsers: []string{"SYSTEM"}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, IdleSessions: IdleSessionConfig{Enabled: true, IdleMinutes: 60, Users: []string{"DEVUSER"}, Applications: []string{"HDBStudio"}, ClientHosts: []string{"devpc01"}, ProtectedUsers: []string{"SYSTEM"}}, Lock: LockConfig{File: true}}}}, false},
+		{"IdleSessionsNoMinutes", args{lc, "testFiles/IdleSessionsNoMinutes.json"}, &Config{}, true},
+		{"MonitoringReset", args{lc, "testFiles/MonitoringReset.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, MonitoringReset: MonitoringResetConfig{Enabled: true, MinRows: 10000, MinAgeDays: 30}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, MonitoringReset: MonitoringResetConfig{Enabled: true, MinRows: 10000, MinAgeDays: 30}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, MonitoringReset: MonitoringResetConfig{Enabled: true, Views: []string{"M_CS_UNLOADS_RESET"}, MinRows: 10000, MinAgeDays: 7}, Lock: LockConfig{File: true}}}}, false},
+		{"MonitoringResetBadView", args{lc, "testFiles/MonitoringResetBadView.json"}, &Config{}, true},
+		{"TraceLevels", args{lc, "testFiles/TraceLevels.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TraceLevels: TraceLevelConfig{Enabled: true, Baseline: "warning", GraceHours: 24}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TraceLevels: TraceLevelConfig{Enabled: true, Baseline: "warning", GraceHours: 24}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TraceLevels: TraceLevelConfig{Enabled: true, Baseline: "warning", GraceHours: 24, Files: []string{"indexserver.ini"}}, Lock: LockConfig{File: true}}}}, false},
+		{"TraceLevelsBadBaseline", args{lc, "testFiles/TraceLevelsBadBaseline.json"}, &Config{}, true},
+		{"PlanCache", args{lc, "testFiles/PlanCache.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, PlanCache: PlanCacheConfig{Enabled: true, MaxUsagePercent: 90, MaxEvictionsPerHour: 1000}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, PlanCache: PlanCacheConfig{Enabled: true, MaxUsagePercent: 90, MaxEvictionsPerHour: 1000}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, PlanCache: PlanCacheConfig{Enabled: true, MaxUsagePercent: 90, MaxEvictionsPerHour: 1000, Schemas: []string{"APP"}, ClearResultCache: true}, Lock: LockConfig{File: true}}}}, false},
+		{"PlanCacheNoLimits", args{lc, "testFiles/PlanCacheNoLimits.json"}, &Config{}, true},
+		{"TableRetention", args{lc, "testFiles/TableRetention.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TableRetention: TableRetentionConfig{Enabled: true, BatchSize: 5000, Tables: []RetentionTable{{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TableRetention: TableRetentionConfig{Enabled: true, BatchSize: 5000, Tables: []RetentionTable{{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, TableRetention: TableRetentionConfig{Enabled: true, BatchSize: 5000, Tables: []RetentionTable{{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 90}, {Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14}}}, Lock: LockConfig{File: true}}}}, false},
+		{"TableRetentionNoColumn", args{lc, "testFiles/TableRetentionNoColumn.json"}, &Config{}, true},
+		{"TableRetentionNoTables", args{lc, "testFiles/TableRetentionNoTables.json"}, &Config{}, true},
+		{"Alerts", args{lc, "testFiles/Alerts.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Alerts: AlertConfig{BatchRows: 10000, MaxRunMinutes: 30}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Alerts: AlertConfig{BatchRows: 10000, MaxRunMinutes: 30}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Alerts: AlertConfig{SliceHours: 24, MaxRunMinutes: 30}, Lock: LockConfig{File: true}}}}, false},
+		{"AlertsBatchClash", args{lc, "testFiles/AlertsBatchClash.json"}, &Config{}, true},
+		{"AlertsBudgetNoBatch", args{lc, "testFiles/AlertsBudgetNoBatch.json"}, &Config{}, true},
+		{"AlertRetention", args{lc, "testFiles/AlertRetention.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Alerts: AlertConfig{RetainDaysByID: map[uint]uint{21: 365, 45: 30}, RetainDaysByRating: map[uint]uint{1: 7, 5: 180}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Alerts: AlertConfig{RetainDaysByID: map[uint]uint{21: 365, 45: 30}, RetainDaysByRating: map[uint]uint{1: 7, 5: 180}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Alerts: AlertConfig{RetainDaysByID: map[uint]uint{21: 365, 45: 30}, RetainDaysByRating: map[uint]uint{4: 90}}, Lock: LockConfig{File: true}}}}, false},
+		{"AlertRetentionBadRating", args{lc, "testFiles/AlertRetentionBadRating.json"}, &Config{}, true},
+		{"AlertRetentionBadID", args{lc, "testFiles/AlertRetentionBadID.json"}, &Config{}, true},
+		{"LogVolume", args{lc, "testFiles/LogVolume.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, LogVolume: LogVolumeConfig{RequireLogMode: "normal", MaxLogBackupAgeHours: 4, MinFreeSegments: 2}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, LogVolume: LogVolumeConfig{RequireLogMode: "normal", MaxLogBackupAgeHours: 4, MinFreeSegments: 2}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, LogVolume: LogVolumeConfig{RequireLogMode: "normal", MaxLogBackupAgeHours: 4, MinFreeSegments: 2, MinFreeMiB: 1024}, Lock: LockConfig{File: true}}}}, false},
+		{"LogVolumeBadMode", args{lc, "testFiles/LogVolumeBadMode.json"}, &Config{}, true},
+		{"Replication", args{lc, "testFiles/Replication.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Replication: ReplicationConfig{CandidateHosts: []string{"hanadb2.mydomain.int", "hanadb3.mydomain.int:30115"}}, Lock: LockConfig{File: true}}}}, false},
+		{"ReplicationBadPort", args{lc, "testFiles/ReplicationBadPort.json"}, &Config{}, true},
+		{"ReplicationDuplicate", args{lc, "testFiles/ReplicationDuplicate.json"}, &Config{}, true},
+		{"Failover", args{lc, "testFiles/Failover.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Connection: ConnectionConfig{FailoverHosts: []string{"hanadb02.mydomain.int", "hanadb03.mydomain.int:30015"}}, Lock: LockConfig{File: true}}}}, false},
+		{"FailoverBadHost", args{lc, "testFiles/FailoverBadHost.json"}, &Config{}, true},
+		{"Cloud", args{lc, "testFiles/Cloud.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Connection: ConnectionConfig{TLS: "auto", TLSRootCAFile: "/etc/hcc/ca.pem"}, Cloud: CloudConfig{EnableTasks: true}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Connection: ConnectionConfig{TLS: "auto", TLSRootCAFile: "/etc/hcc/ca.pem"}, Cloud: CloudConfig{EnableTasks: true}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, RetainAuditDays: 60, CleanDataVolume: true, Connection: ConnectionConfig{TLS: "on", TLSRootCAFile: "/etc/hcc/ca.pem"}, Lock: LockConfig{File: true}, setOff: map[string]bool{"CleanAudit": true}}}}, false},
+		{"ConnectionBadTLS", args{lc, "testFiles/ConnectionBadTLS.json"}, &Config{}, true},
+		{"ConnectionCAWithoutTLS", args{lc, "testFiles/ConnectionCAWithoutTLS.json"}, &Config{}, true},
+		{"FailoverAtRoot", args{lc, "testFiles/FailoverAtRoot.json"}, &Config{}, true},
+		{"ConnectionPool", args{lc, "testFiles/ConnectionPool.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Connection: ConnectionConfig{MaxOpenConnections: 4, MaxIdleConnections: 2, ConnectionLifetimeMinutes: 30, ConnectTimeoutSeconds: 20, ConnectAttempts: 5, ConnectBackoffSeconds: 3}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Connection: ConnectionConfig{MaxOpenConnections: 4, MaxIdleConnections: 2, ConnectionLifetimeMinutes: 30, ConnectTimeoutSeconds: 20, ConnectAttempts: 5, ConnectBackoffSeconds: 3}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Connection: ConnectionConfig{MaxOpenConnections: 8, MaxIdleConnections: 2, ConnectionLifetimeMinutes: 30, ConnectTimeoutSeconds: 20, ConnectAttempts: 1, ConnectBackoffSeconds: 3}, Lock: LockConfig{File: true}}}}, false},
+		{"ConnectionIdleOverOpen", args{lc, "testFiles/ConnectionIdleOverOpen.json"}, &Config{}, true},
+		{"Retry", args{lc, "testFiles/Retry.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Retry: RetryConfig{MaxAttempts: 5, BackoffSeconds: 2, Tasks: map[string]RetryConfig{RetryTaskCleanTrace: {8, 0, 0, nil}}}, Lock: LockConfig{File: true}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Retry: RetryConfig{MaxAttempts: 5, BackoffSeconds: 2, Tasks: map[string]RetryConfig{RetryTaskCleanTrace: {8, 0, 0, nil}}}, Lock: LockConfig{File: true}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Retry: RetryConfig{MaxAttempts: 5, BackoffSeconds: 3, Tasks: map[string]RetryConfig{RetryTaskCleanTrace: {8, 0, 0, nil}, RetryTaskCleanBackupCatalog: {0, 0, 60, nil}}}, Lock: LockConfig{File: true}}}}, false},
+		{"RetryBadTask", args{lc, "testFiles/RetryBadTask.json"}, &Config{}, true},
+		{"RetryBackoffOverMax", args{lc, "testFiles/RetryBackoffOverMax.json"}, &Config{}, true},
+		{"Lock", args{lc, "testFiles/Lock.json"}, &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true, Dir: "/var/lock/hcc", TTLMinutes: 120}, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true, Dir: "/var/lock/hcc", TTLMinutes: 120}}, {Name: "Ten01_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "sstringer", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{Dir: "/var/lock/hcc", Database: true, TTLMinutes: 120}}}}, false},
+		{"LockBadTTL", args{lc, "testFiles/LockBadTTL.json"}, &Config{}, true},
 		{"InvalidJson", args{lc, "testFiles/invalidJson.json"}, &Config{}, true},
 		{"InvalidPath", args{lc, "testFiles/NOFILE.json"}, &Config{}, true},
 	}
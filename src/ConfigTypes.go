@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"path"
+	"strings"
+	"time"
 )
 
 //Application configuration parameters to be shared with functions
@@ -18,20 +21,215 @@ type AppConfig struct {
 //Top level configuration for hanaCleanCentral
 //All root config parameters must be set
 type Config struct {
-	CleanTrace              bool // If true, trace file management will be enabled
-	RetainTraceDays         uint // Specifies the number of days of trace files to retain
-	CleanBackupCatalog      bool // If true, backup catalog truncation will be enabled
-	RetainBackupCatalogDays uint // Specifies the number of days of entries to retain
-	DeleteOldBackups        bool // If true, truncated files will be physically removed, if false entries are removed from the database only
-	CleanAlerts             bool // If true, old alerts are removed from the embedded statistics server
-	RetainAlertsDays        uint // Specifies the number of days of alerts to retain
-	CleanLogVolume          bool // If true, free log segments will be removed from the file system
-	CleanAudit              bool // If true, old audit records will be deleted
-	RetainAuditDays         uint // Specifies the number of days of audit log to retain
-	CleanDataVolume         bool // If true, the data volume will be defragemented, currently uses default size of 120
+	CleanTrace              bool                  // If true, trace file management will be enabled
+	RetainTraceDays         uint                  // Specifies the number of days of trace files to retain
+	CleanBackupCatalog      bool                  // If true, backup catalog truncation will be enabled
+	RetainBackupCatalogDays uint                  // Specifies the number of days of entries to retain
+	DeleteOldBackups        bool                  // If true, truncated files will be physically removed, if false entries are removed from the database only
+	CleanAlerts             bool                  // If true, old alerts are removed from the embedded statistics server
+	RetainAlertsDays        uint                  // Specifies the number of days of alerts to retain
+	CleanLogVolume          bool                  // If true, free log segments will be removed from the file system
+	CleanAudit              bool                  // If true, old audit records will be deleted
+	RetainAuditDays         uint                  // Specifies the number of days of audit log to retain
+	CleanDataVolume         bool                  // If true, the data volume will be defragemented, see DataVolume for the thresholds
+	TraceQuota              TraceQuotaConfig      // Optional, size based trace file management
+	Backup                  BackupConfig          // Optional, additional backup catalog settings
+	Audit                   AuditConfig           // Optional, additional audit log settings
+	DataVolume              DataVolumeConfig      // Optional, data volume reclaim thresholds
+	TableOptimise           TableOptimiseConfig   // Optional, delta merge and compression optimisation of column store tables
+	IdleSessions            IdleSessionConfig     // Optional, disconnection of long idle sessions
+	MonitoringReset         MonitoringResetConfig // Optional, reset of resettable monitoring views
+	TraceLevels             TraceLevelConfig      // Optional, reset of trace levels left above a baseline
+	PlanCache               PlanCacheConfig       // Optional, SQL plan cache clearing
+	TableRetention          TableRetentionConfig  // Optional, age based removal of rows from user defined tables
+	Alerts                  AlertConfig           // Optional, batched alert deletion
+	LogVolume               LogVolumeConfig       // Optional, guards for log volume cleanup
+	Connection              ConnectionConfig      // Optional, connection settings
+	Cloud                   CloudConfig           // Optional, HANA Cloud profile settings
+	Retry                   RetryConfig           // Optional, retry policy for transient database errors
+	Lock                    LockConfig            // Optional, run lock settings
 	Databases               []DbConfig
 }
 
+//Configuration for size based trace file management.  When enabled, closed trace files are removed
+//oldest first until the total size of the trace files on each host is within the quota.
+type TraceQuotaConfig struct {
+	Enabled       bool     // If true, the trace quota will be enforced
+	MaxMiBPerHost uint     // The maximum total size of trace files on each host in MiB
+	MinAgeHours   uint     // Trace files modified within this number of hours are never removed
+	Exclusions    []string // Trace file name patterns that are never removed, e.g. "*_alert_*.trc"
+}
+
+//Returns true if the trace file name matches one of the configured exclusion patterns.
+//Patterns use the syntax of path.Match, malformed patterns never match.
+func (tq TraceQuotaConfig) IsExcluded(filename string) bool {
+	for _, v := range tq.Exclusions {
+		if ok, _ := path.Match(v, filename); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//Backup catalog retention modes
+const (
+	BackupRetentionDays  string = "days"  // Keep the backups of the last RetainBackupCatalogDays (default)
+	BackupRetentionCount string = "count" // Keep the last RetainFullBackups full backups
+	BackupRetentionBoth  string = "both"  // Keep whichever of the above retains the most
+)
+
+//Optional configuration for backup catalog management.  The catalog is truncated before an anchor backup, the
+//anchor is a successful complete data backup chosen according to RetentionMode.
+type BackupConfig struct {
+	RetentionMode     string // One of "days", "count" or "both".  When not set "days" is used
+	RetainFullBackups uint   // The number of successful complete data backups to keep in "count" and "both" modes
+	SafetyCheck       bool   // If true, the catalog is only truncated when the checks in CheckBackupSafety pass
+	SafetyWindowHours uint   // A successful complete data backup newer than the anchor must have finished within this number of hours
+	ExportDir         string // If set, catalog entries are exported to a file in this directory before truncation
+	ExportFormat      string // One of "csv" or "json".  When not set "json" is used
+
+	/*When DeleteOldBackups is true, only backups matching both lists are physically removed.  An empty list matches everything*/
+	DeleteDestinationTypes []string // Destination types to physically delete, e.g. "file" or "backint"
+	DeleteEntryTypes       []string // Entry types to physically delete, e.g. "log backup"
+}
+
+//Returns true if physical deletion is limited to some destination or entry types
+func (bc BackupConfig) FilteredDelete() bool {
+	return len(bc.DeleteDestinationTypes) > 0 || len(bc.DeleteEntryTypes) > 0
+}
+
+//Returns true if the list is empty or contains the value
+func matchesAny(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+//Optional configuration for audit log management
+type AuditConfig struct {
+	ArchiveDir    string // If set, audit records are archived to compressed files in this directory before they are cleared
+	ArchiveFormat string // One of "jsonl" or "csv".  When not set "jsonl" is used
+	Rules         []AuditRule
+}
+
+//An audit retention rule.  Records matching every field that is set are retained for RetainDays.  As the audit log
+//can only be cleared up to a point in time, the longest retention that still protects records is used for the whole log.
+type AuditRule struct {
+	Name        string // Used in the log, plan and report
+	PolicyName  string // Matched against AUDIT_POLICY_NAME
+	EventAction string // Matched against EVENT_ACTION
+	EventLevel  string // Matched against EVENT_LEVEL
+	RetainDays  uint
+}
+
+//Default data volume reclaim thresholds
+const (
+	DefaultFreeTriggerPercent   uint = 50
+	DefaultReclaimTargetPercent uint = 120
+)
+
+//Optional configuration for data volume reclaim.  Thresholds that are 0 use the defaults.
+type DataVolumeConfig struct {
+	FreeTriggerPercent   uint                 // Reclaim when more than this percentage of the data volume is free.  When not set 50 is used
+	MinFreeMiB           uint                 // Reclaim only when at least this much of the data volume is free
+	ReclaimTargetPercent uint                 // The size to reclaim the data volume to as a percentage of its used size.  When not set 120 is used
+	Volumes              []DataVolumeOverride // Thresholds for individual volumes
+	Gates                DataVolumeGates      // Conditions that must be met before any volume is reclaimed
+}
+
+//Conditions checked before a data volume is reclaimed.  Gates that are not set are not checked.
+type DataVolumeGates struct {
+	Window              string // Reclaim only between these times of day on the database, e.g. "22:00-05:00"
+	MaxCPUPercent       uint   // Reclaim only when the CPU usage of the volume's host is at or below this percentage
+	MaxMemoryPercent    uint   // Reclaim only when the physical memory usage of the volume's host is at or below this percentage
+	NoRunningBackup     bool   // If true, do not reclaim while a data backup is running
+	MaxSavepointSeconds uint   // Do not reclaim a volume whose service had a savepoint longer than this in the last hour
+}
+
+//Returns true if any gate is set
+func (g DataVolumeGates) Enabled() bool {
+	return g.Window != "" || g.MaxCPUPercent > 0 || g.MaxMemoryPercent > 0 || g.NoRunningBackup || g.MaxSavepointSeconds > 0
+}
+
+//Returns true if the time of day, given as HH:MM, is inside the window.  Windows may span midnight.
+func (g DataVolumeGates) InWindow(now string) (bool, error) {
+	if g.Window == "" {
+		return true, nil
+	}
+	start, end, err := ParseWindow(g.Window)
+	if err != nil {
+		return false, err
+	}
+	t, err := parseTimeOfDay(now)
+	if err != nil {
+		return false, err
+	}
+	if start <= end {
+		return t >= start && t < end, nil
+	}
+	return t >= start || t < end, nil
+}
+
+//Parses a window given as "HH:MM-HH:MM" into the start and end minute of the day
+func ParseWindow(window string) (int, int, error) {
+	parts := strings.Split(window, "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("window '%s' must be given as HH:MM-HH:MM", window)
+	}
+	start, err := parseTimeOfDay(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseTimeOfDay(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("window '%s' is empty", window)
+	}
+	return start, end, nil
+}
+
+//Parses HH:MM into the minute of the day
+func parseTimeOfDay(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("time '%s' must be given as HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+//Data volume reclaim thresholds for a single volume, thresholds that are not set are inherited from the database
+type DataVolumeOverride struct {
+	Volume               string // The volume as host:port
+	FreeTriggerPercent   uint
+	MinFreeMiB           uint
+	ReclaimTargetPercent uint
+}
+
+//Returns the thresholds for the volume on host:port with defaults applied.  Gates are not included.
+func (dc DataVolumeConfig) ForVolume(host string, port uint) DataVolumeConfig {
+	t := DataVolumeConfig{dc.FreeTriggerPercent, dc.MinFreeMiB, dc.ReclaimTargetPercent, nil, DataVolumeGates{}}
+	for _, v := range dc.Volumes {
+		if v.Volume == fmt.Sprintf("%s:%d", host, port) {
+			t = DataVolumeConfig{v.FreeTriggerPercent, v.MinFreeMiB, v.ReclaimTargetPercent, nil, DataVolumeGates{}}
+		}
+	}
+	if t.FreeTriggerPercent == 0 {
+		t.FreeTriggerPercent = DefaultFreeTriggerPercent
+	}
+	if t.ReclaimTargetPercent == 0 {
+		t.ReclaimTargetPercent = DefaultReclaimTargetPercent
+	}
+	return t
+}
+
 //Duplicate DB names are confusing at best and make it impossible to set
 //password names from environment variables.  This function checks for duplicate names and
 //returns an error if duplicate names are found
@@ -71,3 +269,330 @@ func (c *Config) PrintConfig() error {
 	fmt.Println(prettyJson.String())
 	return nil
 }
+
+//Defaults used by table optimisation when a setting is not configured
+const (
+	DefaultDeltaTriggerMiB   uint = 1024
+	DefaultMaxOptimiseTables uint = 10
+)
+
+//Optional configuration for delta merge and compression optimisation of column store tables.  Tables over either
+//threshold are optimised largest first, up to MaxTables tables in each run.
+type TableOptimiseConfig struct {
+	Enabled                   bool     // If true, column store tables over the thresholds will be optimised
+	DeltaTriggerMiB           uint     // Merge the delta store of tables with a delta larger than this.  When not set 1024 is used
+	CompressionTriggerPercent uint     // Optimise the compression of tables with a main store larger than this percentage of the uncompressed size.  Not checked when not set
+	MinTableMiB               uint     // Tables with a main store smaller than this are not checked for compression
+	MaxTables                 uint     // The maximum number of tables to optimise in each run.  When not set 10 is used
+	IncludeSchemas            []string // Only tables in these schemas are optimised.  An empty list includes every schema
+	ExcludeSchemas            []string // Tables in these schemas are never optimised
+}
+
+//Returns a copy of the configuration with defaults applied to the settings that are not configured
+func (t TableOptimiseConfig) WithDefaults() TableOptimiseConfig {
+	if t.DeltaTriggerMiB == 0 {
+		t.DeltaTriggerMiB = DefaultDeltaTriggerMiB
+	}
+	if t.MaxTables == 0 {
+		t.MaxTables = DefaultMaxOptimiseTables
+	}
+	return t
+}
+
+//Optional configuration for disconnecting idle sessions.  Only sessions that match all of the non empty lists are
+//disconnected.  Sessions of the protected users, which always include the HCC user, are never disconnected.
+type IdleSessionConfig struct {
+	Enabled        bool     // If true, sessions idle for longer than IdleMinutes will be disconnected
+	IdleMinutes    uint     // Sessions idle for longer than this number of minutes are disconnected
+	Users          []string // Only disconnect sessions of these users.  An empty list matches every user
+	Applications   []string // Only disconnect sessions of these applications.  An empty list matches every application
+	ClientHosts    []string // Only disconnect sessions from these client hosts.  An empty list matches every host
+	ProtectedUsers []string // Sessions of these users are never disconnected
+}
+
+//The monitoring views reset when MonitoringReset.Views is not set, the column store unload history and the object
+//lock statistics
+var DefaultMonitoringResetViews = []string{"M_CS_UNLOADS_RESET", "M_OBJECT_LOCK_STATISTICS_RESET"}
+
+//Optional configuration for resetting monitoring views.  A view is reset when it is over both thresholds.
+type MonitoringResetConfig struct {
+	Enabled    bool     // If true, the monitoring views will be reset
+	Views      []string // Resettable monitoring views in the SYS schema, e.g. "M_CS_UNLOADS_RESET".  When not set DefaultMonitoringResetViews is used
+	MinRows    uint     // Only reset views holding at least this many rows
+	MinAgeDays uint     // Only reset views that were last reset at least this number of days ago
+}
+
+//Returns the views to reset
+func (m MonitoringResetConfig) ResetViews() []string {
+	if len(m.Views) == 0 {
+		return DefaultMonitoringResetViews
+	}
+	return m.Views
+}
+
+//Trace levels in increasing order of detail
+var TraceLevels = []string{"none", "fatal", "error", "warning", "info", "debug"}
+
+//The trace level baseline used when TraceLevels.Baseline is not set
+const DefaultTraceBaseline string = "info"
+
+//Optional configuration for resetting trace levels.  Trace section keys on the SYSTEM, DATABASE and HOST layers that
+//are set above the baseline are unset, which returns the component to its default trace level.
+type TraceLevelConfig struct {
+	Enabled    bool     // If true, trace levels above the baseline will be reset
+	Baseline   string   // The highest trace level allowed to remain set.  When not set "info" is used
+	GraceHours uint     // Trace levels changed within this number of hours are left alone.  Not checked when not set
+	Files      []string // Only check these ini files, e.g. "indexserver.ini".  An empty list checks every file
+}
+
+//Returns the trace levels above the baseline
+func (t TraceLevelConfig) ElevatedLevels() []string {
+	baseline := t.Baseline
+	if baseline == "" {
+		baseline = DefaultTraceBaseline
+	}
+	for k, v := range TraceLevels {
+		if v == strings.ToLower(baseline) {
+			return TraceLevels[k+1:]
+		}
+	}
+	return nil
+}
+
+//Optional configuration for clearing the SQL plan cache.  The cache is cleared when any service is over one of the
+//configured limits.  When Schemas or Users are set only the unused plans matching all of the non empty lists are removed.
+type PlanCacheConfig struct {
+	Enabled             bool     // If true, the plan cache will be cleared when it is over a limit
+	MaxUsagePercent     uint     // Clear when the cached plans use more than this percentage of the plan cache capacity
+	MaxSizeMiB          uint     // Clear when the cached plans of a service use more than this
+	MaxEvictionsPerHour uint     // Clear when plans are evicted faster than this, averaged since the service started
+	Schemas             []string // Only remove plans for these schemas
+	Users               []string // Only remove plans for these users
+	ClearResultCache    bool     // If true, the result cache is also cleared when the plan cache is cleared
+}
+
+//Returns true if plans are removed selectively rather than clearing the whole cache
+func (p PlanCacheConfig) Filtered() bool {
+	return len(p.Schemas) > 0 || len(p.Users) > 0
+}
+
+//The number of rows deleted in each batch when TableRetention.BatchSize is not set
+const DefaultRetentionBatchSize uint = 10000
+
+//A user defined table whose old rows are removed by the table retention task
+type RetentionTable struct {
+	Schema          string // The schema of the table
+	Table           string // The name of the table
+	TimestampColumn string // The column holding the time each row was written
+	RetainDays      uint   // Rows older than this number of days are deleted
+}
+
+//Returns the table as schema.table, used to identify the table in logs and results
+func (r RetentionTable) String() string {
+	return fmt.Sprintf("%s.%s", r.Schema, r.Table)
+}
+
+//Optional configuration for removing old rows from user defined tables, such as application log tables.  Rows are
+//deleted in batches with a commit after each batch so that a large backlog doesn't create a single huge transaction.
+type TableRetentionConfig struct {
+	Enabled   bool             // If true, rows past their retention will be removed from the tables
+	BatchSize uint             // The maximum number of rows deleted in each transaction.  When not set 10000 is used
+	Tables    []RetentionTable // The tables to clean
+}
+
+//Returns the batch size to use
+func (t TableRetentionConfig) Batch() uint {
+	if t.BatchSize == 0 {
+		return DefaultRetentionBatchSize
+	}
+	return t.BatchSize
+}
+
+//Optional configuration for alert cleanup.  When BatchRows or SliceHours is set the alerts are deleted in batches,
+//oldest first, with a commit after each batch.  As the oldest alerts always go first, a run stopped by MaxRunMinutes
+//is carried on by the next run.  Alerts are kept for the retention of their alert ID if one is set, otherwise for the
+//retention of their rating if one is set, otherwise for RetainAlertsDays.
+type AlertConfig struct {
+	BatchRows          uint          // Delete at most this many alerts in each transaction
+	SliceHours         uint          // Delete the alerts of this many hours, from the oldest alert, in each transaction
+	MaxRunMinutes      uint          // Stop starting new batches after this many minutes.  Not checked when not set
+	RetainDaysByID     map[uint]uint // Days of alerts to retain for individual ALERT_IDs
+	RetainDaysByRating map[uint]uint // Days of alerts to retain for each ALERT_RATING, 1 (information) to 5 (error)
+}
+
+//Returns true if the alerts are deleted in batches rather than in a single statement
+func (a AlertConfig) Batched() bool {
+	return a.BatchRows > 0 || a.SliceHours > 0
+}
+
+//Returns true if any alert ID or rating has its own retention
+func (a AlertConfig) HasRules() bool {
+	return len(a.RetainDaysByID) > 0 || len(a.RetainDaysByRating) > 0
+}
+
+//Database log modes
+const (
+	LogModeNormal    string = "normal"
+	LogModeOverwrite string = "overwrite"
+)
+
+//Optional guards for log volume cleanup.  Log segments are only reclaimed when every configured guard passes.
+type LogVolumeConfig struct {
+	RequireLogMode       string // Only reclaim when the database log mode is this, "normal" or "overwrite".  Not checked when not set
+	MaxLogBackupAgeHours uint   // In log mode normal, only reclaim when the latest finished log backup succeeded within this many hours
+	MinFreeSegments      uint   // Only reclaim when at least this many log segments are free
+	MinFreeMiB           uint   // Only reclaim when the free log segments hold at least this much
+}
+
+//System replication roles reported by the replication pre-flight
+const (
+	ReplicationPrimary   string = "primary"
+	ReplicationSecondary string = "secondary"
+	ReplicationNone      string = "none"
+	ReplicationUnknown   string = "unknown"
+)
+
+//Optional system replication settings.  When candidate hosts are listed each one is tried in turn
+//after 'Hostname' and HCC connects to the first one that is the current primary.
+type ReplicationConfig struct {
+	CandidateHosts []string // Other hosts that may be the primary, "host" or "host:port".  The port defaults to 'Port'
+}
+
+//TLS modes for the database connection
+const (
+	TLSModeAuto string = "auto"
+	TLSModeOn   string = "on"
+	TLSModeOff  string = "off"
+)
+
+//The domain of HANA Cloud SQL endpoints, TLS is used for these hosts in TLS mode auto
+const HanaCloudDomain string = ".hanacloud.ondemand.com"
+
+//Optional connection settings
+type ConnectionConfig struct {
+	FailoverHosts             []string // Other hosts of a scale-out system to connect to when 'Hostname' cannot be reached, "host" or "host:port"
+	TLS                       string   // "auto", "on" or "off".  Auto, the default, uses TLS for HANA Cloud hosts
+	TLSRootCAFile             string   // Optional file of root certificates used to verify the server, the system roots are used when not set
+	MaxOpenConnections        uint     // The maximum number of open connections.  When not set there is no limit
+	MaxIdleConnections        uint     // The maximum number of idle connections kept for reuse.  When not set 2 are kept
+	ConnectionLifetimeMinutes uint     // Connections are closed after this number of minutes.  When not set they are reused until the database is finished
+	ConnectTimeoutSeconds     uint     // The driver side connection timeout in seconds.  When not set the driver default is used
+	ConnectAttempts           uint     // The number of times the initial connection is tried.  When not set 3 is used
+	ConnectBackoffSeconds     uint     // The wait before the second attempt, doubled for each further attempt.  When not set 2 is used
+}
+
+//Returns the number of times to try the initial connection
+func (c ConnectionConfig) Attempts() uint {
+	if c.ConnectAttempts == 0 {
+		return 3
+	}
+	return c.ConnectAttempts
+}
+
+//Returns the wait before the given retry, the first retry is 1
+func (c ConnectionConfig) Backoff(retry uint) time.Duration {
+	secs := c.ConnectBackoffSeconds
+	if secs == 0 {
+		secs = 2
+	}
+	return time.Duration(secs) * connectBackoffUnit << (retry - 1)
+}
+
+//Returns true if TLS should be used to connect to host
+func (c ConnectionConfig) UseTLS(host string) bool {
+	switch c.TLS {
+	case TLSModeOn:
+		return true
+	case TLSModeOff:
+		return false
+	default:
+		return strings.HasSuffix(strings.ToLower(host), HanaCloudDomain)
+	}
+}
+
+//Optional HANA Cloud profile settings.  The profile is applied when the database is found to be HANA Cloud.
+type CloudConfig struct {
+	EnableTasks bool // If true the profile also enables the audit, alert and table retention tasks unless they are set to false
+}
+
+//Tasks that have their own retry policy, named after the setting that enables them
+const (
+	RetryTaskCleanTrace         string = "CleanTrace"
+	RetryTaskCleanBackupCatalog string = "CleanBackupCatalog"
+)
+
+var RetryTasks = []string{RetryTaskCleanTrace, RetryTaskCleanBackupCatalog}
+
+//Optional retry policy for statements that fail with a transient error, such as a connection reset or a lock wait
+//timeout.  The policy applies to every task that retries, 'Tasks' overrides it for individual tasks.
+type RetryConfig struct {
+	MaxAttempts       uint                   // The number of times a statement is tried.  When not set 3 is used, 1 turns retries off
+	BackoffSeconds    uint                   // The wait before the first retry, doubled for each further retry.  When not set 1 is used
+	MaxBackoffSeconds uint                   // The longest wait before a retry.  When not set 30 is used
+	Tasks             map[string]RetryConfig // Policies for individual tasks keyed by task, fields that are not set use the policy above
+}
+
+//Returns the policy for a task, the task's own settings take priority
+func (r RetryConfig) ForTask(task string) RetryConfig {
+	p := RetryConfig{r.MaxAttempts, r.BackoffSeconds, r.MaxBackoffSeconds, nil}
+	t, ok := r.Tasks[task]
+	if !ok {
+		return p
+	}
+	if t.MaxAttempts > 0 {
+		p.MaxAttempts = t.MaxAttempts
+	}
+	if t.BackoffSeconds > 0 {
+		p.BackoffSeconds = t.BackoffSeconds
+	}
+	if t.MaxBackoffSeconds > 0 {
+		p.MaxBackoffSeconds = t.MaxBackoffSeconds
+	}
+	return p
+}
+
+//Returns the number of times to try a statement
+func (r RetryConfig) Attempts() uint {
+	if r.MaxAttempts == 0 {
+		return 3
+	}
+	return r.MaxAttempts
+}
+
+//Returns the wait before the given retry without jitter, the first retry is 1
+func (r RetryConfig) Backoff(retry uint) time.Duration {
+	base, max := r.BackoffSeconds, r.MaxBackoffSeconds
+	if base == 0 {
+		base = 1
+	}
+	if max == 0 {
+		max = 30
+	}
+	wait := time.Duration(base) * retryBackoffUnit
+	for k := uint(1); k < retry && wait < time.Duration(max)*retryBackoffUnit; k++ {
+		wait *= 2
+	}
+	if wait > time.Duration(max)*retryBackoffUnit {
+		wait = time.Duration(max) * retryBackoffUnit
+	}
+	return wait
+}
+
+//The default age in minutes after which a run lock is stale
+const DefaultLockTTLMinutes uint = 360
+
+//Optional run lock settings.  The lock stops overlapping runs from working on the same database.
+type LockConfig struct {
+	File       bool   // If true, a lock file is held for each database while it is processed - Defaults to true
+	Dir        string // The directory lock files are written to.  When not set the system temporary directory is used
+	Database   bool   // If true, the database is also checked for sessions of another HCC run, e.g. from another host - Defaults to false
+	TTLMinutes uint   // Locks older than this number of minutes are stale and are ignored.  When not set 360 is used
+}
+
+//Returns the age after which a lock is stale
+func (l LockConfig) TTL() time.Duration {
+	if l.TTLMinutes == 0 {
+		return time.Duration(DefaultLockTTLMinutes) * time.Minute
+	}
+	return time.Duration(l.TTLMinutes) * time.Minute
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestConfig_CheckForDupeNames(t *testing.T) {
@@ -11,9 +13,9 @@ func TestConfig_CheckForDupeNames(t *testing.T) {
 		c       *Config
 		wantErr bool
 	}{
-		{"Good_SingleDB", &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "hccuser", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}}}, false},
-		{"Good_TwoDBs", &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "hccuser", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}, {"ten1_TST", "hanadb.mydomain.int", 30041, "hccuser", "ReallyCoolPassw0rd", false, 0, false, 0, true, true, 90, false, true, 30, true, nil, CleanResults{}}}}, false},
-		{"Err_IdenticalNames", &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"database", "hanadb.mydomain.int", 30015, "hccuser", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}, {"database", "hanadb.mydomain.int", 30041, "hccuser", "ReallyCoolPassw0rd", false, 0, false, 0, true, true, 90, false, true, 30, true, nil, CleanResults{}}}}, true},
+		{"Good_SingleDB", &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "hccuser", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true}}}, false},
+		{"Good_TwoDBs", &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "hccuser", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true}, {Name: "ten1_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "hccuser", password: "ReallyCoolPassw0rd", DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 90, CleanAudit: true, RetainAuditDays: 30, CleanDataVolume: true}}}, false},
+		{"Err_IdenticalNames", &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Databases: []DbConfig{{Name: "database", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "hccuser", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true}, {Name: "database", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "hccuser", password: "ReallyCoolPassw0rd", DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 90, CleanAudit: true, RetainAuditDays: 30, CleanDataVolume: true}}}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -31,8 +33,8 @@ func TestConfig_PrintConfig(t *testing.T) {
 		c       *Config
 		wantErr bool
 	}{
-		{"GoodSingleDB", &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "hccuser", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}}}, false},
-		{"GoodTwoDBs", &Config{true, 60, true, 60, true, true, 60, true, true, 60, true, []DbConfig{{"systemdb_TST", "hanadb.mydomain.int", 30015, "hccuser", "ReallyCoolPassw0rd", true, 60, true, 60, true, true, 60, true, true, 60, true, nil, CleanResults{}}, {"ten1_TST", "hanadb.mydomain.int", 30041, "hccuser", "ReallyCoolPassw0rd", false, 0, false, 0, true, true, 90, false, true, 30, true, nil, CleanResults{}}}}, false},
+		{"GoodSingleDB", &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "hccuser", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true}}}, false},
+		{"GoodTwoDBs", &Config{CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Databases: []DbConfig{{Name: "systemdb_TST", Hostname: "hanadb.mydomain.int", Port: 30015, Username: "hccuser", password: "ReallyCoolPassw0rd", CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true}, {Name: "ten1_TST", Hostname: "hanadb.mydomain.int", Port: 30041, Username: "hccuser", password: "ReallyCoolPassw0rd", DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 90, CleanAudit: true, RetainAuditDays: 30, CleanDataVolume: true}}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -42,3 +44,152 @@ func TestConfig_PrintConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestTraceQuotaConfig_IsExcluded(t *testing.T) {
+	tests := []struct {
+		name     string
+		tq       TraceQuotaConfig
+		filename string
+		want     bool
+	}{
+		{"NoExclusions", TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 100}, "indexserver_hana01.30003.001.trc", false},
+		{"Excluded", TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 100, Exclusions: []string{"*_alert_*.trc"}}, "indexserver_alert_hana01.trc", true},
+		{"NotExcluded", TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 100, Exclusions: []string{"*_alert_*.trc"}}, "indexserver_hana01.30003.001.trc", false},
+		{"SecondPattern", TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 100, Exclusions: []string{"*_alert_*.trc", "nameserver_*"}}, "nameserver_hana01.30001.000.trc", true},
+		{"MalformedPattern", TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 100, Exclusions: []string{"[trc"}}, "[trc", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tq.IsExcluded(tt.filename); got != tt.want {
+				t.Errorf("TraceQuotaConfig.IsExcluded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataVolumeConfig_ForVolume(t *testing.T) {
+	dc := DataVolumeConfig{FreeTriggerPercent: 30, MinFreeMiB: 1024, Volumes: []DataVolumeOverride{{"hana01:30040", 60, 0, 150}}}
+	tests := []struct {
+		name string
+		dc   DataVolumeConfig
+		host string
+		port uint
+		want DataVolumeConfig
+	}{
+		{"Defaults", DataVolumeConfig{}, "hana01", 30040, DataVolumeConfig{FreeTriggerPercent: 50, ReclaimTargetPercent: 120}},
+		{"Database", dc, "hana01", 30041, DataVolumeConfig{FreeTriggerPercent: 30, MinFreeMiB: 1024, ReclaimTargetPercent: 120}},
+		{"Override", dc, "hana01", 30040, DataVolumeConfig{FreeTriggerPercent: 60, ReclaimTargetPercent: 150}},
+		{"OtherHost", dc, "hana02", 30040, DataVolumeConfig{FreeTriggerPercent: 30, MinFreeMiB: 1024, ReclaimTargetPercent: 120}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dc.ForVolume(tt.host, tt.port); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DataVolumeConfig.ForVolume() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataVolumeGates_InWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		window  string
+		now     string
+		want    bool
+		wantErr bool
+	}{
+		{"NoWindow", "", "12:00", true, false},
+		{"Inside", "01:00-05:00", "03:30", true, false},
+		{"Start", "01:00-05:00", "01:00", true, false},
+		{"End", "01:00-05:00", "05:00", false, false},
+		{"Outside", "01:00-05:00", "12:00", false, false},
+		{"OvernightLate", "22:00-05:00", "23:15", true, false},
+		{"OvernightEarly", "22:00-05:00", "04:59", true, false},
+		{"OvernightOutside", "22:00-05:00", "12:00", false, false},
+		{"BadWindow", "22:00", "12:00", false, true},
+		{"BadTime", "22:00-05:00", "noon", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DataVolumeGates{Window: tt.window}.InWindow(tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DataVolumeGates.InWindow() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DataVolumeGates.InWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceLevelConfig_ElevatedLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline string
+		want     []string
+	}{
+		{"Default", "", []string{"debug"}},
+		{"Error", "error", []string{"warning", "info", "debug"}},
+		{"UpperCase", "WARNING", []string{"info", "debug"}},
+		{"Debug", "debug", nil},
+		{"Unknown", "verbose", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TraceLevelConfig{Baseline: tt.baseline}.ElevatedLevels()
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TraceLevelConfig.ElevatedLevels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionConfig_UseTLS(t *testing.T) {
+	tests := []struct {
+		name string
+		c    ConnectionConfig
+		host string
+		want bool
+	}{
+		{"DefaultOnPremise", ConnectionConfig{}, "hana1.mydomain.int", false},
+		{"DefaultCloud", ConnectionConfig{}, "abc.hana.prod-eu10.hanacloud.ondemand.com", true},
+		{"AutoCloudUpper", ConnectionConfig{TLS: TLSModeAuto}, "ABC.HANA.PROD-EU10.HANACLOUD.ONDEMAND.COM", true},
+		{"OnOnPremise", ConnectionConfig{TLS: TLSModeOn}, "hana1.mydomain.int", true},
+		{"OffCloud", ConnectionConfig{TLS: TLSModeOff}, "abc.hana.prod-eu10.hanacloud.ondemand.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.UseTLS(tt.host); got != tt.want {
+				t.Errorf("ConnectionConfig.UseTLS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionConfig_Backoff(t *testing.T) {
+	tests := []struct {
+		name         string
+		c            ConnectionConfig
+		retry        uint
+		wantAttempts uint
+		want         time.Duration
+	}{
+		{"DefaultFirst", ConnectionConfig{}, 1, 3, 2 * time.Second},
+		{"DefaultThird", ConnectionConfig{}, 3, 3, 8 * time.Second},
+		{"Configured", ConnectionConfig{ConnectAttempts: 5, ConnectBackoffSeconds: 5}, 2, 5, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Backoff(tt.retry); got != tt.want {
+				t.Errorf("ConnectionConfig.Backoff() = %s, want %s", got, tt.want)
+			}
+			if got := tt.c.Attempts(); got != tt.wantAttempts {
+				t.Errorf("ConnectionConfig.Attempts() = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
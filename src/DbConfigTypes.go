@@ -4,51 +4,314 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/SAP/go-hdb/driver"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
 
 //Struct for holding database configuration
 type DbConfig struct {
-	Name                    string // Friendly name of the DB.  <Tenant>@<SID> is a good option here
-	Hostname                string // Hostname or IP address of the primary HANA node
-	Port                    uint   // Port of the HANA DB
-	Username                string // HANA DB user name to use
-	password                string // Password for HANA DB user
-	CleanTrace              bool   // If true, trace file management will be enabled - Defaults to false
-	RetainTraceDays         uint   // Specifies the number of days of trace files to retain
-	CleanBackupCatalog      bool   // If true, backup catalog truncation will be enabled - Defaults to false
-	RetainBackupCatalogDays uint   // Specifies the number of days of entries to retain
-	DeleteOldBackups        bool   // If true, truncated files will be physically removed, if false entries are removed from the database only - Defaults to false
-	CleanAlerts             bool   // If true, old alerts are removed from the embedded statistics server - Defaults to false
-	RetainAlertsDays        uint   // Specifies the number of days of alerts to retain
-	CleanLogVolume          bool   // If true, free log segments will be removed from the file system
-	CleanAudit              bool   // If true, old audit records will be deleted
-	RetainAuditDays         uint   // Specifies the number of days of audit log to retain
-	CleanDataVolume         bool   // If true, the data volume will be defragemented, currently uses default size of 120
+	Name                    string                // Friendly name of the DB.  <Tenant>@<SID> is a good option here
+	Hostname                string                // Hostname or IP address of the primary HANA node
+	Port                    uint                  // Port of the HANA DB
+	Username                string                // HANA DB user name to use
+	password                string                // Password for HANA DB user
+	CleanTrace              bool                  // If true, trace file management will be enabled - Defaults to false
+	RetainTraceDays         uint                  // Specifies the number of days of trace files to retain
+	CleanBackupCatalog      bool                  // If true, backup catalog truncation will be enabled - Defaults to false
+	RetainBackupCatalogDays uint                  // Specifies the number of days of entries to retain
+	DeleteOldBackups        bool                  // If true, truncated files will be physically removed, if false entries are removed from the database only - Defaults to false
+	CleanAlerts             bool                  // If true, old alerts are removed from the embedded statistics server - Defaults to false
+	RetainAlertsDays        uint                  // Specifies the number of days of alerts to retain
+	CleanLogVolume          bool                  // If true, free log segments will be removed from the file system
+	CleanAudit              bool                  // If true, old audit records will be deleted
+	RetainAuditDays         uint                  // Specifies the number of days of audit log to retain
+	CleanDataVolume         bool                  // If true, the data volume will be defragemented, see DataVolume for the thresholds
+	TraceQuota              TraceQuotaConfig      // Optional, size based trace file management
+	Backup                  BackupConfig          // Optional, additional backup catalog settings
+	Audit                   AuditConfig           // Optional, additional audit log settings
+	DataVolume              DataVolumeConfig      // Optional, data volume reclaim thresholds
+	TableOptimise           TableOptimiseConfig   // Optional, delta merge and compression optimisation of column store tables
+	IdleSessions            IdleSessionConfig     // Optional, disconnection of long idle sessions
+	MonitoringReset         MonitoringResetConfig // Optional, reset of resettable monitoring views
+	TraceLevels             TraceLevelConfig      // Optional, reset of trace levels left above a baseline
+	PlanCache               PlanCacheConfig       // Optional, SQL plan cache clearing
+	TableRetention          TableRetentionConfig  // Optional, age based removal of rows from user defined tables
+	Alerts                  AlertConfig           // Optional, batched alert deletion
+	LogVolume               LogVolumeConfig       // Optional, guards for log volume cleanup
+	Replication             ReplicationConfig     // Optional, system replication candidate hosts
+	Connection              ConnectionConfig      // Optional, failover hosts and TLS
+	Cloud                   CloudConfig           // Optional, HANA Cloud profile settings
+	Retry                   RetryConfig           // Optional, retry policy for transient database errors
+	Lock                    LockConfig            // Optional, run lock settings
+	setOff                  map[string]bool       // Task settings set to false in the configuration, the Cloud profile never enables them
 	db                      *sql.DB
 	Results                 CleanResults //Results stored here and printed later
 }
 
 func (hdb DbConfig) Dsn() string {
-	return fmt.Sprintf("hdb://%s:%s@%s:%d", hdb.Username, hdb.password, hdb.Hostname, hdb.Port)
+	dsn := fmt.Sprintf("hdb://%s:%s@%s:%d", hdb.Username, hdb.password, hdb.Hostname, hdb.Port)
+	params := url.Values{}
+	if hdb.Connection.ConnectTimeoutSeconds > 0 {
+		params.Set(driver.DSNTimeout, strconv.FormatUint(uint64(hdb.Connection.ConnectTimeoutSeconds), 10))
+	}
+
+	/*Setting the server name is enough for the driver to use TLS, verified against the system roots by default*/
+	if hdb.Connection.UseTLS(hdb.Hostname) {
+		params.Set(driver.DSNTLSServerName, hdb.Hostname)
+		if hdb.Connection.TLSRootCAFile != "" {
+			params.Set(driver.DSNTLSRootCAFile, hdb.Connection.TLSRootCAFile)
+		}
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+	return fmt.Sprintf("%s?%s", dsn, params.Encode())
 }
 
-//helper function that connects to the target database and populates the DbConfig.db struct.
-func (hdb *DbConfig) NewDb() error {
-	var err error
-	hdb.db, err = sql.Open("hdb", hdb.Dsn())
+//The unit of the connection backoff, shortened in tests
+var connectBackoffUnit = time.Second
+
+//Opens the connection to the host set in the DbConfig, replaced in tests to connect to mock databases
+var newDbFunc = func(hdb *DbConfig, lc chan<- LogMessage) error {
+	return hdb.NewDb(lc)
+}
+
+//helper function that connects to the target database and populates the DbConfig.db struct.  The pool settings
+//from 'Connection' are applied, the sessions are named for the run lock and the connection is closed again when it
+//cannot be made.
+func (hdb *DbConfig) NewDb(lc chan<- LogMessage) error {
+	connector, err := driver.NewDSNConnector(hdb.Dsn())
+	if err != nil {
+		return err
+	}
+
+	/*Name the sessions so that other HCC runs can find them, see CheckDatabaseLockFunc*/
+	if err = connector.SetApplicationName(sessionName()); err != nil {
+		return err
+	}
+	if err = connector.SetSessionVariables(driver.SessionVariables{"APPLICATION": sessionName()}); err != nil {
+		return err
+	}
+	hdb.db = sql.OpenDB(connector)
+
+	if hdb.Connection.MaxOpenConnections > 0 {
+		hdb.db.SetMaxOpenConns(int(hdb.Connection.MaxOpenConnections))
+	}
+	if hdb.Connection.MaxIdleConnections > 0 {
+		hdb.db.SetMaxIdleConns(int(hdb.Connection.MaxIdleConnections))
+	}
+	if hdb.Connection.ConnectionLifetimeMinutes > 0 {
+		hdb.db.SetConnMaxLifetime(time.Duration(hdb.Connection.ConnectionLifetimeMinutes) * time.Minute)
+	}
+
+	err = hdb.pingDb(lc)
 	if err != nil {
+		hdb.CloseDb(lc)
 		return err
 	}
 
-	err = hdb.db.Ping()
+	return nil
+}
+
+//Pings the database until it answers or the connection attempts are used up, waiting longer before each retry.
+//The time taken by the successful attempt is recorded as the connect latency.
+func (hdb *DbConfig) pingDb(lc chan<- LogMessage) error {
+	fname := fmt.Sprintf("%s:%s", hdb.Name, "Connect")
+	var err error
+	attempts := hdb.Connection.Attempts()
+	for k := uint(1); k <= attempts; k++ {
+		start := time.Now()
+		err = hdb.db.Ping()
+		if err == nil {
+			hdb.Results.ConnectLatency = time.Since(start)
+			hdb.Results.ConnectAttempts = k
+			lc <- LogMessage{fname, fmt.Sprintf("Connected to %s:%d in %s", hdb.Hostname, hdb.Port, hdb.Results.ConnectLatency), true}
+			return nil
+		}
+		if k == attempts {
+			break
+		}
+		wait := hdb.Connection.Backoff(k)
+		lc <- LogMessage{fname, fmt.Sprintf("Connection attempt %d of %d to %s:%d failed, retrying in %s", k, attempts, hdb.Hostname, hdb.Port, wait), false}
+		lc <- LogMessage{fname, err.Error(), true}
+		time.Sleep(wait)
+	}
+	return err
+}
+
+//Closes the connection to the database.  It is safe to call when there is no connection.
+func (hdb *DbConfig) CloseDb(lc chan<- LogMessage) {
+	if hdb.db == nil {
+		return
+	}
+	err := hdb.db.Close()
+	if err != nil {
+		lc <- LogMessage{fmt.Sprintf("%s:%s", hdb.Name, "Connect"), fmt.Sprintf("Closing the connection failed: %s", err.Error()), false}
+	}
+	hdb.db = nil
+}
+
+//A host that may be the system replication primary
+type candidateHost struct {
+	host string
+	port uint
+}
+
+func (c candidateHost) String() string {
+	return net.JoinHostPort(c.host, strconv.FormatUint(uint64(c.port), 10))
+}
+
+//Parses a candidate host given as "host" or "host:port", the port defaults to the one passed
+func parseCandidateHost(s string, port uint) (candidateHost, error) {
+	if !strings.Contains(s, ":") {
+		if s == "" {
+			return candidateHost{}, fmt.Errorf("empty host name")
+		}
+		return candidateHost{s, port}, nil
+	}
+	h, p, err := net.SplitHostPort(s)
+	if err != nil {
+		return candidateHost{}, err
+	}
+	if h == "" {
+		return candidateHost{}, fmt.Errorf("empty host name in '%s'", s)
+	}
+	n, err := strconv.ParseUint(p, 10, 16)
+	if err != nil {
+		return candidateHost{}, fmt.Errorf("invalid port in '%s'", s)
+	}
+	return candidateHost{h, uint(n)}, nil
+}
+
+//Parses a list of "host" or "host:port" entries, the port defaults to the one passed
+func parseHostList(list []string, port uint) ([]candidateHost, error) {
+	hosts := []candidateHost{}
+	for _, v := range list {
+		c, err := parseCandidateHost(v, port)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, c)
+	}
+	return hosts, nil
+}
+
+//Returns the hosts that may be the system replication primary, 'Hostname' first followed by the replication
+//candidate hosts
+func (hdb DbConfig) candidateHosts() ([]candidateHost, error) {
+	hosts, err := parseHostList(hdb.Replication.CandidateHosts, hdb.Port)
+	if err != nil {
+		return nil, err
+	}
+	return append([]candidateHost{{hdb.Hostname, hdb.Port}}, hosts...), nil
+}
+
+//Returns the other hosts of a scale-out system to connect to when 'Hostname' cannot be reached
+func (hdb DbConfig) failoverHosts() ([]candidateHost, error) {
+	return parseHostList(hdb.Connection.FailoverHosts, hdb.Port)
+}
+
+//Connects to a host.  When it cannot be reached each of the failover hosts passed is tried in turn, the host that
+//was connected to is returned.
+func (hdb *DbConfig) connectHost(lc chan<- LogMessage, c candidateHost, failover []candidateHost) (candidateHost, error) {
+	fname := fmt.Sprintf("%s:%s", hdb.Name, "ConnectPrimary")
+	var err error
+	for k, h := range append([]candidateHost{c}, failover...) {
+		if k > 0 {
+			lc <- LogMessage{fname, fmt.Sprintf("Trying failover host %s", h), false}
+		}
+		hdb.Hostname, hdb.Port = h.host, h.port
+		err = newDbFunc(hdb, lc)
+		if err == nil {
+			return h, nil
+		}
+		lc <- LogMessage{fname, fmt.Sprintf("Could not connect to %s", h), false}
+		lc <- LogMessage{fname, err.Error(), true}
+	}
+	return candidateHost{}, err
+}
+
+//Connects to the database, preferring the current system replication primary.  Each candidate host is tried in
+//turn and the first one that is the primary or is not replicated is used.  The failover hosts are only tried when
+//'Hostname' cannot be reached, they are hosts of the same scale-out system and not replication candidates.  When
+//only secondaries, or hosts whose role could not be determined, can be reached the connection falls back to one of
+//them so that the role is reported and the tasks can be skipped.
+func (hdb *DbConfig) ConnectPrimaryFunc(lc chan<- LogMessage) error {
+	fname := fmt.Sprintf("%s:%s", hdb.Name, "ConnectPrimary")
+	hosts, err := hdb.candidateHosts()
 	if err != nil {
 		return err
 	}
+	failover, err := hdb.failoverHosts()
+	if err != nil {
+		return err
+	}
+
+	var fallback *candidateHost
+	var fallbackRole string
+	var lastErr error
+	for k := range hosts {
+		lc <- LogMessage{fname, fmt.Sprintf("Trying %s", hosts[k]), true}
+		var connected candidateHost
+		if k == 0 {
+			connected, err = hdb.connectHost(lc, hosts[k], failover)
+		} else {
+			connected, err = hdb.connectHost(lc, hosts[k], nil)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		/*The version selects the query variants, including the one used for the replication role*/
+		_, err = hdb.HanaVersionFunc(lc)
+		if err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Could not get the HANA version of %s", connected), false}
+			hdb.CloseDb(lc)
+			lastErr = err
+			continue
+		}
+
+		role, err := hdb.ReplicationRoleFunc(lc)
+		if err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Could not determine the replication role of %s", connected), false}
+			hdb.Results.ReplicationRole = role
+		}
+		if role == ReplicationPrimary || role == ReplicationNone || len(hosts) == 1 {
+			hdb.Results.ConnectedHost = connected.String()
+			lc <- LogMessage{fname, fmt.Sprintf("Connected to %s", connected), false}
+			return nil
+		}
+
+		/*Prefer a host of unknown role over a known secondary*/
+		if fallback == nil || (fallbackRole == ReplicationSecondary && role == ReplicationUnknown) {
+			fallback, fallbackRole = &connected, role
+		}
+		hdb.CloseDb(lc)
+	}
 
+	if fallback == nil {
+		return lastErr
+	}
+	if _, err = hdb.connectHost(lc, *fallback, nil); err != nil {
+		return err
+	}
+	if _, err = hdb.HanaVersionFunc(lc); err != nil {
+		hdb.CloseDb(lc)
+		return err
+	}
+	hdb.Results.ReplicationRole = fallbackRole
+	hdb.Results.ConnectedHost = fallback.String()
+	lc <- LogMessage{fname, fmt.Sprintf("No primary found, connected to %s which is %s", fallback, fallbackRole), false}
 	return nil
 }
 
@@ -65,15 +328,109 @@ func (db *DbConfig) GetPasswordFromEnv() error {
 }
 
 type CleanResults struct {
-	TraceFilesRemoved       uint
-	BackupFilesRemoved      uint
-	BackupFilesBytesRemoved uint
-	AlertsRemoved           uint
-	LogSegmentsRemoved      uint
-	LogSegmentsBytesRemoved uint
-	AuditEntriesRemoved     uint
-	DataVolumeBytesRemoved  uint
-	TotalDiskBytesRemoved   uint
+	Version                  HanaVersion //Version of the connected database
+	CloudProfile             []string    //Task changes made by the HANA Cloud profile
+	TraceFilesRemoved        uint
+	TraceQuotaFilesRemoved   uint
+	TraceQuotaBytesRemoved   uint
+	TraceQuotaHostsOver      uint   //Hosts that could not be brought within the trace quota
+	BackupAnchorID           string //Catalog entries before this backup are removed
+	BackupAnchorDate         string
+	BackupSafetyFailure      string //Reason the backup safety check prevented truncation, empty if it passed or was not run
+	BackupExportFile         string //File the removed catalog entries were exported to
+	BackupFilesRemoved       uint
+	BackupFilesBytesRemoved  uint
+	BackupBytesByDestination map[string]uint //Bytes physically removed per destination type
+	AlertsRemoved            uint
+	AlertsRemovedByID        map[uint]uint //Alerts removed for each alert ID
+	AlertBatches             uint
+	AlertsRemaining          uint //Alerts past their retention left for the next run when the run time budget was reached
+	LogSegmentsRemoved       uint
+	LogSegmentsBytesRemoved  uint
+	LogMode                  string //The log mode read from global.ini, empty if it was not checked
+	LogReclaimSkipped        string //Reason the log volume guards prevented the reclaim, empty if it ran
+	LogVolumeBytesBefore     uint64
+	LogVolumeBytesAfter      uint64
+	LogVolumeMeasuredBefore  bool //True if LogVolumeBytesBefore was read from the database
+	LogVolumeMeasuredAfter   bool //True if LogVolumeBytesAfter was read from the database after the reclaim
+	AuditEntriesRemoved      uint
+	AuditEntriesArchived     uint
+	AuditRuleExpired         map[string]uint //Records past their retention for each audit rule
+	AuditRetainDays          uint            //The audit retention used after applying the audit rules
+	AuditConstrainingRule    string          //The audit rule that set AuditRetainDays, empty if the default was used
+	AuditArchiveFiles        []string
+	DataVolumeBytesRemoved   uint
+	DataVolumeSkipped        []string //Data volumes that needed reclaiming but were skipped, with the reason
+	TablesOptimised          []TableOptimiseResult
+	TablesDeferred           uint //Tables over the optimisation thresholds that were left for a later run
+	SessionsDisconnected     uint
+	MonitoringViewsReset     uint
+	MonitoringRowsReset      uint
+	TraceLevelsReset         []string //Trace level settings that were unset
+	PlanCacheReason          string   //Why the plan cache was cleared, empty if it was within its limits
+	PlanCacheEntriesRemoved  uint
+	PlanCacheBytesBefore     uint64
+	PlanCacheBytesAfter      uint64
+	TableRowsRemoved         map[string]uint        //Rows removed from each retention table, keyed by schema.table
+	TraceRemovedByHost       map[string]NodeRemoved //Trace files removed per host by the trace tasks
+	DataVolumeByService      map[string]uint64      //Data volume bytes reclaimed per service, keyed by host:port
+	LogSegmentsByService     map[string]NodeRemoved //Free log segments reclaimed per service, keyed by host:port
+	ReplicationRole          string                 //System replication role of the connected host
+	SecondarySkipped         []string               //Tasks skipped because the connected host is a system replication secondary
+	ConnectedHost            string                 //The host and port HCC connected to
+	ConnectLatency           time.Duration          //Time taken by the successful connection attempt
+	ConnectAttempts          uint                   //The number of attempts needed to connect
+	LockFile                 string                 //The lock file held while the database was processed
+	Retries                  uint                   //Statements retried after a transient error
+	TotalDiskBytesRemoved    uint
+	Plan                     []string //Changes made, or in dry run mode changes that would be made
+}
+
+//Files or segments removed from a single host or service of a scale-out system
+type NodeRemoved struct {
+	Count uint
+	Bytes uint64
+}
+
+//Adds a count and size to the entry for key, creating the map if needed
+func addNodeRemoved(m map[string]NodeRemoved, key string, count uint, bytes uint64) map[string]NodeRemoved {
+	if m == nil {
+		m = make(map[string]NodeRemoved)
+	}
+	n := m[key]
+	n.Count += count
+	n.Bytes += bytes
+	m[key] = n
+	return m
+}
+
+//Returns the keys of a per host or per service breakdown in order
+func nodeKeys(m map[string]NodeRemoved) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Logs a change that is about to be made and records it in the plan.  In dry run mode the
+//plan is printed instead of the cleaning report.
+func (dbc *DbConfig) AddPlan(lc chan<- LogMessage, fname, step string) {
+	lc <- LogMessage{fname, step, false}
+	dbc.Results.Plan = append(dbc.Results.Plan, step)
+}
+
+//Prints the plan recorded while processing the database
+func (dbc *DbConfig) PrintPlan() {
+	fmt.Printf("%s:Dry Run Plan\n", dbc.Name)
+	if len(dbc.Results.Plan) == 0 {
+		fmt.Printf("No changes would be made\n")
+		return
+	}
+	for k, v := range dbc.Results.Plan {
+		fmt.Printf("%d. %s\n", k+1, v)
+	}
 }
 
 func (dbc *DbConfig) PrintResults() {
@@ -83,11 +440,82 @@ func (dbc *DbConfig) PrintResults() {
 
 	fmt.Printf("%s:Cleaning Report\n", dbc.Name)
 
+	/*Version report*/
+	if dbc.Results.Version.Raw != "" {
+		p.Printf("HANA version:\t\t\t%s\n", dbc.Results.Version)
+	}
+
+	if len(dbc.Results.CloudProfile) > 0 {
+		p.Printf("HANA Cloud profile:\t\t%s\n", strings.Join(dbc.Results.CloudProfile, "; "))
+	}
+
+	/*Replication report*/
+	if dbc.Results.ReplicationRole != "" {
+		p.Printf("Replication role:\t\t%s (%s)\n", dbc.Results.ReplicationRole, dbc.Results.ConnectedHost)
+	}
+	if len(dbc.Results.SecondarySkipped) > 0 {
+		p.Printf("Skipped on secondary:\t\t%s\n", strings.Join(dbc.Results.SecondarySkipped, ", "))
+	}
+
+	/*Connection report*/
+	if dbc.Results.ConnectAttempts > 0 {
+		p.Printf("Connect latency:\t\t%s (%d attempts)\n", dbc.Results.ConnectLatency.Round(time.Millisecond), dbc.Results.ConnectAttempts)
+	}
+
+	if dbc.Results.LockFile != "" {
+		p.Printf("Run lock:\t\t\t%s\n", dbc.Results.LockFile)
+	}
+
+	if dbc.Results.Retries > 0 {
+		p.Printf("Transient errors retried:\t%d\n", dbc.Results.Retries)
+	}
+
+	/*Trace file report*/
+	if dbc.CleanTrace || dbc.TraceQuota.Enabled {
+		p.Printf("Trace files removed:\t\t%d\n", dbc.Results.TraceFilesRemoved+dbc.Results.TraceQuotaFilesRemoved)
+		for _, k := range nodeKeys(dbc.Results.TraceRemovedByHost) {
+			v := dbc.Results.TraceRemovedByHost[k]
+			p.Printf("  %s:\t\t\t%d files, %.2fMiB\n", k, v.Count, float64(v.Bytes)/1024/1024)
+		}
+	} else {
+		p.Printf("Trace files removed:\t\tNot Enabled\n")
+	}
+
+	/*Trace quota report*/
+	if dbc.TraceQuota.Enabled {
+		p.Printf("Trace quota files removed:\t%d\n", dbc.Results.TraceQuotaFilesRemoved)
+		p.Printf("Trace quota data removed:\t%.2fMiB\n", float64(dbc.Results.TraceQuotaBytesRemoved)/1024/1024)
+		if dbc.Results.TraceQuotaHostsOver == 0 {
+			p.Printf("Trace quota met:\t\tYes\n")
+		} else {
+			p.Printf("Trace quota met:\t\tNo, %d host(s) over quota\n", dbc.Results.TraceQuotaHostsOver)
+		}
+	} else {
+		p.Printf("Trace quota files removed:\tNot Enabled\n")
+	}
+
 	/*Backup file report*/
 	if dbc.CleanBackupCatalog {
+		if dbc.Results.BackupAnchorID != "" {
+			p.Printf("Backup catalog anchor:\t\t%s (%s)\n", dbc.Results.BackupAnchorID, dbc.Results.BackupAnchorDate)
+		}
+		if dbc.Results.BackupExportFile != "" {
+			p.Printf("Backup catalog export:\t\t%s\n", dbc.Results.BackupExportFile)
+		}
+		if dbc.Results.BackupSafetyFailure != "" {
+			p.Printf("Backup safety check:\t\tFailed, %s\n", dbc.Results.BackupSafetyFailure)
+		}
 		p.Printf("Backup files removed:\t\t%d\n", dbc.Results.BackupFilesRemoved)
 		if dbc.DeleteOldBackups {
 			p.Printf("Backup data removed:\t\t%.2fMiB\n", float64(dbc.Results.BackupFilesBytesRemoved/1024/1024))
+			dests := make([]string, 0, len(dbc.Results.BackupBytesByDestination))
+			for k := range dbc.Results.BackupBytesByDestination {
+				dests = append(dests, k)
+			}
+			sort.Strings(dests)
+			for _, k := range dests {
+				p.Printf("  %s:\t\t\t%.2fMiB\n", k, float64(dbc.Results.BackupBytesByDestination[k])/1024/1024)
+			}
 		} else {
 			p.Printf("Backup data removed:\t\tNot Enabled\n")
 		}
@@ -99,6 +527,15 @@ func (dbc *DbConfig) PrintResults() {
 	/*Alerts report*/
 	if dbc.CleanAlerts {
 		p.Printf("Alert entries removed:\t\t%d\n", dbc.Results.AlertsRemoved)
+		for _, id := range sortedKeys(dbc.Results.AlertsRemovedByID) {
+			p.Printf("  Alert %d:\t\t\t%d\n", id, dbc.Results.AlertsRemovedByID[id])
+		}
+		if dbc.Alerts.Batched() {
+			p.Printf("Alert delete batches:\t\t%d\n", dbc.Results.AlertBatches)
+		}
+		if dbc.Results.AlertsRemaining > 0 {
+			p.Printf("Alerts left for next run:\t%d\n", dbc.Results.AlertsRemaining)
+		}
 	} else {
 		p.Printf("Alert entries removed:\t\tNot Enabled\n")
 	}
@@ -106,6 +543,19 @@ func (dbc *DbConfig) PrintResults() {
 	/*Audit report*/
 	if dbc.CleanAudit {
 		p.Printf("Audit entries removed:\t\t%d\n", dbc.Results.AuditEntriesRemoved)
+		if len(dbc.Audit.Rules) > 0 {
+			if dbc.Results.AuditConstrainingRule != "" {
+				p.Printf("Audit retention used:\t\t%d days (rule %s)\n", dbc.Results.AuditRetainDays, dbc.Results.AuditConstrainingRule)
+			} else {
+				p.Printf("Audit retention used:\t\t%d days\n", dbc.Results.AuditRetainDays)
+			}
+			for _, r := range dbc.Audit.Rules {
+				p.Printf("  %s:\t\t\t%d past retention\n", r.Name, dbc.Results.AuditRuleExpired[r.Name])
+			}
+		}
+		if dbc.Audit.ArchiveDir != "" {
+			p.Printf("Audit entries archived:\t\t%d (%d files)\n", dbc.Results.AuditEntriesArchived, len(dbc.Results.AuditArchiveFiles))
+		}
 	} else {
 		p.Printf("Audit entries removed:\t\tNot Enabled\n")
 	}
@@ -114,6 +564,22 @@ func (dbc *DbConfig) PrintResults() {
 	if dbc.CleanLogVolume {
 		p.Printf("Log Segments removed:\t\t%d\n", dbc.Results.LogSegmentsRemoved)
 		p.Printf("Log Segments reduced by:\t%.2fMiB\n", float64(dbc.Results.LogSegmentsBytesRemoved)/1024/1024)
+		for _, k := range nodeKeys(dbc.Results.LogSegmentsByService) {
+			v := dbc.Results.LogSegmentsByService[k]
+			p.Printf("  %s:\t\t%d segments, %.2fMiB\n", k, v.Count, float64(v.Bytes)/1024/1024)
+		}
+		if dbc.Results.LogReclaimSkipped != "" {
+			p.Printf("Log reclaim skipped:\t\t%s\n", dbc.Results.LogReclaimSkipped)
+		}
+		/*The size after is only known when the reclaim ran and the size could be read again*/
+		switch {
+		case dbc.Results.LogVolumeMeasuredBefore && dbc.Results.LogVolumeMeasuredAfter:
+			p.Printf("Log Volume size:\t\t%.2fMiB before, %.2fMiB after\n", float64(dbc.Results.LogVolumeBytesBefore)/1024/1024, float64(dbc.Results.LogVolumeBytesAfter)/1024/1024)
+		case dbc.Results.LogVolumeMeasuredBefore:
+			p.Printf("Log Volume size:\t\t%.2fMiB before\n", float64(dbc.Results.LogVolumeBytesBefore)/1024/1024)
+		case dbc.Results.LogVolumeMeasuredAfter:
+			p.Printf("Log Volume size:\t\t%.2fMiB after\n", float64(dbc.Results.LogVolumeBytesAfter)/1024/1024)
+		}
 	} else {
 		p.Printf("Log Segments removed:\t\tNot Enabled\n")
 		p.Printf("Log Segments reduction:\t\tNot Enabled\n")
@@ -121,9 +587,101 @@ func (dbc *DbConfig) PrintResults() {
 
 	/*Data Report*/
 	if dbc.CleanDataVolume {
-		p.Printf("Data Volume reduced by:\t\t%.2fMiB\n", float64(dbc.Results.LogSegmentsBytesRemoved)/1024/1024)
+		p.Printf("Data Volume reduced by:\t\t%.2fMiB\n", float64(dbc.Results.DataVolumeBytesRemoved)/1024/1024)
+		services := make([]string, 0, len(dbc.Results.DataVolumeByService))
+		for k := range dbc.Results.DataVolumeByService {
+			services = append(services, k)
+		}
+		sort.Strings(services)
+		for _, k := range services {
+			p.Printf("  %s:\t\t%.2fMiB\n", k, float64(dbc.Results.DataVolumeByService[k])/1024/1024)
+		}
+		for _, v := range dbc.Results.DataVolumeSkipped {
+			p.Printf("Data Volume skipped:\t\t%s\n", v)
+		}
 	} else {
 		p.Printf("Data Volume reduction:\t\tNot Enabled\n")
 	}
 
+	/*Table optimisation report*/
+	if dbc.TableOptimise.Enabled {
+		p.Printf("Tables optimised:\t\t%d\n", len(dbc.Results.TablesOptimised))
+		for _, v := range dbc.Results.TablesOptimised {
+			ops := []string{}
+			if v.Merged {
+				ops = append(ops, "delta merge")
+			}
+			if v.Compressed {
+				ops = append(ops, "compression")
+			}
+			if v.BytesAfter == 0 {
+				p.Printf("  %s.%s:\t\t%.2fMiB before, size after unknown (%s)\n", v.Schema, v.Table, float64(v.BytesBefore)/1024/1024, strings.Join(ops, ", "))
+			} else {
+				p.Printf("  %s.%s:\t\t%.2fMiB -> %.2fMiB (%s)\n", v.Schema, v.Table, float64(v.BytesBefore)/1024/1024, float64(v.BytesAfter)/1024/1024, strings.Join(ops, ", "))
+			}
+		}
+		if dbc.Results.TablesDeferred > 0 {
+			p.Printf("Tables deferred:\t\t%d, over the per run limit\n", dbc.Results.TablesDeferred)
+		}
+	} else {
+		p.Printf("Tables optimised:\t\tNot Enabled\n")
+	}
+
+	/*Idle session report*/
+	if dbc.IdleSessions.Enabled {
+		p.Printf("Idle sessions disconnected:\t%d\n", dbc.Results.SessionsDisconnected)
+	} else {
+		p.Printf("Idle sessions disconnected:\tNot Enabled\n")
+	}
+
+	/*Monitoring view report*/
+	if dbc.MonitoringReset.Enabled {
+		p.Printf("Monitoring views reset:\t\t%d (%d rows)\n", dbc.Results.MonitoringViewsReset, dbc.Results.MonitoringRowsReset)
+	} else {
+		p.Printf("Monitoring views reset:\t\tNot Enabled\n")
+	}
+
+	/*Trace level report*/
+	if dbc.TraceLevels.Enabled {
+		p.Printf("Trace levels reset:\t\t%d\n", len(dbc.Results.TraceLevelsReset))
+		for _, v := range dbc.Results.TraceLevelsReset {
+			p.Printf("  %s\n", v)
+		}
+	} else {
+		p.Printf("Trace levels reset:\t\tNot Enabled\n")
+	}
+
+	/*Plan cache report*/
+	if dbc.PlanCache.Enabled {
+		if dbc.Results.PlanCacheReason == "" {
+			p.Printf("Plan cache cleared:\t\tNo, within limits\n")
+		} else {
+			p.Printf("Plan cache cleared:\t\tYes, %s\n", dbc.Results.PlanCacheReason)
+			if dbc.PlanCache.Filtered() {
+				p.Printf("Plan cache entries removed:\t%d\n", dbc.Results.PlanCacheEntriesRemoved)
+			}
+			if dbc.Results.PlanCacheBytesBefore > dbc.Results.PlanCacheBytesAfter {
+				p.Printf("Plan cache memory freed:\t%.2fMiB\n", float64(dbc.Results.PlanCacheBytesBefore-dbc.Results.PlanCacheBytesAfter)/1024/1024)
+			} else {
+				p.Printf("Plan cache memory freed:\t0.00MiB\n")
+			}
+		}
+	} else {
+		p.Printf("Plan cache cleared:\t\tNot Enabled\n")
+	}
+
+	/*Table retention report*/
+	if dbc.TableRetention.Enabled {
+		var total uint
+		for _, v := range dbc.Results.TableRowsRemoved {
+			total += v
+		}
+		p.Printf("Table rows removed:\t\t%d\n", total)
+		for _, t := range dbc.TableRetention.Tables {
+			p.Printf("  %s:\t%d\n", t, dbc.Results.TableRowsRemoved[t.String()])
+		}
+	} else {
+		p.Printf("Table rows removed:\t\tNot Enabled\n")
+	}
+
 }
@@ -3,10 +3,14 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
-//HanaVersion function returns the version string of the database
+//HanaVersion function returns the version string of the database.  The parsed version is stored in the results and
+//selects the query variants used for the database.
 func (dbc *DbConfig) HanaVersionFunc(lc chan<- LogMessage) (string, error) {
 	fname := fmt.Sprintf("%s:%s", dbc.Name, "HanaVersion")
 	var version string
@@ -19,10 +23,53 @@ func (dbc *DbConfig) HanaVersionFunc(lc chan<- LogMessage) (string, error) {
 		lc <- LogMessage{fname, err.Error(), true}
 		return "", err // allow calling function to handle the error
 	}
+	dbc.Results.Version, err = ParseHanaVersion(version)
+	if err != nil {
+		lc <- LogMessage{fname, fmt.Sprintf("Could not parse the version, the current query variants will be used: %s", err.Error()), false}
+	}
 	lc <- LogMessage{fname, "OK", true}
 	return version, nil
 }
 
+//ReplicationRoleFunc determines the system replication role of the connected host and stores it in the results.
+//A site that is replicated to with an operation mode in effect is a secondary, a site with secondary sites or the
+//primary mode is a primary and anything else is not replicated.
+func (dbc *DbConfig) ReplicationRoleFunc(lc chan<- LogMessage) (string, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "ReplicationRole")
+	var mode string
+	var secondaries, inactive uint
+	lc <- LogMessage{fname, "Starting", false}
+	query := GetReplicationRole(dbc.Results.Version)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+	err := dbc.db.QueryRow(query).Scan(&mode, &secondaries, &inactive)
+	if err != nil {
+		lc <- LogMessage{fname, "Query failed", false}
+		lc <- LogMessage{fname, err.Error(), true}
+		return ReplicationUnknown, err
+	}
+
+	role := replicationRole(mode, secondaries)
+	lc <- LogMessage{fname, fmt.Sprintf("Replication mode '%s' with %d secondary site(s), role is %s", mode, secondaries, role), false}
+	if role == ReplicationPrimary && inactive > 0 {
+		lc <- LogMessage{fname, fmt.Sprintf("%d replicated service(s) are not active", inactive), false}
+	}
+	dbc.Results.ReplicationRole = role
+	return role, nil
+}
+
+//Maps the replication mode and the number of secondary sites to a replication role
+func replicationRole(mode string, secondaries uint) string {
+	mode = strings.ToLower(mode)
+	switch {
+	case mode != "" && mode != ReplicationPrimary && mode != ReplicationNone:
+		return ReplicationSecondary
+	case mode == ReplicationPrimary || secondaries > 0:
+		return ReplicationPrimary
+	default:
+		return ReplicationNone
+	}
+}
+
 //CleanTraceFiles function removes closed trace files that are older than the number of days
 //specified in the 'CleanDaysOlder' argument.  The function will log all activity.  The function will also return
 //an error.  If no errors are found nil is returned.
@@ -48,25 +95,32 @@ func (dbc *DbConfig) CleanTraceFilesFunc(lc chan<- LogMessage, CleanDaysOlder ui
 	//fmt.Printf("%s", GetTraceFileQuery(CleanDaysOlder))
 	lc <- LogMessage{fname, fmt.Sprintf("Performing query:'%s'", GetTraceFileQuery(CleanDaysOlder)), true}
 
-	rows, err := dbc.db.Query(GetTraceFileQuery(CleanDaysOlder))
-	if err != nil {
-		lc <- LogMessage{fname, "Query Failed", false}
-		lc <- LogMessage{fname, err.Error(), true}
-		/*allow calling function to deal with error*/
-		return err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		tf := TraceFile{}
-		err := rows.Scan(&tf.Hostname, &tf.TraceFile, &tf.SizeBytes, &tf.LastModified)
+	/*A read, so it is safe to retry after a transient error*/
+	err := dbc.withRetry(lc, fname, RetryTaskCleanTrace, "Trace file query", func() error {
+		TraceFiles = TraceFiles[:0]
+		rows, err := dbc.db.Query(GetTraceFileQuery(CleanDaysOlder))
 		if err != nil {
-			lc <- LogMessage{fname, "Scan Error", false}
+			lc <- LogMessage{fname, "Query Failed", false}
 			lc <- LogMessage{fname, err.Error(), true}
-			/*allow calling function to deal with the error*/
 			return err
 		}
-		TraceFiles = append(TraceFiles, tf)
+		defer rows.Close()
+
+		for rows.Next() {
+			tf := TraceFile{}
+			err := rows.Scan(&tf.Hostname, &tf.TraceFile, &tf.SizeBytes, &tf.LastModified)
+			if err != nil {
+				lc <- LogMessage{fname, "Scan Error", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return err
+			}
+			TraceFiles = append(TraceFiles, tf)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		/*allow calling function to deal with error*/
+		return err
 	}
 
 	if len(TraceFiles) == 0 {
@@ -81,38 +135,8 @@ func (dbc *DbConfig) CleanTraceFilesFunc(lc chan<- LogMessage, CleanDaysOlder ui
 
 		/*do nothing destructive if dryrun enabled*/
 		if !dryrun {
-			lc <- LogMessage{fname, fmt.Sprintf("Performing Query'%s'", GetRemoveTrace(v.Hostname, v.TraceFile)), true}
-			_, err := dbc.db.Exec(GetRemoveTrace(v.Hostname, v.TraceFile))
-			if err != nil {
-				lc <- LogMessage{fname, fmt.Sprintf("The tracefile '%s' on host '%s' could not be removed, it may be open!  This will be retried next time.", v.TraceFile, v.Hostname), false}
-				lc <- LogMessage{fname, err.Error(), false}
+			if !dbc.removeTraceFile(lc, fname, v) {
 				continue
-
-			}
-			//Check if the trace file was actually deleted
-			var tracePresent uint = 0
-
-			lc <- LogMessage{fname, "Checking if tracefile was removed", true}
-			lc <- LogMessage{fname, fmt.Sprint("Performing Query:", v.TraceFile), true}
-			err = dbc.db.QueryRow(GetCheckTracePresent(v.TraceFile)).Scan(&tracePresent)
-			switch {
-			case err == sql.ErrNoRows:
-				lc <- LogMessage{fname, "No rows returned", false}
-				lc <- LogMessage{fname, err.Error(), false}
-				lc <- LogMessage{fname, fmt.Sprintf("Failed to remove tracefile %s", v.TraceFile), true}
-				continue /*try the next one perhaps we should check for how many files couldn't be removed*/
-			case err != nil:
-				lc <- LogMessage{fname, "DB failed to query failed", false}
-				lc <- LogMessage{fname, err.Error(), false}
-				lc <- LogMessage{fname, fmt.Sprintf("Failed to remove tracefile %s", v.TraceFile), true}
-				continue /*try the next one - don't throw error - perhaps we should check for how many files couldn't be removed*/
-			default:
-				if tracePresent == 0 {
-					lc <- LogMessage{fname, fmt.Sprintf("Successfully removed trace file %s", v.TraceFile), true}
-				} else { /*for trace files we should only ever see 0 or 1*/
-					lc <- LogMessage{fname, fmt.Sprintf("Tracefile %s was not removed", v.TraceFile), true}
-					continue
-				}
 			}
 			count += 1
 			saved += v.SizeBytes
@@ -125,6 +149,188 @@ func (dbc *DbConfig) CleanTraceFilesFunc(lc chan<- LogMessage, CleanDaysOlder ui
 	return nil
 }
 
+//Attempts to remove a single trace file and then checks that it is no longer present.  Failures are
+//logged but not returned as open trace files cannot be removed and this is expected.
+//Returns true only if the trace file was confirmed as removed.
+func (dbc *DbConfig) removeTraceFile(lc chan<- LogMessage, fname string, v TraceFile) bool {
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query'%s'", GetRemoveTrace(v.Hostname, v.TraceFile)), true}
+	/*Removing a trace file that is already gone does no harm, so the removal and the check can be retried*/
+	err := dbc.withRetry(lc, fname, RetryTaskCleanTrace, "Trace file removal", func() error {
+		_, err := dbc.db.Exec(GetRemoveTrace(v.Hostname, v.TraceFile))
+		return err
+	})
+	if err != nil {
+		lc <- LogMessage{fname, fmt.Sprintf("The tracefile '%s' on host '%s' could not be removed, it may be open!  This will be retried next time.", v.TraceFile, v.Hostname), false}
+		lc <- LogMessage{fname, err.Error(), false}
+		return false
+	}
+	//Check if the trace file was actually deleted
+	var tracePresent uint = 0
+
+	lc <- LogMessage{fname, "Checking if tracefile was removed", true}
+	lc <- LogMessage{fname, fmt.Sprint("Performing Query:", v.TraceFile), true}
+	err = dbc.withRetry(lc, fname, RetryTaskCleanTrace, "Trace file check", func() error {
+		return dbc.db.QueryRow(GetCheckTracePresent(v.TraceFile)).Scan(&tracePresent)
+	})
+	switch {
+	case err == sql.ErrNoRows:
+		lc <- LogMessage{fname, "No rows returned", false}
+		lc <- LogMessage{fname, err.Error(), false}
+		lc <- LogMessage{fname, fmt.Sprintf("Failed to remove tracefile %s", v.TraceFile), true}
+		return false /*the caller will try the next one*/
+	case err != nil:
+		lc <- LogMessage{fname, "DB failed to query failed", false}
+		lc <- LogMessage{fname, err.Error(), false}
+		lc <- LogMessage{fname, fmt.Sprintf("Failed to remove tracefile %s", v.TraceFile), true}
+		return false /*don't throw error, the caller will try the next one*/
+	default:
+		if tracePresent == 0 {
+			lc <- LogMessage{fname, fmt.Sprintf("Successfully removed trace file %s", v.TraceFile), true}
+			dbc.Results.TraceRemovedByHost = addNodeRemoved(dbc.Results.TraceRemovedByHost, v.Hostname, 1, v.SizeBytes)
+		} else { /*for trace files we should only ever see 0 or 1*/
+			lc <- LogMessage{fname, fmt.Sprintf("Tracefile %s was not removed", v.TraceFile), true}
+			return false
+		}
+	}
+	return true
+}
+
+//CleanTraceQuotaFunc enforces a maximum total size of trace files on each host.  Where a host is over the quota, closed trace files
+//are removed oldest first until the host is within the quota.  Files modified within 'MinAgeHours' and files matching one of the
+//'Exclusions' patterns are never removed.  The number of hosts that could not be brought within the quota is recorded in the results.
+func (dbc *DbConfig) CleanTraceQuotaFunc(lc chan<- LogMessage, dryrun bool) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanTraceQuota")
+	lc <- LogMessage{fname, "Starting", false}
+	if dryrun {
+		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
+	}
+	quota := uint64(dbc.TraceQuota.MaxMiBPerHost) * 1024 * 1024
+
+	/*Find how much trace is stored on each host.  The reads are safe to retry after a transient error and the
+	removals are retried by removeTraceFile*/
+	hosts := make([]string, 0)
+	hostBytes := make(map[string]uint64)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query:'%s'", QUERY_GetTraceSizePerHost), true}
+	err := dbc.withRetry(lc, fname, RetryTaskCleanTrace, "Trace size query", func() error {
+		hosts = hosts[:0]
+		rows, err := dbc.db.Query(QUERY_GetTraceSizePerHost)
+		if err != nil {
+			lc <- LogMessage{fname, "Query Failed", false}
+			lc <- LogMessage{fname, err.Error(), true}
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var host string
+			var bytes uint64
+			err := rows.Scan(&host, &bytes)
+			if err != nil {
+				lc <- LogMessage{fname, "Scan Error", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return err
+			}
+			hosts = append(hosts, host)
+			hostBytes[host] = bytes
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	/*Only bother finding candidates if at least one host is over quota*/
+	overQuota := false
+	for _, host := range hosts {
+		if hostBytes[host] > quota {
+			overQuota = true
+		}
+	}
+	if !overQuota {
+		lc <- LogMessage{fname, "All hosts are within the trace quota", true}
+		return nil
+	}
+
+	/*Get the candidate trace files for each host, oldest first*/
+	var candidates map[string][]TraceFile
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query:'%s'", GetTraceQuotaCandidates(dbc.TraceQuota.MinAgeHours)), true}
+	err = dbc.withRetry(lc, fname, RetryTaskCleanTrace, "Trace quota candidate query", func() error {
+		candidates = make(map[string][]TraceFile)
+		rows, err := dbc.db.Query(GetTraceQuotaCandidates(dbc.TraceQuota.MinAgeHours))
+		if err != nil {
+			lc <- LogMessage{fname, "Query Failed", false}
+			lc <- LogMessage{fname, err.Error(), true}
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			tf := TraceFile{}
+			err := rows.Scan(&tf.Hostname, &tf.TraceFile, &tf.SizeBytes, &tf.LastModified)
+			if err != nil {
+				lc <- LogMessage{fname, "Scan Error", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return err
+			}
+			candidates[tf.Hostname] = append(candidates[tf.Hostname], tf)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	var count uint = 0
+	var saved uint64 = 0
+	var hostsOver uint = 0
+	for _, host := range hosts {
+		used := hostBytes[host]
+		if used <= quota {
+			lc <- LogMessage{fname, fmt.Sprintf("Host %s is within the trace quota", host), true}
+			continue
+		}
+		lc <- LogMessage{fname, fmt.Sprintf("Host %s has %.2fMiB of trace files, the quota is %dMiB", host, float64(used)/1024/1024, dbc.TraceQuota.MaxMiBPerHost), false}
+
+		var reclaimed uint64 = 0
+		for _, v := range candidates[host] {
+			if used-reclaimed <= quota {
+				break
+			}
+			if dbc.TraceQuota.IsExcluded(v.TraceFile) {
+				lc <- LogMessage{fname, fmt.Sprintf("Tracefile %s matches an exclusion and will not be removed", v.TraceFile), true}
+				continue
+			}
+			/*do nothing destructive if dryrun enabled*/
+			if dryrun {
+				lc <- LogMessage{fname, fmt.Sprintf("Tracefile %s would be removed", v.TraceFile), true}
+				reclaimed += v.SizeBytes
+				continue
+			}
+			if !dbc.removeTraceFile(lc, fname, v) {
+				continue
+			}
+			count += 1
+			reclaimed += v.SizeBytes
+		}
+		saved += reclaimed
+
+		if used-reclaimed > quota {
+			hostsOver += 1
+			lc <- LogMessage{fname, fmt.Sprintf("Host %s could not be brought within the trace quota, %.2fMiB reclaimed", host, float64(reclaimed)/1024/1024), false}
+		} else {
+			lc <- LogMessage{fname, fmt.Sprintf("Host %s is now within the trace quota, %.2fMiB reclaimed", host, float64(reclaimed)/1024/1024), false}
+		}
+	}
+
+	if !dryrun {
+		dbc.Results.TraceQuotaFilesRemoved += count
+		dbc.Results.TraceQuotaBytesRemoved += uint(saved)
+		dbc.Results.TraceQuotaHostsOver += hostsOver
+		dbc.Results.TotalDiskBytesRemoved += uint(saved)
+	}
+	return nil
+}
+
 //This function truncates the backup catalog of the HANA database with the option of also deleting the underlying database backup files.
 //The options in the configuration file that control this are:
 //TruncateBackupCatalog - bool.  If true, the function will be triggered.
@@ -137,10 +343,14 @@ func (dbc *DbConfig) CleanBackupFunc(lc chan<- LogMessage, CleanDaysOlder uint,
 		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
 	}
 
-	/*Find the backup ID of the latest full backup that matches the */
-	var backupID string
-	lc <- LogMessage{fname, fmt.Sprintf("Performing Query: %s", GetLatestFullBackupID(CleanDaysOlder)), true}
-	err := dbc.db.QueryRow(GetLatestFullBackupID(CleanDaysOlder)).Scan(&backupID)
+	/*Find the backup ID of the full backup that the catalog will be truncated before.  The reads in this task, the
+	deletion of single backups and the truncation can be repeated safely and are retried after a transient error*/
+	var backupID, backupDate string
+	err := dbc.withRetry(lc, fname, RetryTaskCleanBackupCatalog, "Backup anchor query", func() error {
+		var err error
+		backupID, backupDate, err = dbc.FindBackupAnchor(lc, CleanDaysOlder)
+		return err
+	})
 	switch {
 	case err == sql.ErrNoRows:
 		lc <- LogMessage{fname, "No backupID found which matches the criteria", true}
@@ -151,28 +361,56 @@ func (dbc *DbConfig) CleanBackupFunc(lc chan<- LogMessage, CleanDaysOlder uint,
 		return fmt.Errorf("query error")
 
 	default:
-		lc <- LogMessage{fname, fmt.Sprintf("Found recent backupID (%s) that meets the search criteria", backupID), true}
+		lc <- LogMessage{fname, fmt.Sprintf("Found recent backupID (%s) from %s that meets the search criteria", backupID, backupDate), true}
+	}
+	dbc.Results.BackupAnchorID = backupID
+	dbc.Results.BackupAnchorDate = backupDate
+
+	/*Make sure the database remains recoverable once the catalog is truncated*/
+	if dbc.Backup.SafetyCheck {
+		var reason string
+		err := dbc.withRetry(lc, fname, RetryTaskCleanBackupCatalog, "Backup safety check", func() error {
+			var err error
+			reason, err = dbc.CheckBackupSafety(lc, backupID)
+			return err
+		})
+		if err != nil {
+			lc <- LogMessage{fname, "An error occurred querying the database", false}
+			lc <- LogMessage{fname, err.Error(), true}
+			return fmt.Errorf("failed to perform backup safety check")
+		}
+		if reason != "" {
+			lc <- LogMessage{fname, fmt.Sprintf("Backup safety check failed, %s.  The backup catalog will not be cleaned", reason), false}
+			dbc.Results.BackupSafetyFailure = reason
+			return fmt.Errorf("backup safety check failed, %s", reason)
+		}
+		lc <- LogMessage{fname, "Backup safety check passed", true}
 	}
 
 	/*Count how many backups will be deleted*/
 	bfs := []BackupFiles{}
 	lc <- LogMessage{fname, fmt.Sprintf("Performing Query: %s", GetBackupFileData(backupID)), true}
-	rows, err := dbc.db.Query(GetBackupFileData(backupID))
+	err = dbc.withRetry(lc, fname, RetryTaskCleanBackupCatalog, "Backup catalog query", func() error {
+		bfs = bfs[:0]
+		rows, err := dbc.db.Query(GetBackupFileData(backupID))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			bf := BackupFiles{}
+			if err := rows.Scan(&bf.EntryType, &bf.DestinationType, &bf.FileCount, &bf.Bytes); err != nil {
+				return err
+			}
+			bfs = append(bfs, bf)
+		}
+		return rows.Err()
+	})
 	if err != nil {
 		lc <- LogMessage{fname, "An error occurred querying the database", false}
 		lc <- LogMessage{fname, err.Error(), true}
 		return fmt.Errorf("failed to retrieve data on backup catalog entries to remove")
 	}
-	defer rows.Close()
-	for rows.Next() {
-		bf := BackupFiles{}
-		err := rows.Scan(&bf.EntryType, &bf.FileCount, &bf.Bytes) //need unit test here!
-		if err != nil {
-			lc <- LogMessage{fname, "An error occurred querying the database", false}
-			lc <- LogMessage{fname, err.Error(), true}
-		}
-		bfs = append(bfs, bf)
-	}
 
 	if len(bfs) == 0 {
 		/*Looks like we found a backup, but it is the oldest backup in the catalog so we have nothing to delete*/
@@ -184,30 +422,94 @@ func (dbc *DbConfig) CleanBackupFunc(lc chan<- LogMessage, CleanDaysOlder uint,
 
 	var removeCount uint
 	var removeBytes uint
+	removeByDest := make(map[string]uint)
 	/*print some info in the log*/
 	for _, v := range bfs {
-		if delete {
-			removeCount++
+		removeCount += v.FileCount
+		/*When every backup is physically deleted, the bytes are those of every file before the anchor*/
+		if delete && !dbc.Backup.FilteredDelete() {
 			removeBytes += uint(v.Bytes)
-		} else {
-			removeCount++
+			if v.DestinationType != "" {
+				removeByDest[v.DestinationType] += uint(v.Bytes)
+			}
 		}
 	}
 
 	/*do the truncation*/
 	var query string
-	if delete {
+	if delete && !dbc.Backup.FilteredDelete() {
 		query = GetBackupDeleteComplete(backupID)
 	} else {
 		query = GetBackupDelete(backupID)
 	}
+
+	/*When physical deletion is limited, the selected backups are deleted one by one before the catalog is truncated*/
+	var deleteIDs []string
+	var bytesByID map[string]map[string]uint
+	if delete && dbc.Backup.FilteredDelete() {
+		err = dbc.withRetry(lc, fname, RetryTaskCleanBackupCatalog, "Backup ID query", func() error {
+			var err error
+			deleteIDs, bytesByID, err = dbc.getBackupIDsToDelete(lc, backupID)
+			return err
+		})
+		if err != nil {
+			lc <- LogMessage{fname, "An error occurred querying the database", false}
+			lc <- LogMessage{fname, err.Error(), true}
+			return fmt.Errorf("failed to retrieve backups to delete")
+		}
+		/*Only the selected backups are physically deleted, so only their bytes are removed*/
+		removeBytes, removeByDest = sumBackupBytes(deleteIDs, bytesByID)
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Physically delete %d backups before backup ID %s (destination types: %s, entry types: %s)",
+			len(deleteIDs), backupID, listOrAll(dbc.Backup.DeleteDestinationTypes), listOrAll(dbc.Backup.DeleteEntryTypes)))
+	}
+
+	if dbc.Backup.ExportDir != "" {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Export backup catalog entries before backup ID %s to %s", backupID, dbc.Backup.ExportDir))
+	}
+	dbc.AddPlan(lc, fname, fmt.Sprintf("Remove %d backup catalog entries before backup ID %s from %s", removeCount, backupID, backupDate))
 	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
 
 	if !dryrun {
-		_, err = dbc.db.Exec(query)
+		/*The catalog cannot be recovered once truncated, so never truncate without a successful export*/
+		if dbc.Backup.ExportDir != "" {
+			file, err := dbc.ExportBackupCatalog(lc, backupID)
+			if err != nil {
+				lc <- LogMessage{fname, "Backup catalog export failed, the backup catalog will not be cleaned", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return fmt.Errorf("couldn't export backup catalog")
+			}
+			dbc.Results.BackupExportFile = file
+		}
+
+		/*Backups that were deleted stay deleted when a later statement fails, so they are always reported*/
+		deleted := make([]string, 0, len(deleteIDs))
+		recordDeleted := func() {
+			dbc.Results.BackupFilesRemoved = uint(len(deleted))
+			dbc.Results.BackupFilesBytesRemoved, dbc.Results.BackupBytesByDestination = sumBackupBytes(deleted, bytesByID)
+		}
+		for _, id := range deleteIDs {
+			lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", GetBackupDeleteIDComplete(id)), true}
+			err = dbc.withRetry(lc, fname, RetryTaskCleanBackupCatalog, fmt.Sprintf("Delete of backup %s", id), func() error {
+				_, err := dbc.db.Exec(GetBackupDeleteIDComplete(id))
+				return err
+			})
+			if err != nil {
+				lc <- LogMessage{fname, fmt.Sprintf("Failed to delete backup %s, %d backups were deleted before it", id, len(deleted)), false}
+				lc <- LogMessage{fname, err.Error(), true}
+				recordDeleted()
+				return fmt.Errorf("couldn't delete backup %s", id)
+			}
+			deleted = append(deleted, id)
+		}
+
+		err = dbc.withRetry(lc, fname, RetryTaskCleanBackupCatalog, "Backup catalog truncation", func() error {
+			_, err := dbc.db.Exec(query)
+			return err
+		})
 		if err != nil {
 			lc <- LogMessage{fname, "Query failed", false}
 			lc <- LogMessage{fname, err.Error(), true}
+			recordDeleted()
 			return fmt.Errorf("couldn't clean backup catalog")
 		}
 
@@ -215,21 +517,202 @@ func (dbc *DbConfig) CleanBackupFunc(lc chan<- LogMessage, CleanDaysOlder uint,
 	}
 	dbc.Results.BackupFilesRemoved = removeCount
 	dbc.Results.BackupFilesBytesRemoved = removeBytes
+	dbc.Results.BackupBytesByDestination = removeByDest
 	return nil
 }
 
+//Returns the IDs of the backups before backupID that match Backup.DeleteDestinationTypes and Backup.DeleteEntryTypes,
+//with the bytes each of these backups holds in each destination type
+func (dbc *DbConfig) getBackupIDsToDelete(lc chan<- LogMessage, backupID string) ([]string, map[string]map[string]uint, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanBackupCatalog")
+	query := GetBackupIDsToDelete(backupID, dbc.Backup.DeleteDestinationTypes, dbc.Backup.DeleteEntryTypes)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query: %s", query), true}
+	rows, err := dbc.db.Query(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	byID := make(map[string]map[string]uint)
+	for rows.Next() {
+		var id, dest string
+		var bytes uint64
+		if err := rows.Scan(&id, &dest, &bytes); err != nil {
+			return nil, nil, err
+		}
+		/*There is a row for each destination type of a backup*/
+		if len(ids) == 0 || ids[len(ids)-1] != id {
+			ids = append(ids, id)
+			byID[id] = make(map[string]uint)
+		}
+		byID[id][dest] += uint(bytes)
+	}
+	return ids, byID, rows.Err()
+}
+
+//Adds up the bytes the backups in ids hold, in total and for each destination type
+func sumBackupBytes(ids []string, byID map[string]map[string]uint) (uint, map[string]uint) {
+	var total uint
+	byDest := make(map[string]uint)
+	for _, id := range ids {
+		for dest, b := range byID[id] {
+			total += b
+			byDest[dest] += b
+		}
+	}
+	return total, byDest
+}
+
+//Returns the list as a comma separated string, an empty list is reported as "all"
+func listOrAll(list []string) string {
+	if len(list) == 0 {
+		return "all"
+	}
+	return strings.Join(list, ", ")
+}
+
+//FindBackupAnchor returns the backup ID and end time of the full backup that the backup catalog will be truncated before.
+//The anchor is chosen according to the backup retention mode:
+//days - the most recent successful full backup older than CleanDaysOlder
+//count - the nth most recent successful full backup, where n is RetainFullBackups
+//both - the older of the two, so that the days and count policies are both satisfied
+//If no backup meets the criteria sql.ErrNoRows is returned.
+func (dbc *DbConfig) FindBackupAnchor(lc chan<- LogMessage, CleanDaysOlder uint) (string, string, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "FindBackupAnchor")
+	mode := dbc.Backup.RetentionMode
+	if mode == "" {
+		mode = BackupRetentionDays
+	}
+
+	queries := make([]string, 0, 2)
+	if mode == BackupRetentionDays || mode == BackupRetentionBoth {
+		queries = append(queries, GetLatestFullBackupID(CleanDaysOlder))
+	}
+	if mode == BackupRetentionCount || mode == BackupRetentionBoth {
+		queries = append(queries, GetNthLatestFullBackupID(dbc.Backup.RetainFullBackups))
+	}
+	if len(queries) == 0 {
+		lc <- LogMessage{fname, fmt.Sprintf("Unknown backup retention mode '%s'", mode), false}
+		return "", "", fmt.Errorf("unknown backup retention mode")
+	}
+
+	var anchorID, anchorDate string
+	var anchorNum uint64
+	for _, query := range queries {
+		var id, date string
+		lc <- LogMessage{fname, fmt.Sprintf("Performing Query: %s", query), true}
+		err := dbc.db.QueryRow(query).Scan(&id, &date)
+		if err != nil {
+			/*If any policy finds no backup there is nothing that can be removed, let the caller handle sql.ErrNoRows*/
+			return "", "", err
+		}
+		num, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Backup ID %s is not a number", id), false}
+			return "", "", err
+		}
+		/*Backup IDs increase over time, the oldest anchor satisfies every policy*/
+		if anchorID == "" || num < anchorNum {
+			anchorID, anchorDate, anchorNum = id, date, num
+		}
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("Using '%s' retention, anchor backup ID is %s from %s", mode, anchorID, anchorDate), false}
+	return anchorID, anchorDate, nil
+}
+
+//ExportBackupCatalog writes every backup catalog entry before backupID, with its files, to a new file in
+//Backup.ExportDir.  The name of the file written is returned.
+func (dbc *DbConfig) ExportBackupCatalog(lc chan<- LogMessage, backupID string) (string, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "ExportBackupCatalog")
+	format := dbc.Backup.ExportFormat
+	if format == "" {
+		format = ArchiveFormatJSON
+	}
+
+	query := GetBackupCatalogExport(backupID)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query: %s", query), true}
+	rows, err := dbc.db.Query(query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	records := [][]string{}
+	for rows.Next() {
+		var e BackupCatalogEntry
+		err := rows.Scan(&e.BackupID, &e.EntryType, &e.State, &e.StartTime, &e.EndTime, &e.Host, &e.Service, &e.DestinationType, &e.DestinationPath, &e.SizeBytes)
+		if err != nil {
+			return "", err
+		}
+		records = append(records, e.Record())
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	file := filepath.Join(dbc.Backup.ExportDir, fmt.Sprintf("%s_backup_catalog_%s_%s.%s", dbc.Name, backupID, time.Now().Format("20060102150405"), format))
+	err = WriteArchiveFile(file, format, BackupCatalogEntryHeader, records)
+	if err != nil {
+		return "", err
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("Exported %d backup catalog entries to %s", len(records), file), false}
+	return file, nil
+}
+
+//CheckBackupSafety checks that the database is still recoverable once the catalog is truncated before anchorID.
+//There must be a successful complete data backup newer than the anchor that finished within SafetyWindowHours, followed
+//by at least one log backup, and the log backups after it must be contiguous, otherwise the log chain from it is broken.
+//The reason the check failed is returned, an empty string means the check passed.
+func (dbc *DbConfig) CheckBackupSafety(lc chan<- LogMessage, anchorID string) (string, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CheckBackupSafety")
+
+	var recentID, recentDate string
+	query := GetRecentFullBackupAfter(anchorID, dbc.Backup.SafetyWindowHours)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query: %s", query), true}
+	err := dbc.db.QueryRow(query).Scan(&recentID, &recentDate)
+	switch {
+	case err == sql.ErrNoRows:
+		return fmt.Sprintf("no successful complete data backup newer than backup ID %s finished in the last %d hours", anchorID, dbc.Backup.SafetyWindowHours), nil
+	case err != nil:
+		return "", err
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("Found successful complete data backup %s from %s", recentID, recentDate), true}
+
+	var logBackups, gaps uint
+	query = GetLogBackupChainAfter(recentID)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query: %s", query), true}
+	err = dbc.db.QueryRow(query).Scan(&logBackups, &gaps)
+	if err != nil {
+		return "", err
+	}
+	if logBackups == 0 {
+		return fmt.Sprintf("no successful log backup was taken after backup ID %s", recentID), nil
+	}
+	if gaps > 0 {
+		return fmt.Sprintf("the %d log backup(s) after backup ID %s are not contiguous with it and each other, %d gap(s) found", logBackups, recentID, gaps), nil
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("Found %d contiguous log backup(s) after backup ID %s", logBackups, recentID), true}
+
+	return "", nil
+}
+
 //This function deletes alerts from the table _SYS_STATISTICS.STATISTICS_ALERTS_BASE.  Alerts are deleted if they are older than
-//the given number of days in the CleanDaysOlder argument.  No changes are made to the database if the dryrun argument is set to true
+//the given number of days in the CleanDaysOlder argument, or the retention set in the Alerts settings for their alert ID or rating.
+//When the Alerts settings ask for batches, the alerts are deleted oldest first with a commit after each batch, stopping once the
+//run time budget is used.  No changes are made to the database if the dryrun argument is set to true
 func (dbc *DbConfig) CleanAlertFunc(lc chan<- LogMessage, CleanDaysOlder uint, dryrun bool) error {
 	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanAlert")
 	lc <- LogMessage{fname, "Starting", false}
 	if dryrun {
 		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
 	}
+	c := dbc.Alerts
+
 	/*Find how many alerts there are that match the deletion criteria*/
 	var ac uint
-	lc <- LogMessage{fname, fmt.Sprintf("Performing query:%s", GetAlertCount(CleanDaysOlder)), true}
-	err := dbc.db.QueryRow(GetAlertCount(CleanDaysOlder)).Scan(&ac)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query:%s", GetAlertCount(CleanDaysOlder, c)), true}
+	err := dbc.db.QueryRow(GetAlertCount(CleanDaysOlder, c)).Scan(&ac)
 	switch {
 	case err == sql.ErrNoRows:
 		lc <- LogMessage{fname, "DB failed to count rows", false}
@@ -249,22 +732,109 @@ func (dbc *DbConfig) CleanAlertFunc(lc chan<- LogMessage, CleanDaysOlder uint, d
 		return nil
 	}
 
+	before, err := dbc.getAlertCountsByID(lc, fname, CleanDaysOlder)
+	if err != nil {
+		return err
+	}
+
+	retention := fmt.Sprintf("older than %d days", CleanDaysOlder)
+	if c.HasRules() {
+		retention = fmt.Sprintf("past their retention (%d days by default, %d alert ID and %d rating rules)", CleanDaysOlder, len(c.RetainDaysByID), len(c.RetainDaysByRating))
+	}
+	if !c.Batched() {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Delete %d alerts %s", ac, retention))
+	} else if c.BatchRows > 0 {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Delete %d alerts %s in batches of %d", ac, retention, c.BatchRows))
+	} else {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Delete %d alerts %s in slices of %d hours", ac, retention, c.SliceHours))
+	}
+	for _, id := range sortedKeys(before) {
+		lc <- LogMessage{fname, fmt.Sprintf("Alert %d: %d alerts to delete", id, before[id]), true}
+	}
+
 	/*Attempt to delete the records*/
-	if !dryrun {
-		_, err = dbc.db.Exec(GetAlertDelete(CleanDaysOlder))
+	if dryrun {
+		return nil
+	}
+	if !c.Batched() {
+		_, err = dbc.db.Exec(GetAlertDelete(CleanDaysOlder, c))
 		if err != nil {
 			lc <- LogMessage{fname, "Query to remove alerts failed", false}
 			lc <- LogMessage{fname, err.Error(), true}
 			return err
 		}
 		dbc.Results.AlertsRemoved = ac
+		dbc.Results.AlertsRemovedByID = before
+		return nil
+	}
+
+	query := GetAlertDeleteSlice(CleanDaysOlder, c)
+	if c.BatchRows > 0 {
+		query = GetAlertDeleteBatch(CleanDaysOlder, c)
+	}
+	var deadline time.Time
+	if c.MaxRunMinutes > 0 {
+		deadline = time.Now().Add(time.Duration(c.MaxRunMinutes) * time.Minute)
+	}
+	removed, batches, complete, err := dbc.deleteInBatches(lc, fname, "the alert table", query, c.BatchRows, ac, deadline)
+	dbc.Results.AlertsRemoved = removed
+	dbc.Results.AlertBatches = batches
+	if complete {
+		dbc.Results.AlertsRemovedByID = before
+	} else {
+		if removed < ac {
+			dbc.Results.AlertsRemaining = ac - removed
+		}
+		/*Only part of the alerts went, so the breakdown comes from what is left.  This is a 'nice to have', if it fails
+		we'll log it but carry on*/
+		after, aerr := dbc.getAlertCountsByID(lc, fname, CleanDaysOlder)
+		if aerr != nil {
+			lc <- LogMessage{fname, "Post cleaning alert count failed, cannot report alerts removed per alert ID", true}
+		} else {
+			dbc.Results.AlertsRemovedByID = make(map[uint]uint)
+			for id, n := range before {
+				if n > after[id] {
+					dbc.Results.AlertsRemovedByID[id] = n - after[id]
+				}
+			}
+		}
+	}
+	if err != nil {
+		lc <- LogMessage{fname, fmt.Sprintf("Query to remove alerts failed after removing %d in %d batches", removed, batches), false}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
 	}
 	return nil
 }
 
+//Counts the alerts past their retention for each alert ID
+func (dbc *DbConfig) getAlertCountsByID(lc chan<- LogMessage, fname string, days uint) (map[uint]uint, error) {
+	query := GetAlertCountByID(days, dbc.Alerts)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+	rows, err := dbc.db.Query(query)
+	if err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[uint]uint)
+	for rows.Next() {
+		var id, count uint
+		if err := rows.Scan(&id, &count); err != nil {
+			lc <- LogMessage{fname, "Scan Error", true}
+			lc <- LogMessage{fname, err.Error(), true}
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
 //This function deletes free logsegments from the log volume.  Performing this task will reduce the disk space used in the log volume
 //but may also cause a minor IO penalty when new new log segments need to be created.  It is more important to run this function is an MDC
-//environemt than a non-MDC one.
+//environemt than a non-MDC one.  When LogVolume guards are configured the log is only reclaimed when they all pass.
 func (dbc *DbConfig) CleanLogFunc(lc chan<- LogMessage, dryrun bool) error {
 	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanLog")
 	lc <- LogMessage{fname, "Starting", false}
@@ -288,20 +858,149 @@ func (dbc *DbConfig) CleanLogFunc(lc chan<- LogMessage, dryrun bool) error {
 
 	//lc <- LogMessage{fname, fmt.Sprintf("Attempting to clear %d log segments saving %.2f MiB of disk space", count, float32(bytes/1024/1024)), true}
 
-	if !dryrun {
-		lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", QUERY_ReclaimLog), true}
-		_, err = dbc.db.Exec(QUERY_ReclaimLog)
-		if err != nil {
+	/*This is a 'nice to have' check, if it fails we'll log it but carry on*/
+	if dbc.Results.LogVolumeBytesBefore, err = dbc.getLogVolumeSize(lc, fname); err != nil {
+		lc <- LogMessage{fname, "Log volume size check failed, cannot report the log volume size", true}
+	} else {
+		dbc.Results.LogVolumeMeasuredBefore = true
+	}
+
+	reason, err := dbc.logReclaimGate(lc, fname, count, bytes)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		lc <- LogMessage{fname, fmt.Sprintf("Skipping log reclaim, %s", reason), false}
+		dbc.Results.LogReclaimSkipped = reason
+		return nil
+	}
+
+	dbc.AddPlan(lc, fname, fmt.Sprintf("Reclaim %d free log segments (%.2fMiB)", count, float64(bytes)/1024/1024))
+	if !dryrun {
+		/*This is a 'nice to have' check, the free segments of each service are reclaimed so read them first*/
+		services, err := dbc.getFreeLogSegmentsByService(lc, fname)
+		if err != nil {
+			lc <- LogMessage{fname, "Free log segments by service check failed, cannot report the per service breakdown", true}
+		}
+
+		lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", QUERY_ReclaimLog), true}
+		_, err = dbc.db.Exec(QUERY_ReclaimLog)
+		if err != nil {
 			lc <- LogMessage{fname, "Query produced a database error", false}
 			lc <- LogMessage{fname, err.Error(), true}
 			return fmt.Errorf("db error")
 		}
 		dbc.Results.LogSegmentsRemoved = count
 		dbc.Results.LogSegmentsBytesRemoved = uint(bytes)
+		dbc.Results.LogSegmentsByService = services
+
+		if dbc.Results.LogVolumeBytesAfter, err = dbc.getLogVolumeSize(lc, fname); err != nil {
+			lc <- LogMessage{fname, "Post cleaning log volume size check failed, cannot report the log volume size", true}
+		} else {
+			dbc.Results.LogVolumeMeasuredAfter = true
+		}
 	}
 	return nil
 }
 
+//Reads the number and size of the free log segments of each service, keyed by host:port
+func (dbc *DbConfig) getFreeLogSegmentsByService(lc chan<- LogMessage, fname string) (map[string]NodeRemoved, error) {
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", QUERY_GetFreeLogSegmentsByService), true}
+	rows, err := dbc.db.Query(QUERY_GetFreeLogSegmentsByService)
+	if err != nil {
+		lc <- LogMessage{fname, err.Error(), true}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var services map[string]NodeRemoved
+	for rows.Next() {
+		var host string
+		var port, count uint
+		var bytes uint64
+		err := rows.Scan(&host, &port, &count, &bytes)
+		if err != nil {
+			lc <- LogMessage{fname, err.Error(), true}
+			return nil, err
+		}
+		services = addNodeRemoved(services, fmt.Sprintf("%s:%d", host, port), count, bytes)
+	}
+	return services, rows.Err()
+}
+
+//Reads the total size of the log volumes
+func (dbc *DbConfig) getLogVolumeSize(lc chan<- LogMessage, fname string) (uint64, error) {
+	var size uint64
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", QUERY_GetLogVolumeSize), true}
+	if err := dbc.db.QueryRow(QUERY_GetLogVolumeSize).Scan(&size); err != nil {
+		lc <- LogMessage{fname, err.Error(), true}
+		return 0, err
+	}
+	return size, nil
+}
+
+//Checks the LogVolume guards before the log is reclaimed.  Returns the reason the reclaim should be skipped, or an
+//empty string when every configured guard passes.  An error is returned when a guard can't be checked.
+func (dbc *DbConfig) logReclaimGate(lc chan<- LogMessage, fname string, count uint, bytes uint64) (string, error) {
+	c := dbc.LogVolume
+
+	/*Free segments*/
+	if count < c.MinFreeSegments {
+		return fmt.Sprintf("only %d log segments are free, below %d", count, c.MinFreeSegments), nil
+	}
+	if c.MinFreeMiB > 0 && bytes < uint64(c.MinFreeMiB)*1024*1024 {
+		return fmt.Sprintf("the free log segments hold %.2fMiB, below %dMiB", float64(bytes)/1024/1024, c.MinFreeMiB), nil
+	}
+	if c.RequireLogMode == "" && c.MaxLogBackupAgeHours == 0 {
+		return "", nil
+	}
+
+	/*Log mode*/
+	var mode string
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", QUERY_GetLogMode), true}
+	err := dbc.db.QueryRow(QUERY_GetLogMode).Scan(&mode)
+	switch {
+	case err == sql.ErrNoRows:
+		mode = LogModeNormal
+	case err != nil:
+		lc <- LogMessage{fname, "Failed to read the log mode", false}
+		lc <- LogMessage{fname, err.Error(), true}
+		return "", err
+	}
+	mode = strings.ToLower(mode)
+	dbc.Results.LogMode = mode
+	lc <- LogMessage{fname, fmt.Sprintf("Log mode is %s", mode), true}
+	if c.RequireLogMode != "" && mode != c.RequireLogMode {
+		return fmt.Sprintf("the log mode is %s, not %s", mode, c.RequireLogMode), nil
+	}
+
+	/*Log backup health, log backups are not written in log mode overwrite*/
+	if c.MaxLogBackupAgeHours == 0 {
+		return "", nil
+	}
+	if mode != LogModeNormal {
+		lc <- LogMessage{fname, fmt.Sprintf("Log mode is %s, log backups are not checked", mode), true}
+		return "", nil
+	}
+	var state string
+	var age uint64
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", QUERY_GetLatestLogBackup), true}
+	err = dbc.db.QueryRow(QUERY_GetLatestLogBackup).Scan(&state, &age)
+	switch {
+	case err == sql.ErrNoRows:
+		return "no finished log backup was found", nil
+	case err != nil:
+		lc <- LogMessage{fname, "Failed to read the latest log backup", false}
+		lc <- LogMessage{fname, err.Error(), true}
+		return "", err
+	case state != "successful":
+		return fmt.Sprintf("the latest log backup is %s", state), nil
+	case age > uint64(c.MaxLogBackupAgeHours)*3600:
+		return fmt.Sprintf("the latest successful log backup is %.1f hours old, above %d", float64(age)/3600, c.MaxLogBackupAgeHours), nil
+	}
+	return "", nil
+}
+
 //Function that removes old audit events from the audit table.
 func (dbc *DbConfig) CleanAuditFunc(lc chan<- LogMessage, CleanDaysOlder uint, dryrun bool) error {
 
@@ -311,6 +1010,17 @@ func (dbc *DbConfig) CleanAuditFunc(lc chan<- LogMessage, CleanDaysOlder uint, d
 		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
 	}
 
+	/*The audit log can only be cleared up to a point in time, so rules can only extend the retention*/
+	if len(dbc.Audit.Rules) > 0 {
+		days, err := dbc.AuditRetentionDays(lc, CleanDaysOlder)
+		if err != nil {
+			lc <- LogMessage{fname, "Failed to apply the audit retention rules", false}
+			lc <- LogMessage{fname, err.Error(), true}
+			return fmt.Errorf("db error")
+		}
+		CleanDaysOlder = days
+	}
+
 	//Get the number of items to be removed
 	lc <- LogMessage{fname, fmt.Sprintf("Performing query:%s", GetAuditCount(CleanDaysOlder)), true}
 	var auditCount uint
@@ -360,7 +1070,37 @@ func (dbc *DbConfig) CleanAuditFunc(lc chan<- LogMessage, CleanDaysOlder uint, d
 		return fmt.Errorf("couldn't split string")
 	}
 
+	if dbc.Audit.ArchiveDir != "" {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Archive %d audit records up to %s to %s", auditCount, dateParts[0], dbc.Audit.ArchiveDir))
+	}
+	dbc.AddPlan(lc, fname, fmt.Sprintf("Clear %d audit records up to %s", auditCount, dateParts[0]))
+
 	if !dryrun {
+		/*Audit records cannot be recovered once cleared, so never clear without a complete archive*/
+		if dbc.Audit.ArchiveDir != "" {
+			/*The first count was taken against a moving cutoff, so count again with the cutoff used to archive and clear*/
+			lc <- LogMessage{fname, fmt.Sprintf("Performing query:%s", GetAuditCountBefore(dateParts[0])), true}
+			err = dbc.db.QueryRow(GetAuditCountBefore(dateParts[0])).Scan(&auditCount)
+			if err != nil {
+				lc <- LogMessage{fname, "Query produced a database error", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return fmt.Errorf("db error")
+			}
+			ra, err := dbc.ArchiveAuditLog(lc, dateParts[0])
+			if err != nil {
+				lc <- LogMessage{fname, "Audit log archive failed, the audit log will not be cleared", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return fmt.Errorf("couldn't archive audit log")
+			}
+			if ra.Count != auditCount {
+				lc <- LogMessage{fname, fmt.Sprintf("Archived %d audit records but expected %d, the audit log will not be cleared", ra.Count, auditCount), false}
+				ra.Remove()
+				return fmt.Errorf("audit log archive incomplete")
+			}
+			dbc.Results.AuditEntriesArchived = ra.Count
+			dbc.Results.AuditArchiveFiles = ra.Files
+		}
+
 		lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", GetTruncateAuditLog(dateParts[0])), true}
 		_, err = dbc.db.Exec(GetTruncateAuditLog(dateParts[0]))
 		if err != nil {
@@ -374,6 +1114,99 @@ func (dbc *DbConfig) CleanAuditFunc(lc chan<- LogMessage, CleanDaysOlder uint, d
 	return nil
 }
 
+//AuditRetentionDays applies the audit retention rules to the default retention and returns the number of days of
+//audit log that can safely be kept.  A rule with a longer retention than the default constrains the cutoff only when
+//it protects records that the default would remove.  The records past retention for each rule and the rule that set
+//the cutoff are recorded in the results and the reason is added to the plan.
+func (dbc *DbConfig) AuditRetentionDays(lc chan<- LogMessage, defaultDays uint) (uint, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanAuditLog")
+	days := defaultDays
+	constraint := ""
+	dbc.Results.AuditRuleExpired = make(map[string]uint)
+
+	for _, r := range dbc.Audit.Rules {
+		var expired, defaultExpired uint
+		query := GetAuditRuleCount(r, defaultDays)
+		lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", query), true}
+		err := dbc.db.QueryRow(query).Scan(&expired, &defaultExpired)
+		if err != nil {
+			return defaultDays, err
+		}
+		dbc.Results.AuditRuleExpired[r.Name] = expired
+		lc <- LogMessage{fname, fmt.Sprintf("Audit rule %s: %d records past its retention of %d days", r.Name, expired, r.RetainDays), true}
+
+		/*Records the default would remove but the rule retains*/
+		if r.RetainDays > days && defaultExpired > expired {
+			lc <- LogMessage{fname, fmt.Sprintf("Audit rule %s protects %d records older than %d days", r.Name, defaultExpired-expired, defaultDays), true}
+			days = r.RetainDays
+			constraint = r.Name
+		}
+	}
+
+	dbc.Results.AuditRetainDays = days
+	dbc.Results.AuditConstrainingRule = constraint
+	if constraint != "" {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Retain %d days of audit log instead of %d, constrained by audit rule %s", days, defaultDays, constraint))
+	} else {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Retain %d days of audit log, no audit rule protects older records", days))
+	}
+	return days, nil
+}
+
+//ArchiveAuditLog streams the audit records up to and including datetime into gzip compressed files in
+//Audit.ArchiveDir.  A new file is started for each day of records, files are named
+//<Name>_audit_<date>_<run time>.<format>.gz.  On error no files are left behind.
+func (dbc *DbConfig) ArchiveAuditLog(lc chan<- LogMessage, datetime string) (*RotatingArchive, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "ArchiveAuditLog")
+	format := dbc.Audit.ArchiveFormat
+	if format == "" {
+		format = ArchiveFormatJSONL
+	}
+	ra := &RotatingArchive{Dir: dbc.Audit.ArchiveDir, Prefix: fmt.Sprintf("%s_audit", dbc.Name), Suffix: time.Now().Format("20060102150405"), Format: format, Header: AuditArchiveColumns}
+
+	lc <- LogMessage{fname, fmt.Sprintf("Performing Query:%s", GetAuditArchive(datetime)), true}
+	rows, err := dbc.db.Query(GetAuditArchive(datetime))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(AuditArchiveColumns))
+	dest := make([]interface{}, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			ra.Remove()
+			return nil, err
+		}
+		record := make([]string, len(values))
+		for i, v := range values {
+			record[i] = v.String
+		}
+		/*Rotate on the date part of the timestamp*/
+		day := record[0]
+		if len(day) > 10 {
+			day = day[:10]
+		}
+		if err := ra.Write(day, record); err != nil {
+			ra.Remove()
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		ra.Remove()
+		return nil, err
+	}
+	if err := ra.Close(); err != nil {
+		ra.Remove()
+		return nil, err
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("Archived %d audit records to %d files", ra.Count, len(ra.Files)), false}
+	return ra, nil
+}
+
 func (dbc *DbConfig) CleanDataVolumeFunc(lc chan<- LogMessage, dryrun bool) error {
 	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanDataVolume")
 	lc <- LogMessage{fname, "Starting", false}
@@ -416,34 +1249,58 @@ func (dbc *DbConfig) CleanDataVolumeFunc(lc chan<- LogMessage, dryrun bool) erro
 	}
 
 	var failures = 0
+	var gates *dataVolumeGateState
 
 	for k, v := range dvs {
 		lc <- LogMessage{fname, fmt.Sprintf("Processing data volume %d of %d", k+1, len(dvs)), true}
-		if !v.CleanNeeded() {
-			lc <- LogMessage{fname, "Cleaning not required, data volume is less than 50% whitespace", true}
+		t := dbc.DataVolume.ForVolume(v.Host, v.Port)
+		if !v.ReclaimNeeded(t) {
+			lc <- LogMessage{fname, fmt.Sprintf("Cleaning not required, data volume %s:%d is less than %d%% whitespace or has less than %dMiB free", v.Host, v.Port, t.FreeTriggerPercent, t.MinFreeMiB), true}
 			continue
-		} else {
-			if dryrun {
-				lc <- LogMessage{fname, "Cleaning required, but skipping due to dry run mode", true}
+		}
+
+		/*The gates are only checked once a volume needs reclaiming, and only once per run*/
+		if dbc.DataVolume.Gates.Enabled() {
+			if gates == nil {
+				gates, err = dbc.checkDataVolumeGates(lc)
+				if err != nil {
+					lc <- LogMessage{fname, "Failed to check the data volume reclaim gates", false}
+					lc <- LogMessage{fname, err.Error(), true}
+					return err
+				}
+			}
+			if reason := gates.reason(v.Host, v.Port); reason != "" {
+				lc <- LogMessage{fname, fmt.Sprintf("Skipping data volume %s:%d, %s", v.Host, v.Port, reason), false}
+				dbc.Results.DataVolumeSkipped = append(dbc.Results.DataVolumeSkipped, fmt.Sprintf("%s:%d %s", v.Host, v.Port, reason))
 				continue
+			}
+		}
+
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Reclaim data volume %s:%d to %d%% of its used size, predicted saving %.2fMiB", v.Host, v.Port, t.ReclaimTargetPercent, float64(v.PredictedSaving(t.ReclaimTargetPercent))/1024/1024))
+		if dryrun {
+			lc <- LogMessage{fname, "Cleaning required, but skipping due to dry run mode", true}
+			continue
+		} else {
+			lc <- LogMessage{fname, fmt.Sprintf("Cleaning required, data volume is more than %d%% whitespace", t.FreeTriggerPercent), true}
+			_, err = dbc.db.Exec(GetCleanDataVolume(v.Host, v.Port, t.ReclaimTargetPercent))
+			if err != nil {
+				lc <- LogMessage{fname, "Failed to clean data volume", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				failures += 1
 			} else {
-				lc <- LogMessage{fname, "Cleaning required, data volume is more than 50% whitespace", true}
-				_, err = dbc.db.Exec(GetCleanDataVolume(v.Host, v.Port))
+				lc <- LogMessage{fname, "Clean data volume OK", true}
+				/*Collect the space saving */
+				/*This is a 'nice to have' check, if it fails we'll log it but carry on*/
+				sizeNow, err := dbc.CheckDataClean(v.Host, v.Port)
 				if err != nil {
-					lc <- LogMessage{fname, "Failed to clean data volume", false}
-					lc <- LogMessage{fname, err.Error(), true}
-					failures += 1
+					lc <- LogMessage{fname, fmt.Sprintf("Post cleaning size check failed for %s:%d, cannot report sizing saving", v.Host, v.Port), true}
 				} else {
-					lc <- LogMessage{fname, "Clean data volume OK", true}
-					/*Collect the space saving */
-					/*This is a 'nice to have' check, if it fails we'll log it but carry on*/
-					sizeNow, err := dbc.CheckDataClean(v.Host, v.Port)
-					if err != nil {
-						lc <- LogMessage{fname, fmt.Sprintf("Post cleaning size check failed for %s:%d, cannot report sizing saving", v.Host, v.Port), true}
-					} else {
-						if sizeNow < v.TotalSizeBytes {
-							dbc.Results.DataVolumeBytesRemoved += uint(v.TotalSizeBytes) - uint(sizeNow)
+					if sizeNow < v.TotalSizeBytes {
+						dbc.Results.DataVolumeBytesRemoved += uint(v.TotalSizeBytes) - uint(sizeNow)
+						if dbc.Results.DataVolumeByService == nil {
+							dbc.Results.DataVolumeByService = make(map[string]uint64)
 						}
+						dbc.Results.DataVolumeByService[fmt.Sprintf("%s:%d", v.Host, v.Port)] += v.TotalSizeBytes - sizeNow
 					}
 				}
 			}
@@ -464,6 +1321,158 @@ func (dbc *DbConfig) CleanDataVolumeFunc(lc chan<- LogMessage, dryrun bool) erro
 	}
 }
 
+//The result of checking the data volume gates.  A gate that failed for the whole database is held in dbReason,
+//gates that failed for a host or a service are keyed on the host or host:port.
+type dataVolumeGateState struct {
+	dbReason      string
+	hostReasons   map[string]string
+	volumeReasons map[string]string
+}
+
+//Returns the reason the volume on host:port must not be reclaimed, or an empty string if every gate passed
+func (g *dataVolumeGateState) reason(host string, port uint) string {
+	if g.dbReason != "" {
+		return g.dbReason
+	}
+	if r := g.hostReasons[host]; r != "" {
+		return r
+	}
+	return g.volumeReasons[fmt.Sprintf("%s:%d", host, port)]
+}
+
+//Checks the configured data volume gates
+func (dbc *DbConfig) checkDataVolumeGates(lc chan<- LogMessage) (*dataVolumeGateState, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanDataVolume")
+	g := dbc.DataVolume.Gates
+	state := &dataVolumeGateState{"", make(map[string]string), make(map[string]string)}
+
+	if g.Window != "" {
+		var now string
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_GetDbTimeOfDay), true}
+		if err := dbc.db.QueryRow(QUERY_GetDbTimeOfDay).Scan(&now); err != nil {
+			return nil, err
+		}
+		in, err := g.InWindow(now)
+		if err != nil {
+			return nil, err
+		}
+		if !in {
+			state.dbReason = fmt.Sprintf("the database time %s is outside the window %s", now, g.Window)
+			return state, nil
+		}
+	}
+
+	if g.NoRunningBackup {
+		var running uint
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_GetRunningDataBackups), true}
+		if err := dbc.db.QueryRow(QUERY_GetRunningDataBackups).Scan(&running); err != nil {
+			return nil, err
+		}
+		if running > 0 {
+			state.dbReason = "a data backup is running"
+			return state, nil
+		}
+	}
+
+	if g.MaxCPUPercent > 0 {
+		if err := dbc.Results.Version.Require(FeatureLoadHistory, "'DataVolume.Gates.MaxCPUPercent'"); err != nil {
+			return nil, err
+		}
+		cpu, err := dbc.getHostCPULoad(lc)
+		if err != nil {
+			return nil, err
+		}
+		for host, c := range cpu {
+			if c > float64(g.MaxCPUPercent) {
+				state.hostReasons[host] = fmt.Sprintf("CPU usage on %s is %.0f%%, above %d%%", host, c, g.MaxCPUPercent)
+			}
+		}
+	}
+
+	if g.MaxMemoryPercent > 0 {
+		hosts, err := dbc.getHostResources(lc)
+		if err != nil {
+			return nil, err
+		}
+		for host, h := range hosts {
+			if _, held := state.hostReasons[host]; !held && h.MemoryPercent() > float64(g.MaxMemoryPercent) {
+				state.hostReasons[host] = fmt.Sprintf("memory usage on %s is %.0f%%, above %d%%", host, h.MemoryPercent(), g.MaxMemoryPercent)
+			}
+		}
+	}
+
+	if g.MaxSavepointSeconds > 0 {
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_GetMaxSavepointDuration), true}
+		rows, err := dbc.db.Query(QUERY_GetMaxSavepointDuration)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var host string
+			var port uint
+			var duration uint64
+			if err := rows.Scan(&host, &port, &duration); err != nil {
+				return nil, err
+			}
+			/*DURATION is in microseconds*/
+			if duration > uint64(g.MaxSavepointSeconds)*1000000 {
+				state.volumeReasons[fmt.Sprintf("%s:%d", host, port)] = fmt.Sprintf("a savepoint took %.1fs, above %ds", float64(duration)/1000000, g.MaxSavepointSeconds)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+//Returns the memory usage of each host keyed on the host name
+func (dbc *DbConfig) getHostResources(lc chan<- LogMessage) (map[string]HostResources, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanDataVolume")
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_GetHostResources), true}
+	rows, err := dbc.db.Query(QUERY_GetHostResources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hosts := make(map[string]HostResources)
+	for rows.Next() {
+		var h HostResources
+		if err := rows.Scan(&h.Host, &h.UsedMemory, &h.FreeMemory); err != nil {
+			return nil, err
+		}
+		hosts[h.Host] = h
+	}
+	return hosts, rows.Err()
+}
+
+//Returns the latest CPU usage percentage of each host keyed on the host name.  The load history is recorded by the
+//database, so there is no need to sample the CPU times and wait.  Hosts with no load recorded in the last five
+//minutes are not returned.
+func (dbc *DbConfig) getHostCPULoad(lc chan<- LogMessage) (map[string]float64, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CleanDataVolume")
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_GetHostCPULoad), true}
+	rows, err := dbc.db.Query(QUERY_GetHostCPULoad)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hosts := make(map[string]float64)
+	for rows.Next() {
+		var host string
+		var cpu float64
+		if err := rows.Scan(&host, &cpu); err != nil {
+			return nil, err
+		}
+		hosts[host] = cpu
+	}
+	return hosts, rows.Err()
+}
+
 func (dbc *DbConfig) CheckDataClean(host string, port uint) (uint64, error) {
 	var ts uint64
 	err := dbc.db.QueryRow(GetSpecificDataVolume(host, port)).Scan(&ts)
@@ -477,6 +1486,610 @@ func (dbc *DbConfig) CheckDataClean(host string, port uint) (uint64, error) {
 
 }
 
+//Merges the delta store and optimises the compression of column store tables that are over the TableOptimise
+//thresholds.  Tables are processed largest first and at most MaxTables are optimised, the rest are left for a later run.
+func (dbc *DbConfig) OptimiseTablesFunc(lc chan<- LogMessage, dryrun bool) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "TableOptimise")
+	lc <- LogMessage{fname, "Starting", false}
+	if dryrun {
+		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
+	}
+
+	c := dbc.TableOptimise.WithDefaults()
+	query := GetCsTables(c.IncludeSchemas, c.ExcludeSchemas)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+	rows, err := dbc.db.Query(query)
+	if err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+	defer rows.Close()
+
+	candidates := make([]CsTable, 0)
+	for rows.Next() {
+		t := CsTable{}
+		err := rows.Scan(&t.Schema, &t.Table, &t.MainBytes, &t.DeltaBytes, &t.UncompressedBytes)
+		if err != nil {
+			lc <- LogMessage{fname, "Scan Error", true}
+			lc <- LogMessage{fname, err.Error(), true}
+			/*allow calling function to deal with the error*/
+			return err
+		}
+		if t.MergeNeeded(c) || t.CompressionNeeded(c) {
+			candidates = append(candidates, t)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+
+	if len(candidates) == 0 {
+		lc <- LogMessage{fname, "No tables are over the optimisation thresholds", true}
+		return nil
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("%d table(s) over the optimisation thresholds", len(candidates)), true}
+	if uint(len(candidates)) > c.MaxTables {
+		dbc.Results.TablesDeferred = uint(len(candidates)) - c.MaxTables
+		lc <- LogMessage{fname, fmt.Sprintf("Only the largest %d tables will be optimised in this run, %d deferred", c.MaxTables, dbc.Results.TablesDeferred), false}
+		candidates = candidates[:c.MaxTables]
+	}
+
+	var failures = 0
+	for _, t := range candidates {
+		merge, compress := t.MergeNeeded(c), t.CompressionNeeded(c)
+		ops := []string{}
+		if merge {
+			ops = append(ops, fmt.Sprintf("merge the %.2fMiB delta", float64(t.DeltaBytes)/1024/1024))
+		}
+		if compress {
+			ops = append(ops, fmt.Sprintf("optimise the compression of the %.2fMiB main store", float64(t.MainBytes)/1024/1024))
+		}
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Optimise table %s.%s, %s", t.Schema, t.Table, strings.Join(ops, " and ")))
+		if dryrun {
+			continue
+		}
+
+		res := TableOptimiseResult{t.Schema, t.Table, false, false, t.TotalBytes(), 0}
+		if merge {
+			query := GetMergeDelta(t.Schema, t.Table)
+			lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+			if _, err := dbc.db.Exec(query); err != nil {
+				lc <- LogMessage{fname, fmt.Sprintf("Failed to merge the delta of %s.%s", t.Schema, t.Table), false}
+				lc <- LogMessage{fname, err.Error(), true}
+				failures += 1
+				continue
+			}
+			res.Merged = true
+		}
+		if compress {
+			query := GetOptimiseCompression(t.Schema, t.Table)
+			lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+			if _, err := dbc.db.Exec(query); err != nil {
+				lc <- LogMessage{fname, fmt.Sprintf("Failed to optimise the compression of %s.%s", t.Schema, t.Table), false}
+				lc <- LogMessage{fname, err.Error(), true}
+				failures += 1
+			} else {
+				res.Compressed = true
+			}
+		}
+
+		/*This is a 'nice to have' check, if it fails we'll log it but carry on*/
+		if err := dbc.db.QueryRow(GetCsTableSize(t.Schema, t.Table)).Scan(&res.BytesAfter); err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Post optimisation size check failed for %s.%s, cannot report the size after", t.Schema, t.Table), true}
+			res.BytesAfter = 0
+		}
+		if res.Merged || res.Compressed {
+			dbc.Results.TablesOptimised = append(dbc.Results.TablesOptimised, res)
+		}
+	}
+
+	/*choose an exit*/
+	switch {
+	case failures == 0:
+		lc <- LogMessage{fname, "Finished with no errors", true}
+		return nil
+	case failures == 1:
+		lc <- LogMessage{fname, "Table optimisation finished with one error", false}
+		return fmt.Errorf("one table optimisation error recorded")
+	default:
+		lc <- LogMessage{fname, fmt.Sprintf("Table optimisation finished with %d errors", failures), false}
+		return fmt.Errorf("%d table optimisation errors recorded", failures)
+	}
+}
+
+//Disconnects sessions that have been idle for longer than IdleSessions.IdleMinutes.  The HCC user is always protected
+//so that HCC never disconnects itself or another HCC run.
+func (dbc *DbConfig) DisconnectIdleSessionsFunc(lc chan<- LogMessage, dryrun bool) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "IdleSessions")
+	lc <- LogMessage{fname, "Starting", false}
+	if dryrun {
+		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
+	}
+
+	c := dbc.IdleSessions
+	protected := append([]string{strings.ToUpper(dbc.Username)}, c.ProtectedUsers...)
+	query := GetIdleSessions(c.IdleMinutes, c.Users, c.Applications, c.ClientHosts, protected)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+	rows, err := dbc.db.Query(query)
+	if err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+	defer rows.Close()
+
+	sessions := make([]IdleSession, 0)
+	for rows.Next() {
+		s := IdleSession{}
+		err := rows.Scan(&s.ConnectionID, &s.User, &s.Application, &s.ClientHost, &s.IdleMs)
+		if err != nil {
+			lc <- LogMessage{fname, "Scan Error", true}
+			lc <- LogMessage{fname, err.Error(), true}
+			/*allow calling function to deal with the error*/
+			return err
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+
+	if len(sessions) == 0 {
+		lc <- LogMessage{fname, fmt.Sprintf("No sessions idle for more than %d minutes", c.IdleMinutes), true}
+		return nil
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("%d session(s) idle for more than %d minutes", len(sessions), c.IdleMinutes), true}
+
+	var failures = 0
+	for _, s := range sessions {
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Disconnect session %d of user %s, application '%s' from client host '%s', idle for %d minutes", s.ConnectionID, s.User, s.Application, s.ClientHost, s.IdleMs/60000))
+		if dryrun {
+			continue
+		}
+		query := GetDisconnectSession(s.ConnectionID)
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+		if _, err := dbc.db.Exec(query); err != nil {
+			/*The session may have ended or become active since it was found*/
+			lc <- LogMessage{fname, fmt.Sprintf("Failed to disconnect session %d", s.ConnectionID), false}
+			lc <- LogMessage{fname, err.Error(), true}
+			failures += 1
+			continue
+		}
+		dbc.Results.SessionsDisconnected += 1
+	}
+
+	/*choose an exit*/
+	switch {
+	case failures == 0:
+		lc <- LogMessage{fname, "Finished with no errors", true}
+		return nil
+	case failures == 1:
+		lc <- LogMessage{fname, "Disconnect idle sessions finished with one error", false}
+		return fmt.Errorf("one session disconnect error recorded")
+	default:
+		lc <- LogMessage{fname, fmt.Sprintf("Disconnect idle sessions finished with %d errors", failures), false}
+		return fmt.Errorf("%d session disconnect errors recorded", failures)
+	}
+}
+
+//Resets the configured monitoring views that are over the MonitoringReset thresholds.  A view without a RESET_TIME
+//column is skipped.  A view that cannot be read or reset is logged and the remaining views are still processed.
+func (dbc *DbConfig) ResetMonitoringViewsFunc(lc chan<- LogMessage, dryrun bool) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "MonitoringReset")
+	lc <- LogMessage{fname, "Starting", false}
+	if dryrun {
+		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
+	}
+
+	/*The age of a view is read from RESET_TIME, so find the views that have it before reading them*/
+	views := dbc.MonitoringReset.ResetViews()
+	query := GetMonitoringViewResetColumns(views)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+	rows, err := dbc.db.Query(query)
+	if err != nil {
+		lc <- LogMessage{fname, "Failed to read the columns of the monitoring views", false}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+	hasResetTime := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var has uint
+		if err := rows.Scan(&name, &has); err != nil {
+			rows.Close()
+			lc <- LogMessage{fname, "Failed to read the columns of the monitoring views", false}
+			lc <- LogMessage{fname, err.Error(), true}
+			return err
+		}
+		hasResetTime[name] = has > 0
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		lc <- LogMessage{fname, "Failed to read the columns of the monitoring views", false}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+
+	var failures = 0
+	for _, name := range views {
+		/*A view that doesn't exist is not listed and fails when it is read below*/
+		if has, found := hasResetTime[name]; found && !has {
+			lc <- LogMessage{fname, fmt.Sprintf("Skipping monitoring view %s, it has no RESET_TIME column", name), true}
+			continue
+		}
+		v := MonitoringView{Name: name}
+		query := GetMonitoringViewStats(name)
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+		if err := dbc.db.QueryRow(query).Scan(&v.Rows, &v.AgeDays); err != nil {
+			/*Not every view exists in every HANA version*/
+			lc <- LogMessage{fname, fmt.Sprintf("Failed to read monitoring view %s", name), false}
+			lc <- LogMessage{fname, err.Error(), true}
+			failures += 1
+			continue
+		}
+		if !v.ResetNeeded(dbc.MonitoringReset) {
+			lc <- LogMessage{fname, fmt.Sprintf("Reset not required, %s holds %d rows and was last reset %d days ago", name, v.Rows, v.AgeDays), true}
+			continue
+		}
+
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Reset monitoring view %s, %d rows, last reset %d days ago", name, v.Rows, v.AgeDays))
+		if dryrun {
+			continue
+		}
+		query = GetResetMonitoringView(name)
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+		if _, err := dbc.db.Exec(query); err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Failed to reset monitoring view %s", name), false}
+			lc <- LogMessage{fname, err.Error(), true}
+			failures += 1
+			continue
+		}
+		dbc.Results.MonitoringViewsReset += 1
+		dbc.Results.MonitoringRowsReset += uint(v.Rows)
+	}
+
+	/*choose an exit*/
+	switch {
+	case failures == 0:
+		lc <- LogMessage{fname, "Finished with no errors", true}
+		return nil
+	case failures == 1:
+		lc <- LogMessage{fname, "Monitoring view reset finished with one error", false}
+		return fmt.Errorf("one monitoring view reset error recorded")
+	default:
+		lc <- LogMessage{fname, fmt.Sprintf("Monitoring view reset finished with %d errors", failures), false}
+		return fmt.Errorf("%d monitoring view reset errors recorded", failures)
+	}
+}
+
+//Unsets trace section keys that are above the TraceLevels baseline.  When a grace period is configured, keys changed
+//within the grace period are left alone; keys with no change history are treated as old enough to reset.
+func (dbc *DbConfig) ResetTraceLevelsFunc(lc chan<- LogMessage, dryrun bool) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "TraceLevels")
+	lc <- LogMessage{fname, "Starting", false}
+	if dryrun {
+		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
+	}
+
+	c := dbc.TraceLevels
+	if c.GraceHours > 0 {
+		if err := dbc.Results.Version.Require(FeatureIniFileHistory, "'TraceLevels.GraceHours'"); err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Not resetting trace levels, %s", err.Error()), false}
+			return err
+		}
+	}
+	query := GetElevatedTraceLevels(c.ElevatedLevels(), c.Files, c.GraceHours > 0)
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+	rows, err := dbc.db.Query(query)
+	if err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+	defer rows.Close()
+
+	settings := make([]TraceLevelSetting, 0)
+	for rows.Next() {
+		t := TraceLevelSetting{}
+		err := rows.Scan(&t.File, &t.Layer, &t.Host, &t.Key, &t.Value, &t.AgeHours)
+		if err != nil {
+			lc <- LogMessage{fname, "Scan Error", true}
+			lc <- LogMessage{fname, err.Error(), true}
+			/*allow calling function to deal with the error*/
+			return err
+		}
+		settings = append(settings, t)
+	}
+	if err := rows.Err(); err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+
+	if len(settings) == 0 {
+		lc <- LogMessage{fname, "No trace levels above the baseline", true}
+		return nil
+	}
+
+	var failures = 0
+	for _, t := range settings {
+		if c.GraceHours > 0 && t.AgeHours >= 0 && t.AgeHours < int64(c.GraceHours) {
+			lc <- LogMessage{fname, fmt.Sprintf("Leaving %s, changed %d hours ago which is within the grace period", t, t.AgeHours), false}
+			continue
+		}
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Reset trace level %s", t))
+		if dryrun {
+			continue
+		}
+		query := GetUnsetTraceLevel(t)
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+		if _, err := dbc.db.Exec(query); err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Failed to reset trace level %s", t), false}
+			lc <- LogMessage{fname, err.Error(), true}
+			failures += 1
+			continue
+		}
+		dbc.Results.TraceLevelsReset = append(dbc.Results.TraceLevelsReset, t.String())
+	}
+
+	/*choose an exit*/
+	switch {
+	case failures == 0:
+		lc <- LogMessage{fname, "Finished with no errors", true}
+		return nil
+	case failures == 1:
+		lc <- LogMessage{fname, "Trace level reset finished with one error", false}
+		return fmt.Errorf("one trace level reset error recorded")
+	default:
+		lc <- LogMessage{fname, fmt.Sprintf("Trace level reset finished with %d errors", failures), false}
+		return fmt.Errorf("%d trace level reset errors recorded", failures)
+	}
+}
+
+//Clears the SQL plan cache when any service is over one of the PlanCache limits.  When schemas or users are configured
+//only the matching plans that are not in use are removed.  The memory freed is taken from before and after readings
+//of M_SQL_PLAN_CACHE_OVERVIEW.
+func (dbc *DbConfig) CleanPlanCacheFunc(lc chan<- LogMessage, dryrun bool) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "PlanCache")
+	lc <- LogMessage{fname, "Starting", false}
+	if dryrun {
+		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
+	}
+
+	c := dbc.PlanCache
+	services, err := dbc.getPlanCacheOverview(lc)
+	if err != nil {
+		return err
+	}
+
+	reason := ""
+	for _, s := range services {
+		dbc.Results.PlanCacheBytesBefore += s.CachedBytes
+		if reason == "" {
+			reason = s.OverLimit(c)
+		}
+	}
+	if reason == "" {
+		lc <- LogMessage{fname, "Plan cache is within its limits", true}
+		return nil
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("Plan cache is over its limits, %s", reason), false}
+
+	if c.Filtered() {
+		query := GetPlanCacheEntries(c.Schemas, c.Users)
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+		rows, err := dbc.db.Query(query)
+		if err != nil {
+			lc <- LogMessage{fname, "Query Failed", true}
+			lc <- LogMessage{fname, err.Error(), true}
+			return err
+		}
+		defer rows.Close()
+
+		plans := make([]uint64, 0)
+		for rows.Next() {
+			var id uint64
+			if err := rows.Scan(&id); err != nil {
+				lc <- LogMessage{fname, "Scan Error", true}
+				lc <- LogMessage{fname, err.Error(), true}
+				/*allow calling function to deal with the error*/
+				return err
+			}
+			plans = append(plans, id)
+		}
+		if err := rows.Err(); err != nil {
+			lc <- LogMessage{fname, "Query Failed", true}
+			lc <- LogMessage{fname, err.Error(), true}
+			return err
+		}
+
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Remove %d unused plans from the SQL plan cache (schemas: %s, users: %s)", len(plans), listOrAll(c.Schemas), listOrAll(c.Users)))
+		if !dryrun {
+			for _, id := range plans {
+				query := GetRemovePlanCacheEntry(id)
+				lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+				/*A plan may have been evicted or put back in use since it was found, so failures are only logged*/
+				if _, err := dbc.db.Exec(query); err != nil {
+					lc <- LogMessage{fname, fmt.Sprintf("Failed to remove plan %d", id), true}
+					lc <- LogMessage{fname, err.Error(), true}
+					continue
+				}
+				dbc.Results.PlanCacheEntriesRemoved += 1
+			}
+		}
+	} else {
+		dbc.AddPlan(lc, fname, "Clear the SQL plan cache")
+		if !dryrun {
+			lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_ClearPlanCache), true}
+			if _, err := dbc.db.Exec(QUERY_ClearPlanCache); err != nil {
+				lc <- LogMessage{fname, "Failed to clear the SQL plan cache", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return err
+			}
+		}
+	}
+
+	if c.ClearResultCache {
+		dbc.AddPlan(lc, fname, "Clear the result cache")
+		if !dryrun {
+			lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_ClearResultCache), true}
+			if _, err := dbc.db.Exec(QUERY_ClearResultCache); err != nil {
+				lc <- LogMessage{fname, "Failed to clear the result cache", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return err
+			}
+		}
+	}
+
+	if dryrun {
+		return nil
+	}
+	dbc.Results.PlanCacheReason = reason
+
+	/*This is a 'nice to have' check, if it fails we'll log it but carry on*/
+	after, err := dbc.getPlanCacheOverview(lc)
+	if err != nil {
+		lc <- LogMessage{fname, "Post cleaning plan cache check failed, cannot report memory freed", true}
+		dbc.Results.PlanCacheBytesAfter = dbc.Results.PlanCacheBytesBefore
+	} else {
+		for _, s := range after {
+			dbc.Results.PlanCacheBytesAfter += s.CachedBytes
+		}
+	}
+	lc <- LogMessage{fname, "Finished with no errors", true}
+	return nil
+}
+
+//Reads the plan cache usage of each service
+func (dbc *DbConfig) getPlanCacheOverview(lc chan<- LogMessage) ([]PlanCacheService, error) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "PlanCache")
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", QUERY_GetPlanCacheOverview), true}
+	rows, err := dbc.db.Query(QUERY_GetPlanCacheOverview)
+	if err != nil {
+		lc <- LogMessage{fname, "Query Failed", true}
+		lc <- LogMessage{fname, err.Error(), true}
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := make([]PlanCacheService, 0)
+	for rows.Next() {
+		s := PlanCacheService{}
+		if err := rows.Scan(&s.Host, &s.Port, &s.CachedBytes, &s.CapacityBytes, &s.EvictionsPerHour); err != nil {
+			lc <- LogMessage{fname, "Scan Error", true}
+			lc <- LogMessage{fname, err.Error(), true}
+			return nil, err
+		}
+		services = append(services, s)
+	}
+	return services, rows.Err()
+}
+
+//Removes rows older than their retention from the configured user defined tables.  Rows are counted first and then
+//deleted in batches, each batch in its own transaction, so the transaction log and table locks stay small.  A failure
+//on one table is recorded and the remaining tables are still cleaned.
+func (dbc *DbConfig) CleanTableRetentionFunc(lc chan<- LogMessage, dryrun bool) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "TableRetention")
+	lc <- LogMessage{fname, "Starting", false}
+	if dryrun {
+		lc <- LogMessage{fname, "Dry run enabled, no changes will be made", true}
+	}
+
+	if dbc.Results.TableRowsRemoved == nil {
+		dbc.Results.TableRowsRemoved = make(map[string]uint)
+	}
+	batch := dbc.TableRetention.Batch()
+	failures := 0
+	for _, t := range dbc.TableRetention.Tables {
+		var count uint
+		query := GetRetentionCount(t)
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+		if err := dbc.db.QueryRow(query).Scan(&count); err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Failed to count the rows to remove from %s", t), false}
+			lc <- LogMessage{fname, err.Error(), true}
+			failures += 1
+			continue
+		}
+		if count == 0 {
+			lc <- LogMessage{fname, fmt.Sprintf("No rows in %s are older than %d days", t, t.RetainDays), true}
+			continue
+		}
+
+		dbc.AddPlan(lc, fname, fmt.Sprintf("Delete %d rows older than %d days from %s in batches of %d", count, t.RetainDays, t, batch))
+		if dryrun {
+			continue
+		}
+
+		removed, _, _, err := dbc.deleteInBatches(lc, fname, t.String(), GetRetentionDeleteBatch(t, batch), batch, count, time.Time{})
+		dbc.Results.TableRowsRemoved[t.String()] += removed
+		if err != nil {
+			lc <- LogMessage{fname, fmt.Sprintf("Failed to remove rows from %s after removing %d", t, removed), false}
+			lc <- LogMessage{fname, err.Error(), true}
+			failures += 1
+			continue
+		}
+		lc <- LogMessage{fname, fmt.Sprintf("Removed %d rows from %s", removed, t), true}
+	}
+
+	/*choose an exit*/
+	switch {
+	case failures == 0:
+		lc <- LogMessage{fname, "Finished with no errors", true}
+		return nil
+	case failures == 1:
+		lc <- LogMessage{fname, "Table retention finished with one error", false}
+		return fmt.Errorf("one table retention error recorded")
+	default:
+		lc <- LogMessage{fname, fmt.Sprintf("Table retention finished with %d errors", failures), false}
+		return fmt.Errorf("%d table retention errors recorded", failures)
+	}
+}
+
+//Runs a batch delete statement, one transaction per batch, until a batch removes fewer than batch rows or the count
+//of rows found before starting has been removed.  A batch of zero means the statement has no row limit, so only an
+//empty batch ends the loop early.  Rows that age past their retention while the task runs are left for the next run
+//so a busy table can't keep the task running.  No new batch is started after the deadline, unless it is zero.
+//Returns the number of rows removed, the number of batches and whether every counted row was removed.
+func (dbc *DbConfig) deleteInBatches(lc chan<- LogMessage, fname, what, query string, batch, count uint, deadline time.Time) (uint, uint, bool, error) {
+	var removed, batches uint
+	for removed < count {
+		if !deadline.IsZero() && batches > 0 && time.Now().After(deadline) {
+			lc <- LogMessage{fname, fmt.Sprintf("Run time budget reached, %d of %d rows removed from %s, the rest are left for the next run", removed, count, what), false}
+			return removed, batches, false, nil
+		}
+		tx, err := dbc.db.Begin()
+		if err != nil {
+			return removed, batches, false, err
+		}
+		lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+		res, err := tx.Exec(query)
+		if err != nil {
+			tx.Rollback()
+			return removed, batches, false, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return removed, batches, false, err
+		}
+		if err := tx.Commit(); err != nil {
+			return removed, batches, false, err
+		}
+		removed += uint(n)
+		batches += 1
+		lc <- LogMessage{fname, fmt.Sprintf("Batch %d removed %d rows from %s, %d of %d", batches, n, what, removed, count), false}
+		if n == 0 || (batch > 0 && uint(n) < batch) {
+			break
+		}
+	}
+	return removed, batches, true, nil
+}
+
 //CheckPrivileges checks which privleges are supplied to the user.  If the users
 //doesn't have sufficient privleges to run the functions that are enabled
 //then none will be attempted
@@ -528,7 +2141,7 @@ func (dbc *DbConfig) CheckPrivileges(lc chan<- LogMessage) error {
 	/*MONITORING, nothing works correctly without monitoring*/
 
 	/*Check the all expected fields are in the map*/
-	elements := []string{"MONITORING", "TRACE_ADMIN", "BACKUP_ADMIN", "LOG_ADMIN", "AUDIT_OPERATOR", "RESOURCE_ADMIN", "SELECT_STATISTICS_ALERTS_BASE", "DELETE_STATISTICS_ALERTS_BASE"}
+	elements := []string{"MONITORING", "TRACE_ADMIN", "BACKUP_ADMIN", "LOG_ADMIN", "AUDIT_OPERATOR", "RESOURCE_ADMIN", "SESSION_ADMIN", "INIFILE_ADMIN", "OPTIMIZER_ADMIN", "SELECT_STATISTICS_ALERTS_BASE", "DELETE_STATISTICS_ALERTS_BASE"}
 	for _, v := range elements {
 		_, ok := privileges[v]
 		if !ok {
@@ -546,6 +2159,11 @@ func (dbc *DbConfig) CheckPrivileges(lc chan<- LogMessage) error {
 		return fmt.Errorf("the system privilege 'TRACE ADMIN' is required for the CleanTrace function but has not been granted to the user %s", dbc.Username)
 	}
 
+	/*If TraceQuota is requested but TRACE ADMIN is missing*/
+	if dbc.TraceQuota.Enabled && !privileges["TRACE_ADMIN"] {
+		return fmt.Errorf("the system privilege 'TRACE ADMIN' is required for the TraceQuota function but has not been granted to the user %s", dbc.Username)
+	}
+
 	/*If CleanBackupCatalog is requested but BACKUP ADMIN is missing*/
 	if dbc.CleanBackupCatalog && !privileges["BACKUP_ADMIN"] {
 		return fmt.Errorf("the system privilege 'BACKUP ADMIN' is required for the CleanBackupCatalog function but has not been granted to the user %s", dbc.Username)
@@ -566,6 +2184,64 @@ func (dbc *DbConfig) CheckPrivileges(lc chan<- LogMessage) error {
 		return fmt.Errorf("the system privilege 'RESOURCE ADMIN' is required for the CleanDataVolume function but has not been granted to the user %s", dbc.Username)
 	}
 
+	/*If MonitoringReset is requested but RESOURCE ADMIN is missing*/
+	if dbc.MonitoringReset.Enabled && !privileges["RESOURCE_ADMIN"] {
+		return fmt.Errorf("the system privilege 'RESOURCE ADMIN' is required for the MonitoringReset function but has not been granted to the user %s", dbc.Username)
+	}
+
+	/*If TraceLevels is requested but INIFILE ADMIN is missing*/
+	if dbc.TraceLevels.Enabled && !privileges["INIFILE_ADMIN"] {
+		return fmt.Errorf("the system privilege 'INIFILE ADMIN' is required for the TraceLevels function but has not been granted to the user %s", dbc.Username)
+	}
+
+	/*If PlanCache is requested but OPTIMIZER ADMIN is missing*/
+	if dbc.PlanCache.Enabled && !privileges["OPTIMIZER_ADMIN"] {
+		return fmt.Errorf("the system privilege 'OPTIMIZER ADMIN' is required for the PlanCache function but has not been granted to the user %s", dbc.Username)
+	}
+
+	/*If IdleSessions is requested but SESSION ADMIN is missing*/
+	if dbc.IdleSessions.Enabled && !privileges["SESSION_ADMIN"] {
+		return fmt.Errorf("the system privilege 'SESSION ADMIN' is required for the IdleSessions function but has not been granted to the user %s", dbc.Username)
+	}
+
+	/*If TableRetention is requested each table needs SELECT and DELETE, granted on the table or its schema*/
+	if dbc.TableRetention.Enabled {
+		for _, t := range dbc.TableRetention.Tables {
+			var count uint
+			query := GetRetentionPrivCheck(t)
+			lc <- LogMessage{fname, fmt.Sprintf("Attempting Query:%s", query), true}
+			if err := dbc.db.QueryRow(query).Scan(&count); err != nil {
+				lc <- LogMessage{fname, "Database returned an error!", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return fmt.Errorf("DB error")
+			}
+			if count < 2 {
+				return fmt.Errorf("the SELECT and DELETE privileges on %s are required for the TableRetention function but have not been granted to the user %s", sqlTableName(t.Schema, t.Table), dbc.Username)
+			}
+		}
+	}
+
+	/*If TableOptimise is requested each included schema needs UPDATE, which both MERGE DELTA and the compression
+	optimisation require.  When every schema is included the tables are only known once the task runs*/
+	if dbc.TableOptimise.Enabled {
+		if len(dbc.TableOptimise.IncludeSchemas) == 0 {
+			lc <- LogMessage{fname, "TableOptimise includes every schema, tables without the UPDATE privilege will fail when they are optimised", false}
+		}
+		for _, schema := range dbc.TableOptimise.IncludeSchemas {
+			var count uint
+			query := GetOptimisePrivCheck(schema)
+			lc <- LogMessage{fname, fmt.Sprintf("Attempting Query:%s", query), true}
+			if err := dbc.db.QueryRow(query).Scan(&count); err != nil {
+				lc <- LogMessage{fname, "Database returned an error!", false}
+				lc <- LogMessage{fname, err.Error(), true}
+				return fmt.Errorf("DB error")
+			}
+			if count == 0 {
+				return fmt.Errorf("the UPDATE privilege on the schema %s is required for the TableOptimise function but has not been granted to the user %s", sqlStringList([]string{schema}), dbc.Username)
+			}
+		}
+	}
+
 	/*If CleanAlerts is requested but SELECT_STATISTICS_ALERTS_BASE is missing*/
 	if dbc.CleanAlerts && !privileges["SELECT_STATISTICS_ALERTS_BASE"] {
 		return fmt.Errorf("the SELECT privilege on \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" is required for the CleanAlerts function but has not been granted to the user %s", dbc.Username)
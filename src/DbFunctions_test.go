@@ -3,11 +3,33 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 )
 
+//Returns the database configuration used by the tests, every task is enabled and retains 60 days.  The settings that
+//a test case needs are changed by the functions in set.
+func testDbConfig(db *sql.DB, set ...func(*DbConfig)) *DbConfig {
+	dbc := &DbConfig{Port: 30015, CleanTrace: true, RetainTraceDays: 60, CleanBackupCatalog: true, RetainBackupCatalogDays: 60, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 60, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, db: db}
+	for _, f := range set {
+		f(dbc)
+	}
+	return dbc
+}
+
+//Sets the name, host and user of the database used by the tests
+func testIdentity(c *DbConfig) {
+	c.Name = "TST"
+	c.Hostname = "test-hostname"
+	c.Username = "hccadmin"
+}
+
 func TestDbConfig_HanaVersionFunc(t *testing.T) {
 	/*Test Setup*/
 	/*Mock DB*/
@@ -39,11 +61,11 @@ func TestDbConfig_HanaVersionFunc(t *testing.T) {
 		want    string
 		wantErr bool
 	}{
-		{"Good01", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, "2.00.45.00", false},
-		{"Good02", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, "2.00.43.33", false},
-		{"Good03", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, "3.00.00.10", false},
-		{"Good04", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, "1.00.112.3", false},
-		{"DbError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, "", true},
+		{"Good01", testDbConfig(db1), args{lc}, "2.00.45.00", false},
+		{"Good02", testDbConfig(db1), args{lc}, "2.00.43.33", false},
+		{"Good03", testDbConfig(db1), args{lc}, "3.00.00.10", false},
+		{"Good04", testDbConfig(db1), args{lc}, "1.00.112.3", false},
+		{"DbError", testDbConfig(db1), args{lc}, "", true},
 	}
 	for _, tt := range tests {
 		/*Set up per case mocking*/
@@ -67,6 +89,80 @@ func TestDbConfig_HanaVersionFunc(t *testing.T) {
 	}
 }
 
+func TestDbConfig_ReplicationRoleFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	/*Types*/
+	type args struct {
+		lc chan<- LogMessage
+	}
+
+	/*Tests*/
+	tests := []struct {
+		name        string
+		dbc         *DbConfig
+		args        args
+		version     string
+		mode        string
+		secondaries uint
+		inactive    uint
+		want        string
+		wantErr     bool
+	}{
+		{"Primary", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "primary", 1, 0, ReplicationPrimary, false},
+		{"PrimaryInactiveService", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "primary", 1, 2, ReplicationPrimary, false},
+		{"PrimaryNoMode", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "", 1, 0, ReplicationPrimary, false},
+		{"SecondarySync", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "SYNC", 0, 0, ReplicationSecondary, false},
+		{"SecondaryAsync", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "async", 0, 0, ReplicationSecondary, false},
+		{"NotReplicated", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "", 0, 0, ReplicationNone, false},
+		{"ModeNone", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "none", 0, 0, ReplicationNone, false},
+		{"HanaOnePrimary", testDbConfig(db1), args{lc}, "1.00.122.33.1604661230", "primary", 1, 0, ReplicationPrimary, false},
+		{"DbError", testDbConfig(db1), args{lc}, "2.00.045.00.1575639312", "", 0, 0, ReplicationUnknown, true},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking, HANA 1.0 uses the query variant without M_SYSTEM_REPLICATION*/
+		tt.dbc.Results.Version, _ = ParseHanaVersion(tt.version)
+		query := QUERY_GetReplicationRole
+		if tt.dbc.Results.Version.Major == 1 {
+			query = QUERY_GetReplicationRoleV1
+		}
+		if tt.wantErr {
+			mock.ExpectQuery(query).WillReturnError(fmt.Errorf("DB Error"))
+		} else {
+			mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"MODE", "SECONDARIES", "INACTIVE"}).AddRow(tt.mode, tt.secondaries, tt.inactive))
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.dbc.ReplicationRoleFunc(tt.args.lc)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.ReplicationRoleFunc() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DbConfig.ReplicationRoleFunc() = %v, want %v", got, tt.want)
+			}
+			if !tt.wantErr && tt.dbc.Results.ReplicationRole != tt.want {
+				t.Errorf("DbConfig.ReplicationRoleFunc() stored role = %v, want %v", tt.dbc.Results.ReplicationRole, tt.want)
+			}
+		})
+	}
+}
+
 func TestDbConfig_CleanTraceFilesFunc(t *testing.T) {
 	/*Test Setup*/
 	/*Mock DB*/
@@ -85,6 +181,10 @@ func TestDbConfig_CleanTraceFilesFunc(t *testing.T) {
 
 	go Logger(AppConfig{"file", true, false, false}, lc, quit)
 
+	/*Keep the retry backoff short*/
+	defer func(d time.Duration) { retryBackoffUnit = d }(retryBackoffUnit)
+	retryBackoffUnit = time.Millisecond
+
 	/*Args*/
 	type args struct {
 		lc             chan<- LogMessage
@@ -99,18 +199,21 @@ func TestDbConfig_CleanTraceFilesFunc(t *testing.T) {
 		args    args
 		wantErr bool
 	}{
-		{"Good01", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"Good02", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 14, false}, false},
-		{"Good03", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 7, false}, false},
-		{"TraceQueryFails", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
-		{"TraceQueryUnscannable", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
-		{"ClearTraceFails", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"MultiTraceGood", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"MultiTraceCantDeleteFirst", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"NothingToDelete", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"RemovalRowEmpty", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"RemovalRowError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"DryRun", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, true}, false},
+		{"Good01", testDbConfig(db1), args{lc, 60, false}, false},
+		{"Good02", testDbConfig(db1), args{lc, 14, false}, false},
+		{"Good03", testDbConfig(db1), args{lc, 7, false}, false},
+		{"TraceQueryFails", testDbConfig(db1), args{lc, 60, false}, true},
+		{"TraceQueryUnscannable", testDbConfig(db1), args{lc, 60, false}, true},
+		{"ClearTraceFails", testDbConfig(db1), args{lc, 60, false}, false},
+		{"MultiTraceGood", testDbConfig(db1), args{lc, 60, false}, false},
+		{"MultiTraceCantDeleteFirst", testDbConfig(db1), args{lc, 60, false}, false},
+		{"NothingToDelete", testDbConfig(db1), args{lc, 60, false}, false},
+		{"RemovalRowEmpty", testDbConfig(db1), args{lc, 60, false}, false},
+		{"RemovalRowError", testDbConfig(db1), args{lc, 60, false}, false},
+		{"DryRun", testDbConfig(db1), args{lc, 60, true}, false},
+		{"MultiHost", testDbConfig(db1), args{lc, 60, false}, false},
+		{"TransientQueryRetried", testDbConfig(db1), args{lc, 60, false}, false},
+		{"TransientQueryGivesUp", testDbConfig(db1, func(c *DbConfig) { c.Retry = RetryConfig{MaxAttempts: 2} }), args{lc, 60, false}, true},
 	}
 	for _, tt := range tests {
 
@@ -167,6 +270,25 @@ func TestDbConfig_CleanTraceFilesFunc(t *testing.T) {
 		case tt.name == "DryRun":
 			rows1 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "traceNoRows.trc", "6400000", "2020-03-14 23:13:35.000000000")
 			mock.ExpectQuery(GetTraceFileQuery(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+		case tt.name == "MultiHost":
+			rows1 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hana01", "trace.trc", "6400000", "2020-03-14 23:13:35.000000000").AddRow("hana02", "trace2.trc", "3200000", "2020-03-14 23:13:35.000000000").AddRow("hana01", "trace3.trc", "1600000", "2020-03-14 23:13:35.000000000")
+			mock.ExpectQuery(GetTraceFileQuery(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectExec(GetRemoveTrace("hana01", "trace.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace.trc")).WillReturnRows(sqlmock.NewRows([]string{"TRACE"}).AddRow("0"))
+			mock.ExpectExec(GetRemoveTrace("hana02", "trace2.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace2.trc")).WillReturnRows(sqlmock.NewRows([]string{"TRACE"}).AddRow("0"))
+			mock.ExpectExec(GetRemoveTrace("hana01", "trace3.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace3.trc")).WillReturnRows(sqlmock.NewRows([]string{"TRACE"}).AddRow("0"))
+		case tt.name == "TransientQueryRetried":
+			rows1 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace.trc", "6400000", "2020-03-14 23:13:35.000000000")
+			rows2 := sqlmock.NewRows([]string{"TRACE"}).AddRow("0")
+			mock.ExpectQuery(GetTraceFileQuery(tt.args.CleanDaysOlder)).WillReturnError(fmt.Errorf("read tcp: %w", syscall.ECONNRESET))
+			mock.ExpectQuery(GetTraceFileQuery(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace.trc")).WillReturnRows(rows2)
+		case tt.name == "TransientQueryGivesUp":
+			mock.ExpectQuery(GetTraceFileQuery(tt.args.CleanDaysOlder)).WillReturnError(fmt.Errorf("read tcp: %w", syscall.ECONNRESET))
+			mock.ExpectQuery(GetTraceFileQuery(tt.args.CleanDaysOlder)).WillReturnError(fmt.Errorf("read tcp: %w", syscall.ECONNRESET))
 		default:
 			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
 		}
@@ -175,6 +297,176 @@ func TestDbConfig_CleanTraceFilesFunc(t *testing.T) {
 			if err := tt.dbc.CleanTraceFilesFunc(tt.args.lc, tt.args.CleanDaysOlder, tt.args.dryrun); (err != nil) != tt.wantErr {
 				t.Errorf("DbConfig.CleanTraceFiles() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if len(tt.name) > 9 && tt.name[0:9] == "Transient" && tt.dbc.Results.Retries != 1 {
+				t.Errorf("DbConfig.CleanTraceFiles() retries = %d, want 1", tt.dbc.Results.Retries)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+			if tt.name == "MultiHost" {
+				want := map[string]NodeRemoved{"hana01": {2, 8000000}, "hana02": {1, 3200000}}
+				if !reflect.DeepEqual(tt.dbc.Results.TraceRemovedByHost, want) {
+					t.Errorf("DbConfig.CleanTraceFiles() by host = %v, want %v", tt.dbc.Results.TraceRemovedByHost, want)
+				}
+			}
+		})
+	}
+	quit <- true
+}
+
+func TestDbConfig_CleanTraceQuotaFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	/*Keep the retry backoff short*/
+	defer func(d time.Duration) { retryBackoffUnit = d }(retryBackoffUnit)
+	retryBackoffUnit = time.Millisecond
+
+	/*Args*/
+	type args struct {
+		lc     chan<- LogMessage
+		dryrun bool
+	}
+
+	/*Tests*/
+	/*Configuration shared by the test cases*/
+	quota := func(c *DbConfig) {
+		c.TraceQuota = TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 1, Exclusions: []string{"*_alert_*.trc"}}
+	}
+
+	tests := []struct {
+		name          string
+		dbc           *DbConfig
+		args          args
+		wantErr       bool
+		wantRemoved   uint
+		wantHostsOver uint
+	}{
+		{"GoodWithinQuota", testDbConfig(db1, quota), args{lc, false}, false, 0, 0},
+		{"GoodQuotaMet", testDbConfig(db1, quota), args{lc, false}, false, 2, 0},
+		{"GoodExclusionSkipped", testDbConfig(db1, quota), args{lc, false}, false, 1, 0},
+		{"QuotaNotMet", testDbConfig(db1, quota), args{lc, false}, false, 1, 1},
+		{"RemovalFails", testDbConfig(db1, quota), args{lc, false}, false, 0, 1},
+		{"SizeQueryFails", testDbConfig(db1, quota), args{lc, false}, true, 0, 0},
+		{"SizeQueryUnscannable", testDbConfig(db1, quota), args{lc, false}, true, 0, 0},
+		{"CandidateQueryFails", testDbConfig(db1, quota), args{lc, false}, true, 0, 0},
+		{"CandidateQueryUnscannable", testDbConfig(db1, quota), args{lc, false}, true, 0, 0},
+		{"DryRun", testDbConfig(db1, quota), args{lc, true}, false, 0, 0},
+		{"TransientSizeQuery", testDbConfig(db1, quota), args{lc, false}, false, 0, 0},
+		{"TransientCandidateQuery", testDbConfig(db1, quota), args{lc, false}, false, 1, 0},
+		{"TransientRemoval", testDbConfig(db1, quota), args{lc, false}, false, 1, 0},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		switch {
+		case tt.name == "GoodWithinQuota":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "500000")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+		case tt.name == "GoodQuotaMet":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000").AddRow("hanaserver2", "500000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace1.trc", "1000000", "2020-03-14 23:13:35.000000000").AddRow("hanaserver", "trace2.trc", "1500000", "2020-03-15 23:13:35.000000000").AddRow("hanaserver", "trace3.trc", "400000", "2020-03-16 23:13:35.000000000")
+			rows3 := sqlmock.NewRows([]string{"TRACE"}).AddRow("0")
+			rows4 := sqlmock.NewRows([]string{"TRACE"}).AddRow("0")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace1.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace1.trc")).WillReturnRows(rows3)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace2.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace2.trc")).WillReturnRows(rows4)
+		case tt.name == "GoodExclusionSkipped":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "2000000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "indexserver_alert_hanaserver.trc", "1000000", "2020-03-14 23:13:35.000000000").AddRow("hanaserver", "trace2.trc", "1500000", "2020-03-15 23:13:35.000000000")
+			rows3 := sqlmock.NewRows([]string{"TRACE"}).AddRow("0")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace2.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace2.trc")).WillReturnRows(rows3)
+		case tt.name == "QuotaNotMet":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace1.trc", "500000", "2020-03-14 23:13:35.000000000")
+			rows3 := sqlmock.NewRows([]string{"TRACE"}).AddRow("0")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace1.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace1.trc")).WillReturnRows(rows3)
+		case tt.name == "RemovalFails":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace1.trc", "2500000", "2020-03-14 23:13:35.000000000")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace1.trc")).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "SizeQueryFails":
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "SizeQueryUnscannable":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "BAD_DATA")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+		case tt.name == "CandidateQueryFails":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "CandidateQueryUnscannable":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace1.trc", "BAD_DATA", "2020-03-14 23:13:35.000000000")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+		case tt.name == "DryRun":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace1.trc", "2500000", "2020-03-14 23:13:35.000000000")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+		case tt.name == "TransientSizeQuery":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "500000")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnError(testHdbError{131})
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+		case tt.name == "TransientCandidateQuery":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace1.trc", "2500000", "2020-03-14 23:13:35.000000000")
+			rows3 := sqlmock.NewRows([]string{"TRACE"}).AddRow("0")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnError(testHdbError{131})
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace1.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace1.trc")).WillReturnRows(rows3)
+		case tt.name == "TransientRemoval":
+			rows1 := sqlmock.NewRows([]string{"HOST", "BYTES"}).AddRow("hanaserver", "3000000")
+			rows2 := sqlmock.NewRows([]string{"HOST", "FILE_NAME", "FILE_SIZE", "FILE_MTIME"}).AddRow("hanaserver", "trace1.trc", "2500000", "2020-03-14 23:13:35.000000000")
+			rows3 := sqlmock.NewRows([]string{"TRACE"}).AddRow("0")
+			mock.ExpectQuery(QUERY_GetTraceSizePerHost).WillReturnRows(rows1)
+			mock.ExpectQuery(GetTraceQuotaCandidates(tt.dbc.TraceQuota.MinAgeHours)).WillReturnRows(rows2)
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace1.trc")).WillReturnError(testHdbError{131})
+			mock.ExpectExec(GetRemoveTrace("hanaserver", "trace1.trc")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetCheckTracePresent("trace1.trc")).WillReturnRows(rows3)
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.CleanTraceQuotaFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.CleanTraceQuotaFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.dbc.Results.TraceQuotaFilesRemoved != tt.wantRemoved {
+				t.Errorf("DbConfig.CleanTraceQuotaFunc() removed = %d, want %d", tt.dbc.Results.TraceQuotaFilesRemoved, tt.wantRemoved)
+			}
+			if tt.dbc.Results.TraceQuotaHostsOver != tt.wantHostsOver {
+				t.Errorf("DbConfig.CleanTraceQuotaFunc() hosts over quota = %d, want %d", tt.dbc.Results.TraceQuotaHostsOver, tt.wantHostsOver)
+			}
+			if len(tt.name) > 9 && tt.name[0:9] == "Transient" && tt.dbc.Results.Retries != 1 {
+				t.Errorf("DbConfig.CleanTraceQuotaFunc() retries = %d, want 1", tt.dbc.Results.Retries)
+			}
 		})
 	}
 	quit <- true
@@ -198,6 +490,13 @@ func TestDbConfig_CleanBackupFunc(t *testing.T) {
 
 	go Logger(AppConfig{"file", true, false, false}, lc, quit)
 
+	/*Keep the retry backoff short*/
+	defer func(d time.Duration) { retryBackoffUnit = d }(retryBackoffUnit)
+	retryBackoffUnit = time.Millisecond
+
+	var backupDate string = "2021-06-01 22:00:00.000000000"
+	exportDir := t.TempDir()
+
 	/*args*/
 	type args struct {
 		lc             chan<- LogMessage
@@ -207,20 +506,54 @@ func TestDbConfig_CleanBackupFunc(t *testing.T) {
 	}
 
 	/*Tests*/
+	/*Configuration shared by the test cases*/
+	countAndAge := func(c *DbConfig) { c.Backup = BackupConfig{RetentionMode: "both", RetainFullBackups: 3} }
+	safetyCheck := func(c *DbConfig) { c.Backup = BackupConfig{SafetyCheck: true, SafetyWindowHours: 24} }
+	export := func(c *DbConfig) {
+		c.Name = "systemdb"
+		c.Backup = BackupConfig{ExportDir: exportDir, ExportFormat: "csv"}
+	}
+	fileOnly := func(c *DbConfig) { c.Backup = BackupConfig{DeleteDestinationTypes: []string{"file"}} }
+
 	tests := []struct {
 		name    string
 		dbc     *DbConfig
 		args    args
 		wantErr bool
 	}{
-		{"GoodClean", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false, false}, false},
-		{"GoodDelete", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, true, false}, false},
-		{"QueryBackupIdFailed", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false, false}, true},
-		{"QueryBackupIdNoRows", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false, false}, false},
-		{"QueryFileDataFailed", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false, false}, true},
-		{"NothingToDelete", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false, false}, false},
-		{"CleanFailed", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false, false}, true},
-		{"DeleteFailed", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, true, false}, true},
+		{"GoodClean", testDbConfig(db1), args{lc, 60, false, false}, false},
+		{"TransientTruncationRetried", testDbConfig(db1, func(c *DbConfig) {
+			c.Retry = RetryConfig{MaxAttempts: 1, Tasks: map[string]RetryConfig{RetryTaskCleanBackupCatalog: {2, 0, 0, nil}}}
+		}), args{lc, 60, false, false}, false},
+		{"FatalTruncationNotRetried", testDbConfig(db1), args{lc, 60, false, false}, true},
+		{"GoodDelete", testDbConfig(db1), args{lc, 60, true, false}, false},
+		{"QueryBackupIdFailed", testDbConfig(db1), args{lc, 60, false, false}, true},
+		{"QueryBackupIdNoRows", testDbConfig(db1), args{lc, 60, false, false}, false},
+		{"QueryFileDataFailed", testDbConfig(db1), args{lc, 60, false, false}, true},
+		{"QueryFileDataScanFailed", testDbConfig(db1), args{lc, 60, false, false}, true},
+		{"NothingToDelete", testDbConfig(db1), args{lc, 60, false, false}, false},
+		{"CleanFailed", testDbConfig(db1), args{lc, 60, false, false}, true},
+		{"DeleteFailed", testDbConfig(db1), args{lc, 60, true, false}, true},
+		{"CountMode", testDbConfig(db1, func(c *DbConfig) { c.Backup = BackupConfig{RetentionMode: "count", RetainFullBackups: 3} }), args{lc, 60, false, false}, false},
+		{"BothModeCountOlder", testDbConfig(db1, countAndAge), args{lc, 60, false, false}, false},
+		{"BothModeDaysOlder", testDbConfig(db1, countAndAge), args{lc, 60, false, false}, false},
+		{"BothModeCountNoRows", testDbConfig(db1, countAndAge), args{lc, 60, false, false}, false},
+		{"SafetyCheckPassed", testDbConfig(db1, safetyCheck), args{lc, 60, false, false}, false},
+		{"SafetyCheckNoRecentFull", testDbConfig(db1, safetyCheck), args{lc, 60, false, false}, true},
+		{"SafetyCheckLogGap", testDbConfig(db1, safetyCheck), args{lc, 60, false, false}, true},
+		{"SafetyCheckLogStartsAfterFull", testDbConfig(db1, safetyCheck), args{lc, 60, false, false}, true},
+		{"SafetyCheckNoLogBackups", testDbConfig(db1, safetyCheck), args{lc, 60, false, false}, true},
+		{"SafetyCheckQueryFailed", testDbConfig(db1, safetyCheck), args{lc, 60, false, false}, true},
+		{"ExportCatalog", testDbConfig(db1, export), args{lc, 60, false, false}, false},
+		{"ExportCatalogDryRun", testDbConfig(db1, export), args{lc, 60, false, true}, false},
+		{"ExportCatalogQueryFailed", testDbConfig(db1, export), args{lc, 60, false, false}, true},
+		{"ExportCatalogMissingDir", testDbConfig(db1, func(c *DbConfig) {
+			c.Name = "systemdb"
+			c.Backup = BackupConfig{ExportDir: filepath.Join(exportDir, "missing"), ExportFormat: "csv"}
+		}), args{lc, 60, false, false}, true},
+		{"FilteredDelete", testDbConfig(db1, fileOnly), args{lc, 60, true, false}, false},
+		{"FilteredDeleteFailed", testDbConfig(db1, fileOnly), args{lc, 60, true, false}, true},
+		{"FilteredDeleteRetried", testDbConfig(db1, fileOnly), args{lc, 60, true, false}, false},
 	}
 	for _, tt := range tests {
 
@@ -228,15 +561,27 @@ func TestDbConfig_CleanBackupFunc(t *testing.T) {
 		switch {
 		case tt.name == "GoodClean":
 			var backupID string = "12345678890"
-			rows1 := sqlmock.NewRows([]string{"BACKUP_ID"}).AddRow(backupID)
-			rows2 := sqlmock.NewRows([]string{"ENTRY", "COUNT", "BYTES"}).AddRow("complete data backup", 10, 100000000).AddRow("log backup", 100, 100000000)
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000).AddRow("log backup", "file", 100, 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
+			mock.ExpectExec(GetBackupDelete(backupID)).WillReturnResult(sqlmock.NewResult(1, 1))
+		case tt.name == "TransientTruncationRetried", tt.name == "FatalTruncationNotRetried":
+			var backupID string = "12345678890"
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000)
 			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
 			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
+			if tt.name == "FatalTruncationNotRetried" {
+				mock.ExpectExec(GetBackupDelete(backupID)).WillReturnError(testHdbError{258})
+				break
+			}
+			mock.ExpectExec(GetBackupDelete(backupID)).WillReturnError(testHdbError{131})
 			mock.ExpectExec(GetBackupDelete(backupID)).WillReturnResult(sqlmock.NewResult(1, 1))
 		case tt.name == "GoodDelete":
 			var backupID string = "12345678890"
-			rows1 := sqlmock.NewRows([]string{"BACKUP_ID"}).AddRow(backupID)
-			rows2 := sqlmock.NewRows([]string{"ENTRY", "COUNT", "BYTES"}).AddRow("complete data backup", 10, 100000000).AddRow("log backup", 100, 100000000)
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000).AddRow("log backup", "file", 100, 100000000)
 			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
 			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
 			mock.ExpectExec(GetBackupDeleteComplete(backupID)).WillReturnResult(sqlmock.NewResult(1, 1))
@@ -246,37 +591,214 @@ func TestDbConfig_CleanBackupFunc(t *testing.T) {
 			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnError(sql.ErrNoRows)
 		case tt.name == "QueryFileDataFailed":
 			var backupID string = "12345678890"
-			rows1 := sqlmock.NewRows([]string{"BACKUP_ID"}).AddRow(backupID)
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
 			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
 			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "QueryFileDataScanFailed":
+			var backupID string = "12345678890"
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", "ten", 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
 		case tt.name == "NothingToDelete":
 			var backupID string = "12345678890"
-			rows1 := sqlmock.NewRows([]string{"BACKUP_ID"}).AddRow(backupID)
-			rows2 := sqlmock.NewRows([]string{"ENTRY", "COUNT", "BYTES"})
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"})
 			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
 			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
 		case tt.name == "CleanFailed":
 			var backupID string = "12345678890"
-			rows1 := sqlmock.NewRows([]string{"BACKUP_ID"}).AddRow(backupID)
-			rows2 := sqlmock.NewRows([]string{"ENTRY", "COUNT", "BYTES"}).AddRow("complete data backup", 10, 100000000).AddRow("log backup", 100, 100000000)
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000).AddRow("log backup", "file", 100, 100000000)
 			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
 			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
 			mock.ExpectExec(GetBackupDelete(backupID)).WillReturnError(fmt.Errorf("Some DB error"))
 		case tt.name == "DeleteFailed":
 			var backupID string = "12345678890"
-			rows1 := sqlmock.NewRows([]string{"BACKUP_ID"}).AddRow(backupID)
-			rows2 := sqlmock.NewRows([]string{"ENTRY", "COUNT", "BYTES"}).AddRow("complete data backup", 10, 100000000).AddRow("log backup", 100, 100000000)
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000).AddRow("log backup", "file", 100, 100000000)
 			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
 			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
 			mock.ExpectExec(GetBackupDeleteComplete(backupID)).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "CountMode":
+			var backupID string = "12345678890"
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow(backupID, backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000).AddRow("log backup", "file", 100, 100000000)
+			mock.ExpectQuery(GetNthLatestFullBackupID(tt.dbc.Backup.RetainFullBackups)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData(backupID)).WillReturnRows(rows2)
+			mock.ExpectExec(GetBackupDelete(backupID)).WillReturnResult(sqlmock.NewResult(1, 1))
+		case tt.name == "BothModeCountOlder":
+			/*The count based anchor is older, so it must be used*/
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("2000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", "2021-05-01 22:00:00.000000000")
+			rows3 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetNthLatestFullBackupID(tt.dbc.Backup.RetainFullBackups)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupFileData("1000")).WillReturnRows(rows3)
+			mock.ExpectExec(GetBackupDelete("1000")).WillReturnResult(sqlmock.NewResult(1, 1))
+		case tt.name == "BothModeDaysOlder":
+			/*The days based anchor is older, so it must be used*/
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("2000", "2021-07-01 22:00:00.000000000")
+			rows3 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetNthLatestFullBackupID(tt.dbc.Backup.RetainFullBackups)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupFileData("1000")).WillReturnRows(rows3)
+			mock.ExpectExec(GetBackupDelete("1000")).WillReturnResult(sqlmock.NewResult(1, 1))
+		case tt.name == "BothModeCountNoRows":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetNthLatestFullBackupID(tt.dbc.Backup.RetainFullBackups)).WillReturnError(sql.ErrNoRows)
+		case tt.name == "SafetyCheckPassed":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("2000", "2021-07-31 22:00:00.000000000")
+			rows3 := sqlmock.NewRows([]string{"LOG_BACKUPS", "GAPS"}).AddRow(12, 0)
+			rows4 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRecentFullBackupAfter("1000", tt.dbc.Backup.SafetyWindowHours)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetLogBackupChainAfter("2000")).WillReturnRows(rows3)
+			mock.ExpectQuery(GetBackupFileData("1000")).WillReturnRows(rows4)
+			mock.ExpectExec(GetBackupDelete("1000")).WillReturnResult(sqlmock.NewResult(1, 1))
+		case tt.name == "SafetyCheckNoRecentFull":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRecentFullBackupAfter("1000", tt.dbc.Backup.SafetyWindowHours)).WillReturnError(sql.ErrNoRows)
+		case tt.name == "SafetyCheckLogGap":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("2000", "2021-07-31 22:00:00.000000000")
+			rows3 := sqlmock.NewRows([]string{"LOG_BACKUPS", "GAPS"}).AddRow(12, 1)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRecentFullBackupAfter("1000", tt.dbc.Backup.SafetyWindowHours)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetLogBackupChainAfter("2000")).WillReturnRows(rows3)
+		case tt.name == "SafetyCheckLogStartsAfterFull":
+			/*The log backups are contiguous with each other, but the first starts after the position the full backup ended at*/
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("2000", "2021-07-31 22:00:00.000000000")
+			rows3 := sqlmock.NewRows([]string{"LOG_BACKUPS", "GAPS"}).AddRow(3, 1)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRecentFullBackupAfter("1000", tt.dbc.Backup.SafetyWindowHours)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetLogBackupChainAfter("2000")).WillReturnRows(rows3)
+		case tt.name == "SafetyCheckNoLogBackups":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("2000", "2021-07-31 22:00:00.000000000")
+			rows3 := sqlmock.NewRows([]string{"LOG_BACKUPS", "GAPS"}).AddRow(0, 0)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRecentFullBackupAfter("1000", tt.dbc.Backup.SafetyWindowHours)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetLogBackupChainAfter("2000")).WillReturnRows(rows3)
+		case tt.name == "SafetyCheckQueryFailed":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRecentFullBackupAfter("1000", tt.dbc.Backup.SafetyWindowHours)).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "ExportCatalog":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 1, 100000000)
+			rows3 := sqlmock.NewRows([]string{"BACKUP_ID", "ENTRY_TYPE_NAME", "STATE_NAME", "SYS_START_TIME", "SYS_END_TIME", "HOST", "SERVICE_TYPE_NAME", "DESTINATION_TYPE_NAME", "DESTINATION_PATH", "BACKUP_SIZE"}).
+				AddRow("900", "complete data backup", "successful", "2021-05-01 22:00:00", "2021-05-01 23:00:00", "hana01", "indexserver", "file", "/hana/backup/data/COMPLETE_DATA_BACKUP_databackup_2_1", 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData("1000")).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupCatalogExport("1000")).WillReturnRows(rows3)
+			mock.ExpectExec(GetBackupDelete("1000")).WillReturnResult(sqlmock.NewResult(1, 1))
+		case tt.name == "ExportCatalogDryRun":
+			/*Nothing is exported or removed in a dry run*/
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1001", backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 1, 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData("1001")).WillReturnRows(rows2)
+		case tt.name == "ExportCatalogQueryFailed":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1002", backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 1, 100000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData("1002")).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupCatalogExport("1002")).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "ExportCatalogMissingDir":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1003", backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 1, 100000000)
+			rows3 := sqlmock.NewRows([]string{"BACKUP_ID", "ENTRY_TYPE_NAME", "STATE_NAME", "SYS_START_TIME", "SYS_END_TIME", "HOST", "SERVICE_TYPE_NAME", "DESTINATION_TYPE_NAME", "DESTINATION_PATH", "BACKUP_SIZE"})
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData("1003")).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupCatalogExport("1003")).WillReturnRows(rows3)
+		case tt.name == "FilteredDelete":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			/*Backup 980 has files in both destination types, its 50000000 bytes held in file are not deleted*/
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 3, 150000000).AddRow("complete data backup", "backint", 2, 300000000)
+			rows3 := sqlmock.NewRows([]string{"BACKUP_ID", "DESTINATION_TYPE_NAME", "BYTES"}).AddRow("900", "file", 60000000).AddRow("950", "file", 40000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData("1000")).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupIDsToDelete("1000", tt.dbc.Backup.DeleteDestinationTypes, tt.dbc.Backup.DeleteEntryTypes)).WillReturnRows(rows3)
+			mock.ExpectExec(GetBackupDeleteIDComplete("900")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(GetBackupDeleteIDComplete("950")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(GetBackupDelete("1000")).WillReturnResult(sqlmock.NewResult(1, 1))
+		case tt.name == "FilteredDeleteFailed":
+			/*Backup 900 is deleted before the delete of 950 fails*/
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000)
+			rows3 := sqlmock.NewRows([]string{"BACKUP_ID", "DESTINATION_TYPE_NAME", "BYTES"}).AddRow("900", "file", 60000000).AddRow("950", "file", 40000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData("1000")).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupIDsToDelete("1000", tt.dbc.Backup.DeleteDestinationTypes, tt.dbc.Backup.DeleteEntryTypes)).WillReturnRows(rows3)
+			mock.ExpectExec(GetBackupDeleteIDComplete("900")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(GetBackupDeleteIDComplete("950")).WillReturnError(fmt.Errorf("Some DB error"))
+		case tt.name == "FilteredDeleteRetried":
+			rows1 := sqlmock.NewRows([]string{"BACKUP_ID", "SYS_END_TIME"}).AddRow("1000", backupDate)
+			rows2 := sqlmock.NewRows([]string{"ENTRY", "DESTINATION", "COUNT", "BYTES"}).AddRow("complete data backup", "file", 10, 100000000)
+			rows3 := sqlmock.NewRows([]string{"BACKUP_ID", "DESTINATION_TYPE_NAME", "BYTES"}).AddRow("900", "file", 60000000)
+			mock.ExpectQuery(GetLatestFullBackupID(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetBackupFileData("1000")).WillReturnRows(rows2)
+			mock.ExpectQuery(GetBackupIDsToDelete("1000", tt.dbc.Backup.DeleteDestinationTypes, tt.dbc.Backup.DeleteEntryTypes)).WillReturnRows(rows3)
+			mock.ExpectExec(GetBackupDeleteIDComplete("900")).WillReturnError(testHdbError{131})
+			mock.ExpectExec(GetBackupDeleteIDComplete("900")).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectExec(GetBackupDelete("1000")).WillReturnResult(sqlmock.NewResult(1, 1))
 		default:
 			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
 		}
 
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.dbc.CleanBackupFunc(tt.args.lc, tt.args.CleanDaysOlder, tt.args.delete, tt.args.dryrun); (err != nil) != tt.wantErr {
+			err := tt.dbc.CleanBackupFunc(tt.args.lc, tt.args.CleanDaysOlder, tt.args.delete, tt.args.dryrun)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("DbConfig.CleanBackupFunc() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.name == "QueryFileDataScanFailed" && (err == nil || err.Error() != "failed to retrieve data on backup catalog entries to remove") {
+				t.Errorf("DbConfig.CleanBackupFunc() error = %v, want the catalog query to fail", err)
+			}
+			if (tt.name == "SafetyCheckNoRecentFull" || tt.name == "SafetyCheckLogGap" || tt.name == "SafetyCheckLogStartsAfterFull" || tt.name == "SafetyCheckNoLogBackups") && tt.dbc.Results.BackupSafetyFailure == "" {
+				t.Errorf("DbConfig.CleanBackupFunc() expected a backup safety failure reason")
+			}
+			if tt.name == "ExportCatalog" {
+				if _, err := os.Stat(tt.dbc.Results.BackupExportFile); err != nil {
+					t.Errorf("DbConfig.CleanBackupFunc() export file not written: %v", err)
+				}
+			}
+			if tt.name == "FilteredDelete" {
+				if tt.dbc.Results.BackupFilesBytesRemoved != 100000000 || len(tt.dbc.Results.BackupBytesByDestination) != 1 || tt.dbc.Results.BackupBytesByDestination["file"] != 100000000 {
+					t.Errorf("DbConfig.CleanBackupFunc() bytes removed = %d %v, want only 100000000 from file", tt.dbc.Results.BackupFilesBytesRemoved, tt.dbc.Results.BackupBytesByDestination)
+				}
+				if tt.dbc.Results.BackupFilesRemoved != 5 {
+					t.Errorf("DbConfig.CleanBackupFunc() backups removed = %d, want 5", tt.dbc.Results.BackupFilesRemoved)
+				}
+			}
+			if tt.name == "FilteredDeleteFailed" {
+				if tt.dbc.Results.BackupFilesRemoved != 1 || tt.dbc.Results.BackupFilesBytesRemoved != 60000000 || tt.dbc.Results.BackupBytesByDestination["file"] != 60000000 {
+					t.Errorf("DbConfig.CleanBackupFunc() removed %d backups, %d bytes %v, want 1 backup of 60000000 bytes from file", tt.dbc.Results.BackupFilesRemoved, tt.dbc.Results.BackupFilesBytesRemoved, tt.dbc.Results.BackupBytesByDestination)
+				}
+			}
+			if tt.name == "FilteredDeleteRetried" {
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+				if tt.dbc.Results.Retries != 1 || tt.dbc.Results.BackupFilesBytesRemoved != 60000000 {
+					t.Errorf("DbConfig.CleanBackupFunc() retries = %d, bytes removed = %d, want 1 and 60000000", tt.dbc.Results.Retries, tt.dbc.Results.BackupFilesBytesRemoved)
+				}
+			}
+			if tt.name == "BothModeCountOlder" || tt.name == "BothModeDaysOlder" {
+				if tt.dbc.Results.BackupAnchorID != "1000" {
+					t.Errorf("DbConfig.CleanBackupFunc() anchor = %s, want 1000", tt.dbc.Results.BackupAnchorID)
+				}
+			}
+			if tt.name == "TransientTruncationRetried" || tt.name == "FatalTruncationNotRetried" {
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			}
 		})
 	}
 }
@@ -308,40 +830,105 @@ func TestDbConfig_CleanAlertFunc(t *testing.T) {
 
 	/*tests*/
 	tests := []struct {
-		name    string
-		dbc     *DbConfig
-		args    args
-		wantErr bool
+		name      string
+		dbc       *DbConfig
+		args      args
+		wantErr   bool
+		removed   uint
+		batches   uint
+		remaining uint
+		byID      map[uint]uint
 	}{
-		{"Good01", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 14, false}, false},
-		{"DryRun", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 14, true}, false},
-		{"CountAlertsNoRows", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 14, false}, true},
-		{"CountAlertsDbError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 14, false}, true},
-		{"NothingToDo", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 14, false}, false},
-		{"CleanAlertsDbError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 14, false}, true},
+		{"Good01", testDbConfig(db1), args{lc, 14, false}, false, 250, 0, 0, map[uint]uint{21: 200, 45: 50}},
+		{"DryRun", testDbConfig(db1), args{lc, 14, true}, false, 0, 0, 0, nil},
+		{"CountAlertsNoRows", testDbConfig(db1), args{lc, 14, false}, true, 0, 0, 0, nil},
+		{"CountAlertsDbError", testDbConfig(db1), args{lc, 14, false}, true, 0, 0, 0, nil},
+		{"NothingToDo", testDbConfig(db1), args{lc, 14, false}, false, 0, 0, 0, nil},
+		{"CleanAlertsDbError", testDbConfig(db1), args{lc, 14, false}, true, 0, 0, 0, nil},
+		{"BatchRows", testDbConfig(db1, func(c *DbConfig) { c.Alerts = AlertConfig{BatchRows: 10000, MaxRunMinutes: 60} }), args{lc, 14, false}, false, 25000, 3, 0, map[uint]uint{21: 25000}},
+		{"SliceHours", testDbConfig(db1, func(c *DbConfig) { c.Alerts = AlertConfig{SliceHours: 24} }), args{lc, 14, false}, false, 300, 2, 0, map[uint]uint{21: 100, 45: 200}},
+		{"SliceHoursWithRules", testDbConfig(db1, func(c *DbConfig) {
+			c.Alerts = AlertConfig{SliceHours: 24, RetainDaysByRating: map[uint]uint{5: 3650}}
+		}), args{lc, 14, false}, false, 150, 2, 0, map[uint]uint{21: 150}},
+		{"BatchFails", testDbConfig(db1, func(c *DbConfig) { c.Alerts = AlertConfig{BatchRows: 10000} }), args{lc, 14, false}, true, 10000, 1, 15000, map[uint]uint{21: 10000}},
+		{"RetentionRules", testDbConfig(db1, func(c *DbConfig) {
+			c.Alerts = AlertConfig{RetainDaysByID: map[uint]uint{21: 365}, RetainDaysByRating: map[uint]uint{1: 7}}
+		}), args{lc, 14, false}, false, 80, 0, 0, map[uint]uint{3: 60, 21: 20}},
+		{"BreakdownFails", testDbConfig(db1), args{lc, 14, false}, true, 0, 0, 0, nil},
 	}
 	for _, tt := range tests {
 		/*Set up per case mocking*/
 		switch {
 		case tt.name == "Good01":
 			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("250")
-			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
-			mock.ExpectExec(GetAlertDelete(tt.args.CleanDaysOlder)).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 200).AddRow(45, 50))
+			mock.ExpectExec(GetAlertDelete(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnResult(sqlmock.NewResult(1, 1))
 		case tt.name == "DryRun":
 			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("250")
-			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 200).AddRow(45, 50))
 		case tt.name == "CountAlertsNoRows":
 			rows1 := sqlmock.NewRows([]string{"COUNT"})
-			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
 		case tt.name == "CountAlertsDbError":
-			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder)).WillReturnError(fmt.Errorf("some DB error"))
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnError(fmt.Errorf("some DB error"))
 		case tt.name == "NothingToDo":
 			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("0")
-			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
 		case tt.name == "CleanAlertsDbError":
 			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("250")
-			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
-			mock.ExpectExec(GetAlertDelete(tt.args.CleanDaysOlder)).WillReturnError(fmt.Errorf("some DB error"))
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 200).AddRow(45, 50))
+			mock.ExpectExec(GetAlertDelete(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnError(fmt.Errorf("some DB error"))
+		case tt.name == "BatchRows":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("25000")
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 25000))
+			for _, n := range []int64{10000, 10000, 5000} {
+				mock.ExpectBegin()
+				mock.ExpectExec(GetAlertDeleteBatch(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnResult(sqlmock.NewResult(0, n))
+				mock.ExpectCommit()
+			}
+		case tt.name == "SliceHours":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("300")
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 100).AddRow(45, 200))
+			for _, n := range []int64{200, 100} {
+				mock.ExpectBegin()
+				mock.ExpectExec(GetAlertDeleteSlice(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnResult(sqlmock.NewResult(0, n))
+				mock.ExpectCommit()
+			}
+		case tt.name == "SliceHoursWithRules":
+			/*The oldest alerts are critical ones kept for 3650 days, the slices start at the oldest expired alert*/
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("150")
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 150))
+			for _, n := range []int64{100, 50} {
+				mock.ExpectBegin()
+				mock.ExpectExec(GetAlertDeleteSlice(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnResult(sqlmock.NewResult(0, n))
+				mock.ExpectCommit()
+			}
+		case tt.name == "BatchFails":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("25000")
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 20000).AddRow(45, 5000))
+			mock.ExpectBegin()
+			mock.ExpectExec(GetAlertDeleteBatch(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnResult(sqlmock.NewResult(0, 10000))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(GetAlertDeleteBatch(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnError(fmt.Errorf("some DB error"))
+			mock.ExpectRollback()
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(21, 10000).AddRow(45, 5000))
+		case tt.name == "RetentionRules":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("80")
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(sqlmock.NewRows([]string{"ALERT_ID", "COUNT"}).AddRow(3, 60).AddRow(21, 20))
+			mock.ExpectExec(GetAlertDelete(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnResult(sqlmock.NewResult(0, 80))
+		case tt.name == "BreakdownFails":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("250")
+			mock.ExpectQuery(GetAlertCount(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetAlertCountByID(tt.args.CleanDaysOlder, tt.dbc.Alerts)).WillReturnError(fmt.Errorf("some DB error"))
 		default:
 			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
 		}
@@ -350,6 +937,13 @@ func TestDbConfig_CleanAlertFunc(t *testing.T) {
 			if err := tt.dbc.CleanAlertFunc(tt.args.lc, tt.args.CleanDaysOlder, tt.args.dryrun); (err != nil) != tt.wantErr {
 				t.Errorf("DbConfig.CleanAlertFunc() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			r := tt.dbc.Results
+			if r.AlertsRemoved != tt.removed || r.AlertBatches != tt.batches || r.AlertsRemaining != tt.remaining {
+				t.Errorf("DbConfig.CleanAlertFunc() results = %d, %d, %d, want %d, %d, %d", r.AlertsRemoved, r.AlertBatches, r.AlertsRemaining, tt.removed, tt.batches, tt.remaining)
+			}
+			if fmt.Sprint(r.AlertsRemovedByID) != fmt.Sprint(tt.byID) {
+				t.Errorf("DbConfig.CleanAlertFunc() removed by ID = %v, want %v", r.AlertsRemovedByID, tt.byID)
+			}
 		})
 	}
 	quit <- true
@@ -380,17 +974,35 @@ func TestDbConfig_CleanLogFunc(t *testing.T) {
 	}
 
 	/*Tests*/
+	/*Configuration shared by the test cases*/
+	normalMode := func(c *DbConfig) { c.LogVolume = LogVolumeConfig{RequireLogMode: "normal"} }
+	logBackupAge := func(c *DbConfig) { c.LogVolume = LogVolumeConfig{MaxLogBackupAgeHours: 4} }
+
 	tests := []struct {
-		name    string
-		dbc     *DbConfig
-		args    args
-		wantErr bool
+		name     string
+		dbc      *DbConfig
+		args     args
+		wantErr  bool
+		skipped  string
+		before   uint64
+		after    uint64
+		services int
 	}{
-		{"Good01", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, false},
-		{"DryRun", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, true}, false},
-		{"GetSegmentsNoRows", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, true},
-		{"GetSegmentsDbError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, true},
-		{"ReclaimDbError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, true},
+		{"Good01", testDbConfig(db1), args{lc, false}, false, "", 4294967296, 3221225472, 2},
+		{"DryRun", testDbConfig(db1), args{lc, true}, false, "", 4294967296, 0, 0},
+		{"GetSegmentsNoRows", testDbConfig(db1), args{lc, false}, true, "", 0, 0, 0},
+		{"GetSegmentsDbError", testDbConfig(db1), args{lc, false}, true, "", 0, 0, 0},
+		{"ReclaimDbError", testDbConfig(db1), args{lc, false}, true, "", 4294967296, 0, 0},
+		{"TooFewSegments", testDbConfig(db1, func(c *DbConfig) { c.LogVolume = LogVolumeConfig{MinFreeSegments: 20} }), args{lc, false}, false, "only 10 log segments are free, below 20", 4294967296, 0, 0},
+		{"TooLittleFree", testDbConfig(db1, func(c *DbConfig) { c.LogVolume = LogVolumeConfig{MinFreeMiB: 1024} }), args{lc, false}, false, "the free log segments hold 1.95MiB, below 1024MiB", 4294967296, 0, 0},
+		{"WrongLogMode", testDbConfig(db1, normalMode), args{lc, false}, false, "the log mode is overwrite, not normal", 4294967296, 0, 0},
+		{"LogBackupHealthy", testDbConfig(db1, func(c *DbConfig) { c.LogVolume = LogVolumeConfig{RequireLogMode: "normal", MaxLogBackupAgeHours: 4} }), args{lc, false}, false, "", 4294967296, 3221225472, 1},
+		{"LogBackupFailed", testDbConfig(db1, logBackupAge), args{lc, false}, false, "the latest log backup is failed", 4294967296, 0, 0},
+		{"LogBackupOld", testDbConfig(db1, logBackupAge), args{lc, false}, false, "the latest successful log backup is 6.0 hours old, above 4", 4294967296, 0, 0},
+		{"NoLogBackups", testDbConfig(db1, logBackupAge), args{lc, false}, false, "no finished log backup was found", 4294967296, 0, 0},
+		{"OverwriteSkipsBackups", testDbConfig(db1, logBackupAge), args{lc, false}, false, "", 4294967296, 3221225472, 0},
+		{"LogModeDbError", testDbConfig(db1, normalMode), args{lc, false}, true, "", 4294967296, 0, 0},
+		{"SizeAfterFails", testDbConfig(db1), args{lc, false}, false, "", 4294967296, 0, 1},
 	}
 	for _, tt := range tests {
 		/*Set up per case mocking*/
@@ -398,10 +1010,14 @@ func TestDbConfig_CleanLogFunc(t *testing.T) {
 		case tt.name == "Good01":
 			rows1 := sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000")
 			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetFreeLogSegmentsByService).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "COUNT", "BYTES"}).AddRow("hana01", 30003, 6, 1228800).AddRow("hana02", 30003, 4, 819200))
 			mock.ExpectExec(QUERY_ReclaimLog).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(3221225472))
 		case tt.name == "DryRun":
 			rows1 := sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000")
 			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
 		case tt.name == "GetSegmentsNoRows":
 			rows1 := sqlmock.NewRows([]string{"COUNT", "BYTES"})
 			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(rows1)
@@ -410,7 +1026,56 @@ func TestDbConfig_CleanLogFunc(t *testing.T) {
 		case tt.name == "ReclaimDbError":
 			rows1 := sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000")
 			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetFreeLogSegmentsByService).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "COUNT", "BYTES"}).AddRow("hana01", 30003, 10, 2048000))
 			mock.ExpectExec(QUERY_ReclaimLog).WillReturnError(fmt.Errorf("some DB error"))
+		case tt.name == "TooFewSegments" || tt.name == "TooLittleFree":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+		case tt.name == "WrongLogMode":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetLogMode).WillReturnRows(sqlmock.NewRows([]string{"VALUE"}).AddRow("overwrite"))
+		case tt.name == "LogBackupHealthy":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetLogMode).WillReturnRows(sqlmock.NewRows([]string{"VALUE"}).AddRow("normal"))
+			mock.ExpectQuery(QUERY_GetLatestLogBackup).WillReturnRows(sqlmock.NewRows([]string{"STATE_NAME", "AGE"}).AddRow("successful", 3600))
+			mock.ExpectQuery(QUERY_GetFreeLogSegmentsByService).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "COUNT", "BYTES"}).AddRow("hana01", 30003, 10, 2048000))
+			mock.ExpectExec(QUERY_ReclaimLog).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(3221225472))
+		case tt.name == "LogBackupFailed":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetLogMode).WillReturnRows(sqlmock.NewRows([]string{"VALUE"}))
+			mock.ExpectQuery(QUERY_GetLatestLogBackup).WillReturnRows(sqlmock.NewRows([]string{"STATE_NAME", "AGE"}).AddRow("failed", 3600))
+		case tt.name == "LogBackupOld":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetLogMode).WillReturnRows(sqlmock.NewRows([]string{"VALUE"}).AddRow("normal"))
+			mock.ExpectQuery(QUERY_GetLatestLogBackup).WillReturnRows(sqlmock.NewRows([]string{"STATE_NAME", "AGE"}).AddRow("successful", 21600))
+		case tt.name == "NoLogBackups":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetLogMode).WillReturnRows(sqlmock.NewRows([]string{"VALUE"}).AddRow("normal"))
+			mock.ExpectQuery(QUERY_GetLatestLogBackup).WillReturnRows(sqlmock.NewRows([]string{"STATE_NAME", "AGE"}))
+		case tt.name == "OverwriteSkipsBackups":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetLogMode).WillReturnRows(sqlmock.NewRows([]string{"VALUE"}).AddRow("overwrite"))
+			mock.ExpectQuery(QUERY_GetFreeLogSegmentsByService).WillReturnError(fmt.Errorf("some DB error"))
+			mock.ExpectExec(QUERY_ReclaimLog).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(3221225472))
+		case tt.name == "SizeAfterFails":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetFreeLogSegmentsByService).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "COUNT", "BYTES"}).AddRow("hana01", 30003, 10, 2048000))
+			mock.ExpectExec(QUERY_ReclaimLog).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnError(fmt.Errorf("some DB error"))
+		case tt.name == "LogModeDbError":
+			mock.ExpectQuery(QUERY_GetFeeLogSegments).WillReturnRows(sqlmock.NewRows([]string{"COUNT", "BYTES"}).AddRow("10", "2048000"))
+			mock.ExpectQuery(QUERY_GetLogVolumeSize).WillReturnRows(sqlmock.NewRows([]string{"BYTES"}).AddRow(4294967296))
+			mock.ExpectQuery(QUERY_GetLogMode).WillReturnError(fmt.Errorf("some DB error"))
 		default:
 			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
 		}
@@ -418,6 +1083,17 @@ func TestDbConfig_CleanLogFunc(t *testing.T) {
 			if err := tt.dbc.CleanLogFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
 				t.Errorf("DbConfig.CleanLogFunc() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			r := tt.dbc.Results
+			if r.LogReclaimSkipped != tt.skipped || r.LogVolumeBytesBefore != tt.before || r.LogVolumeBytesAfter != tt.after {
+				t.Errorf("DbConfig.CleanLogFunc() results = %q, %d, %d, want %q, %d, %d", r.LogReclaimSkipped, r.LogVolumeBytesBefore, r.LogVolumeBytesAfter, tt.skipped, tt.before, tt.after)
+			}
+			if len(r.LogSegmentsByService) != tt.services {
+				t.Errorf("DbConfig.CleanLogFunc() services = %v, want %d", r.LogSegmentsByService, tt.services)
+			}
+			/*A size of zero is never read from the mock, so it means the size wasn't measured*/
+			if r.LogVolumeMeasuredBefore != (tt.before != 0) || r.LogVolumeMeasuredAfter != (tt.after != 0) {
+				t.Errorf("DbConfig.CleanLogFunc() measured = %v, %v, want %v, %v", r.LogVolumeMeasuredBefore, r.LogVolumeMeasuredAfter, tt.before != 0, tt.after != 0)
+			}
 		})
 	}
 	quit <- true
@@ -441,6 +1117,18 @@ func TestDbConfig_CleanAuditFunc(t *testing.T) {
 
 	go Logger(AppConfig{"file", true, false, false}, lc, quit)
 
+	rules := []AuditRule{{Name: "Logins", PolicyName: "LOGIN_FAILURES", RetainDays: 365}, {Name: "Selects", EventAction: "SELECT", RetainDays: 7}}
+
+	archiveDir := t.TempDir()
+	mismatchDir := t.TempDir()
+	movedDir := t.TempDir()
+	auditRows := func() *sqlmock.Rows {
+		r := sqlmock.NewRows(AuditArchiveColumns)
+		r.AddRow("2021-12-30 09:00:00.000000000", "hana01", "30003", "indexserver", "200123", "client01", "10.0.0.1", "SYSTEM", "HDBStudio", "", "LOGIN_FAILURES", "UNSUCCESSFUL", "WARNING", "CONNECT", nil, nil, nil, nil, nil, nil, nil)
+		r.AddRow("2021-12-31 09:00:00.000000000", "hana01", "30003", "indexserver", "200124", "client01", "10.0.0.1", "SYSTEM", "HDBStudio", "", "LOGIN_FAILURES", "UNSUCCESSFUL", "WARNING", "CONNECT", nil, nil, nil, nil, nil, nil, nil)
+		return r
+	}
+
 	/*args*/
 	type args struct {
 		lc             chan<- LogMessage
@@ -449,22 +1137,47 @@ func TestDbConfig_CleanAuditFunc(t *testing.T) {
 	}
 
 	/*tests*/
+	/*Configuration shared by the test cases*/
+	archive := func(c *DbConfig) {
+		c.Name = "systemdb"
+		c.Audit = AuditConfig{ArchiveDir: archiveDir, ArchiveFormat: "jsonl"}
+	}
+	withRules := func(c *DbConfig) { c.Name = "systemdb"; c.Audit = AuditConfig{Rules: rules} }
+
 	tests := []struct {
 		name    string
 		dbc     *DbConfig
 		args    args
 		wantErr bool
 	}{
-		{"Good", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"DryRun", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, true}, false},
-		{"GoodOneRecordToDelete", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"GoodNothingToDelete", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, false},
-		{"CountEventsNoRows", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
-		{"CountEventsDbError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
-		{"GetDateNoRows", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
-		{"GetDateDbError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
-		{"GetDateWrongFormat", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
-		{"TruncateFailed", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, 60, false}, true},
+		{"Good", testDbConfig(db1), args{lc, 60, false}, false},
+		{"DryRun", testDbConfig(db1), args{lc, 60, true}, false},
+		{"GoodOneRecordToDelete", testDbConfig(db1), args{lc, 60, false}, false},
+		{"GoodNothingToDelete", testDbConfig(db1), args{lc, 60, false}, false},
+		{"CountEventsNoRows", testDbConfig(db1), args{lc, 60, false}, true},
+		{"CountEventsDbError", testDbConfig(db1), args{lc, 60, false}, true},
+		{"GetDateNoRows", testDbConfig(db1), args{lc, 60, false}, true},
+		{"GetDateDbError", testDbConfig(db1), args{lc, 60, false}, true},
+		{"GetDateWrongFormat", testDbConfig(db1), args{lc, 60, false}, true},
+		{"TruncateFailed", testDbConfig(db1), args{lc, 60, false}, true},
+		{"Archive", testDbConfig(db1, archive), args{lc, 60, false}, false},
+		{"ArchiveCountMismatch", testDbConfig(db1, func(c *DbConfig) {
+			c.Name = "systemdb"
+			c.Audit = AuditConfig{ArchiveDir: mismatchDir, ArchiveFormat: "jsonl"}
+		}), args{lc, 60, false}, true},
+		{"ArchiveCutoffMoved", testDbConfig(db1, func(c *DbConfig) {
+			c.Name = "systemdb"
+			c.Audit = AuditConfig{ArchiveDir: movedDir, ArchiveFormat: "jsonl"}
+		}), args{lc, 60, false}, false},
+		{"ArchiveQueryFailed", testDbConfig(db1, archive), args{lc, 60, false}, true},
+		{"ArchiveRecountFailed", testDbConfig(db1, archive), args{lc, 60, false}, true},
+		{"ArchiveMissingDir", testDbConfig(db1, func(c *DbConfig) {
+			c.Name = "systemdb"
+			c.Audit = AuditConfig{ArchiveDir: filepath.Join(archiveDir, "missing"), ArchiveFormat: "jsonl"}
+		}), args{lc, 60, false}, true},
+		{"RuleConstrains", testDbConfig(db1, withRules), args{lc, 60, false}, false},
+		{"RuleNotConstraining", testDbConfig(db1, withRules), args{lc, 60, false}, false},
+		{"RuleQueryFailed", testDbConfig(db1, withRules), args{lc, 60, false}, true},
 	}
 	for _, tt := range tests {
 		/*Set up per case mocking*/
@@ -514,6 +1227,65 @@ func TestDbConfig_CleanAuditFunc(t *testing.T) {
 			mock.ExpectQuery(GetAuditCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
 			mock.ExpectQuery(GetDatetime(tt.args.CleanDaysOlder)).WillReturnRows(rows2)
 			mock.ExpectExec(GetTruncateAuditLog("2022-01-01 10:00:00")).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "Archive":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("2")
+			rows2 := sqlmock.NewRows([]string{"NOW"}).AddRow("2022-01-01 10:00:00.431000000")
+			mock.ExpectQuery(GetAuditCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetDatetime(tt.args.CleanDaysOlder)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetAuditCountBefore("2022-01-01 10:00:00")).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow("2"))
+			mock.ExpectQuery(GetAuditArchive("2022-01-01 10:00:00")).WillReturnRows(auditRows())
+			mock.ExpectExec(GetTruncateAuditLog("2022-01-01 10:00:00")).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "ArchiveCountMismatch":
+			/*A record is missing from the archive so the log must not be cleared*/
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("3")
+			rows2 := sqlmock.NewRows([]string{"NOW"}).AddRow("2022-01-01 10:00:00.431000000")
+			mock.ExpectQuery(GetAuditCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetDatetime(tt.args.CleanDaysOlder)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetAuditCountBefore("2022-01-01 10:00:00")).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow("3"))
+			mock.ExpectQuery(GetAuditArchive("2022-01-01 10:00:00")).WillReturnRows(auditRows())
+		case tt.name == "ArchiveCutoffMoved":
+			/*Records written between the two counts are counted first, but are after the cutoff and are not archived*/
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("3")
+			rows2 := sqlmock.NewRows([]string{"NOW"}).AddRow("2022-01-01 10:00:00.431000000")
+			mock.ExpectQuery(GetAuditCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetDatetime(tt.args.CleanDaysOlder)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetAuditCountBefore("2022-01-01 10:00:00")).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow("2"))
+			mock.ExpectQuery(GetAuditArchive("2022-01-01 10:00:00")).WillReturnRows(auditRows())
+			mock.ExpectExec(GetTruncateAuditLog("2022-01-01 10:00:00")).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "ArchiveRecountFailed":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("2")
+			rows2 := sqlmock.NewRows([]string{"NOW"}).AddRow("2022-01-01 10:00:00.431000000")
+			mock.ExpectQuery(GetAuditCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetDatetime(tt.args.CleanDaysOlder)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetAuditCountBefore("2022-01-01 10:00:00")).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "ArchiveQueryFailed":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("2")
+			rows2 := sqlmock.NewRows([]string{"NOW"}).AddRow("2022-01-01 10:00:00.431000000")
+			mock.ExpectQuery(GetAuditCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetDatetime(tt.args.CleanDaysOlder)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetAuditCountBefore("2022-01-01 10:00:00")).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow("2"))
+			mock.ExpectQuery(GetAuditArchive("2022-01-01 10:00:00")).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "ArchiveMissingDir":
+			rows1 := sqlmock.NewRows([]string{"COUNT"}).AddRow("2")
+			rows2 := sqlmock.NewRows([]string{"NOW"}).AddRow("2022-01-01 10:00:00.431000000")
+			mock.ExpectQuery(GetAuditCount(tt.args.CleanDaysOlder)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetDatetime(tt.args.CleanDaysOlder)).WillReturnRows(rows2)
+			mock.ExpectQuery(GetAuditCountBefore("2022-01-01 10:00:00")).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow("2"))
+			mock.ExpectQuery(GetAuditArchive("2022-01-01 10:00:00")).WillReturnRows(auditRows())
+		case tt.name == "RuleConstrains":
+			/*The login rule protects 8 records the default would remove, so its retention is used*/
+			mock.ExpectQuery(GetAuditRuleCount(rules[0], 60)).WillReturnRows(sqlmock.NewRows([]string{"EXPIRED", "DEFAULT_EXPIRED"}).AddRow(2, 10))
+			mock.ExpectQuery(GetAuditRuleCount(rules[1], 60)).WillReturnRows(sqlmock.NewRows([]string{"EXPIRED", "DEFAULT_EXPIRED"}).AddRow(500, 300))
+			mock.ExpectQuery(GetAuditCount(365)).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow("5"))
+			mock.ExpectQuery(GetDatetime(365)).WillReturnRows(sqlmock.NewRows([]string{"NOW"}).AddRow("2021-01-01 10:00:00.431000000"))
+			mock.ExpectExec(GetTruncateAuditLog("2021-01-01 10:00:00")).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "RuleNotConstraining":
+			/*No login failures are older than the default retention*/
+			mock.ExpectQuery(GetAuditRuleCount(rules[0], 60)).WillReturnRows(sqlmock.NewRows([]string{"EXPIRED", "DEFAULT_EXPIRED"}).AddRow(0, 0))
+			mock.ExpectQuery(GetAuditRuleCount(rules[1], 60)).WillReturnRows(sqlmock.NewRows([]string{"EXPIRED", "DEFAULT_EXPIRED"}).AddRow(500, 300))
+			mock.ExpectQuery(GetAuditCount(60)).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow("0"))
+		case tt.name == "RuleQueryFailed":
+			mock.ExpectQuery(GetAuditRuleCount(rules[0], 60)).WillReturnError(fmt.Errorf("some db error"))
 		default:
 			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
 		}
@@ -521,6 +1293,24 @@ func TestDbConfig_CleanAuditFunc(t *testing.T) {
 			if err := tt.dbc.CleanAuditFunc(tt.args.lc, tt.args.CleanDaysOlder, tt.args.dryrun); (err != nil) != tt.wantErr {
 				t.Errorf("DbConfig.CleanAuditFunc() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			switch tt.name {
+			case "RuleConstrains":
+				if tt.dbc.Results.AuditRetainDays != 365 || tt.dbc.Results.AuditConstrainingRule != "Logins" || tt.dbc.Results.AuditRuleExpired["Selects"] != 500 {
+					t.Errorf("DbConfig.CleanAuditFunc() retention = %d by %s, want 365 by Logins", tt.dbc.Results.AuditRetainDays, tt.dbc.Results.AuditConstrainingRule)
+				}
+			case "RuleNotConstraining":
+				if tt.dbc.Results.AuditRetainDays != 60 || tt.dbc.Results.AuditConstrainingRule != "" {
+					t.Errorf("DbConfig.CleanAuditFunc() retention = %d by %s, want 60 by default", tt.dbc.Results.AuditRetainDays, tt.dbc.Results.AuditConstrainingRule)
+				}
+			case "Archive", "ArchiveCutoffMoved":
+				if tt.dbc.Results.AuditEntriesArchived != 2 || len(tt.dbc.Results.AuditArchiveFiles) != 2 {
+					t.Errorf("DbConfig.CleanAuditFunc() archived %d records to %d files, want 2 records in 2 files", tt.dbc.Results.AuditEntriesArchived, len(tt.dbc.Results.AuditArchiveFiles))
+				}
+			case "ArchiveCountMismatch":
+				if files, _ := os.ReadDir(mismatchDir); len(files) != 0 {
+					t.Errorf("DbConfig.CleanAuditFunc() left %d archive files behind", len(files))
+				}
+			}
 		})
 	}
 }
@@ -550,22 +1340,43 @@ func TestDbConfig_CleanDataVolumeFunc(t *testing.T) {
 	}
 
 	/*tests*/
+	/*Configuration shared by the test cases*/
+	noRunningBackup := func(c *DbConfig) { c.DataVolume = DataVolumeConfig{Gates: DataVolumeGates{NoRunningBackup: true}} }
+
 	tests := []struct {
 		name    string
 		dbc     *DbConfig
 		args    args
 		wantErr bool
 	}{
-		{"GoodClean", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, false},
-		{"GoodCleanPostCheckFails", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, false},
-		{"GoodNoCleanNeeded", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, false},
-		{"DataVolumeQueryFail", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, true},
-		{"NoDataVolumes", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, false},
-		{"ScanError", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, true},
-		{"SingleCleanFailed", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, true},
-		{"GoodCleanDryRun", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, true}, false},
-		{"GoodCleanTwoVolumes", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, false},
-		{"CleanTwoVolumesOneFails", &DbConfig{"", "", 30015, "", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc, false}, true},
+		{"GoodClean", testDbConfig(db1), args{lc, false}, false},
+		{"GoodCleanPostCheckFails", testDbConfig(db1), args{lc, false}, false},
+		{"GoodNoCleanNeeded", testDbConfig(db1), args{lc, false}, false},
+		{"DataVolumeQueryFail", testDbConfig(db1), args{lc, false}, true},
+		{"NoDataVolumes", testDbConfig(db1), args{lc, false}, false},
+		{"ScanError", testDbConfig(db1), args{lc, false}, true},
+		{"SingleCleanFailed", testDbConfig(db1), args{lc, false}, true},
+		{"GoodCleanDryRun", testDbConfig(db1), args{lc, true}, false},
+		{"GoodCleanTwoVolumes", testDbConfig(db1), args{lc, false}, false},
+		{"CleanTwoVolumesOneFails", testDbConfig(db1), args{lc, false}, true},
+		{"ConfiguredThresholds", testDbConfig(db1, func(c *DbConfig) {
+			c.DataVolume = DataVolumeConfig{FreeTriggerPercent: 20, ReclaimTargetPercent: 150, Volumes: []DataVolumeOverride{{"testhana:30044", 80, 0, 200}}}
+		}), args{lc, false}, false},
+		{"GateOutsideWindow", testDbConfig(db1, func(c *DbConfig) { c.DataVolume = DataVolumeConfig{Gates: DataVolumeGates{Window: "01:00-05:00"}} }), args{lc, false}, false},
+		{"GateBackupRunning", testDbConfig(db1, noRunningBackup), args{lc, false}, false},
+		{"GateHostBusy", testDbConfig(db1, func(c *DbConfig) {
+			c.DataVolume = DataVolumeConfig{Gates: DataVolumeGates{MaxCPUPercent: 80, MaxMemoryPercent: 90}}
+		}), args{lc, false}, false},
+		{"GateHostCPUBusy", testDbConfig(db1, func(c *DbConfig) { c.DataVolume = DataVolumeConfig{Gates: DataVolumeGates{MaxCPUPercent: 80}} }), args{lc, false}, false},
+		{"GateCPUNotSupported", testDbConfig(db1, func(c *DbConfig) {
+			c.DataVolume = DataVolumeConfig{Gates: DataVolumeGates{MaxCPUPercent: 80}}
+			c.Results.Version, _ = ParseHanaVersion("1.00.122.33.1604661230")
+		}), args{lc, false}, true},
+		{"GateSlowSavepoint", testDbConfig(db1, func(c *DbConfig) { c.DataVolume = DataVolumeConfig{Gates: DataVolumeGates{MaxSavepointSeconds: 10}} }), args{lc, false}, false},
+		{"GatesPassed", testDbConfig(db1, func(c *DbConfig) {
+			c.DataVolume = DataVolumeConfig{Gates: DataVolumeGates{Window: "01:00-05:00", MaxCPUPercent: 80, MaxMemoryPercent: 90, NoRunningBackup: true, MaxSavepointSeconds: 10}}
+		}), args{lc, false}, false},
+		{"GateQueryFailed", testDbConfig(db1, noRunningBackup), args{lc, false}, true},
 	}
 	for _, tt := range tests {
 		/*Set up per case mocking*/
@@ -574,12 +1385,12 @@ func TestDbConfig_CleanDataVolumeFunc(t *testing.T) {
 			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000")
 			rows2 := sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("1500000")
 			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
-			mock.ExpectExec(GetCleanDataVolume("testhana", 30040)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30040, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
 			mock.ExpectQuery(GetSpecificDataVolume("testhana", 30040)).WillReturnRows(rows2)
 		case tt.name == "GoodCleanPostCheckFails":
 			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000")
 			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
-			mock.ExpectExec(GetCleanDataVolume("testhana", 30040)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30040, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
 			mock.ExpectQuery(GetSpecificDataVolume("testhana", 30040)).WillReturnError(fmt.Errorf("some db error"))
 		case tt.name == "GoodNoCleanNeeded":
 			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "2000000", "3000000")
@@ -595,7 +1406,7 @@ func TestDbConfig_CleanDataVolumeFunc(t *testing.T) {
 		case tt.name == "SingleCleanFailed":
 			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000")
 			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
-			mock.ExpectExec(GetCleanDataVolume("testhana", 30040)).WillReturnError(fmt.Errorf("some Db error"))
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30040, 120)).WillReturnError(fmt.Errorf("some Db error"))
 		case tt.name == "GoodCleanDryRun":
 			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000")
 			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
@@ -604,18 +1415,73 @@ func TestDbConfig_CleanDataVolumeFunc(t *testing.T) {
 			rows2 := sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("2000000")
 			rows3 := sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("3000000")
 			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
-			mock.ExpectExec(GetCleanDataVolume("testhana", 30040)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30040, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
 			mock.ExpectQuery(GetSpecificDataVolume("testhana", 30040)).WillReturnRows(rows2)
-			mock.ExpectExec(GetCleanDataVolume("testhana", 30044)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30044, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
 			mock.ExpectQuery(GetSpecificDataVolume("testhana", 30044)).WillReturnRows(rows3)
 
 		case tt.name == "CleanTwoVolumesOneFails":
 			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000").AddRow("testhana", "30044", "2000000", "6000000")
 			rows2 := sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("2000000")
 			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
-			mock.ExpectExec(GetCleanDataVolume("testhana", 30040)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30040, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetSpecificDataVolume("testhana", 30040)).WillReturnRows(rows2)
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30044, 120)).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "ConfiguredThresholds":
+			/*30040 is 33% free, over the database trigger of 20%.  30044 is 66% free, under its own trigger of 80%*/
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "2000000", "3000000").AddRow("testhana", "30044", "2000000", "6000000")
+			rows2 := sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("2500000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30040, 150)).WillReturnResult(sqlmock.NewResult(0, 0))
 			mock.ExpectQuery(GetSpecificDataVolume("testhana", 30040)).WillReturnRows(rows2)
-			mock.ExpectExec(GetCleanDataVolume("testhana", 30044)).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "GateOutsideWindow":
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000").AddRow("testhana2", "30044", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetDbTimeOfDay).WillReturnRows(sqlmock.NewRows([]string{"NOW"}).AddRow("12:00"))
+		case tt.name == "GateBackupRunning":
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000").AddRow("testhana2", "30044", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetRunningDataBackups).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(1))
+		case tt.name == "GateHostBusy":
+			/*testhana is using 95% of its memory so only testhana2 is reclaimed*/
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000").AddRow("testhana2", "30044", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetHostCPULoad).WillReturnRows(sqlmock.NewRows([]string{"HOST", "CPU"}).AddRow("testhana", 10.0).AddRow("testhana2", 10.0))
+			mock.ExpectQuery(QUERY_GetHostResources).WillReturnRows(sqlmock.NewRows([]string{"HOST", "USED_PHYSICAL_MEMORY", "FREE_PHYSICAL_MEMORY"}).AddRow("testhana", 950, 50).AddRow("testhana2", 500, 500))
+			mock.ExpectExec(GetCleanDataVolume("testhana2", 30044, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetSpecificDataVolume("testhana2", 30044)).WillReturnRows(sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("1500000"))
+		case tt.name == "GateHostCPUBusy":
+			/*testhana last recorded 85% CPU so only testhana2 is reclaimed*/
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000").AddRow("testhana2", "30044", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetHostCPULoad).WillReturnRows(sqlmock.NewRows([]string{"HOST", "CPU"}).AddRow("testhana", 85.0).AddRow("testhana2", 20.0))
+			mock.ExpectExec(GetCleanDataVolume("testhana2", 30044, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetSpecificDataVolume("testhana2", 30044)).WillReturnRows(sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("1500000"))
+		case tt.name == "GateCPUNotSupported":
+			/*M_LOAD_HISTORY_HOST is not available on HANA 1.0 so the gate can't be checked*/
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+		case tt.name == "GateSlowSavepoint":
+			/*testhana2:30044 had a 12 second savepoint so only testhana is reclaimed*/
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000").AddRow("testhana2", "30044", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetMaxSavepointDuration).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "DURATION"}).AddRow("testhana", 30040, 2000000).AddRow("testhana2", 30044, 12000000))
+			mock.ExpectExec(GetCleanDataVolume("testhana", 30040, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetSpecificDataVolume("testhana", 30040)).WillReturnRows(sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("1500000"))
+		case tt.name == "GatesPassed":
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana2", "30044", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetDbTimeOfDay).WillReturnRows(sqlmock.NewRows([]string{"NOW"}).AddRow("02:00"))
+			mock.ExpectQuery(QUERY_GetRunningDataBackups).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(0))
+			mock.ExpectQuery(QUERY_GetHostCPULoad).WillReturnRows(sqlmock.NewRows([]string{"HOST", "CPU"}).AddRow("testhana", 10.0).AddRow("testhana2", 10.0))
+			mock.ExpectQuery(QUERY_GetHostResources).WillReturnRows(sqlmock.NewRows([]string{"HOST", "USED_PHYSICAL_MEMORY", "FREE_PHYSICAL_MEMORY"}).AddRow("testhana", 950, 50).AddRow("testhana2", 500, 500))
+			mock.ExpectQuery(QUERY_GetMaxSavepointDuration).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "DURATION"}).AddRow("testhana2", 30044, 2000000))
+			mock.ExpectExec(GetCleanDataVolume("testhana2", 30044, 120)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetSpecificDataVolume("testhana2", 30044)).WillReturnRows(sqlmock.NewRows([]string{"TOTAL_SIZE"}).AddRow("1500000"))
+		case tt.name == "GateQueryFailed":
+			rows1 := sqlmock.NewRows([]string{"HOST", "PORT", "USED_SIZE", "TOTAL_SIZE"}).AddRow("testhana", "30040", "1000000", "3000000").AddRow("testhana2", "30044", "1000000", "3000000")
+			mock.ExpectQuery(QUERY_GetDataVolume).WillReturnRows(rows1)
+			mock.ExpectQuery(QUERY_GetRunningDataBackups).WillReturnError(fmt.Errorf("some db error"))
 		default:
 			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
 		}
@@ -623,11 +1489,17 @@ func TestDbConfig_CleanDataVolumeFunc(t *testing.T) {
 			if err := tt.dbc.CleanDataVolumeFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
 				t.Errorf("DbConfig.CleanDataVolumeFunc() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.name == "GoodCleanTwoVolumes" {
+				want := map[string]uint64{"testhana:30040": 1000000, "testhana:30044": 3000000}
+				if !reflect.DeepEqual(tt.dbc.Results.DataVolumeByService, want) {
+					t.Errorf("DbConfig.CleanDataVolumeFunc() by service = %v, want %v", tt.dbc.Results.DataVolumeByService, want)
+				}
+			}
 		})
 	}
 }
 
-func TestDbConfig_CheckPrivileges(t *testing.T) {
+func TestDbConfig_OptimiseTablesFunc(t *testing.T) {
 	/*Test Setup*/
 	/*Mock DB*/
 	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -645,64 +1517,760 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 
 	go Logger(AppConfig{"file", true, false, false}, lc, quit)
 
+	/*args*/
 	type args struct {
-		lc chan<- LogMessage
+		lc     chan<- LogMessage
+		dryrun bool
 	}
+
+	/*tests*/
+	/*Configuration shared by the test cases*/
+	deltaOnly := func(c *DbConfig) { c.TableOptimise = TableOptimiseConfig{Enabled: true, DeltaTriggerMiB: 1} }
+	compression := func(c *DbConfig) {
+		c.TableOptimise = TableOptimiseConfig{Enabled: true, DeltaTriggerMiB: 1, CompressionTriggerPercent: 50, MinTableMiB: 1}
+	}
+
 	tests := []struct {
-		name    string
-		dbc     *DbConfig
-		args    args
-		wantErr bool
+		name      string
+		dbc       *DbConfig
+		args      args
+		wantErr   bool
+		optimised int
+		deferred  uint
 	}{
-		{"NothingMissing", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, false},
-		{"NoMonitoring", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoTraceAdmin", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoBackupAdmin", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoLogAdmin", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoAuditOperator", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoResourceAdmin", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoSelectAlerts", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoDeleteAlerts", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"NoRows", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"DbError", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"WrongBool", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
-		{"MissingPriv", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{lc}, true},
+		{"GoodMerge", testDbConfig(db1, deltaOnly), args{lc, false}, false, 1, 0},
+		{"GoodMergeAndCompress", testDbConfig(db1, compression), args{lc, false}, false, 1, 0},
+		{"NothingToDo", testDbConfig(db1, compression), args{lc, false}, false, 0, 0},
+		{"DryRun", testDbConfig(db1, compression), args{lc, true}, false, 0, 0},
+		{"MaxTables", testDbConfig(db1, func(c *DbConfig) {
+			c.TableOptimise = TableOptimiseConfig{Enabled: true, DeltaTriggerMiB: 1, MaxTables: 1}
+		}), args{lc, false}, false, 1, 1},
+		{"SchemaFilters", testDbConfig(db1, func(c *DbConfig) {
+			c.TableOptimise = TableOptimiseConfig{Enabled: true, DeltaTriggerMiB: 1, IncludeSchemas: []string{"APP"}, ExcludeSchemas: []string{"APP_ARCHIVE"}}
+		}), args{lc, false}, false, 1, 0},
+		{"MergeFails", testDbConfig(db1, compression), args{lc, false}, true, 1, 0},
+		{"SizeCheckFails", testDbConfig(db1, deltaOnly), args{lc, false}, false, 1, 0},
+		{"QueryFail", testDbConfig(db1, deltaOnly), args{lc, false}, true, 0, 0},
+		{"ScanError", testDbConfig(db1, deltaOnly), args{lc, false}, true, 0, 0},
 	}
 	for _, tt := range tests {
 		/*Set up per case mocking*/
 		switch {
-		case tt.name == "NothingMissing":
-			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
-			rows1.AddRow("MONITORING", "TRUE")
-			rows1.AddRow("TRACE_ADMIN", "TRUE")
-			rows1.AddRow("BACKUP_ADMIN", "TRUE")
-			rows1.AddRow("LOG_ADMIN", "TRUE")
-			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
-			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
-			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
-			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
-			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
-		case tt.name == "NoMonitoring":
-			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
-			rows1.AddRow("MONITORING", "FALSE")
-			rows1.AddRow("TRACE_ADMIN", "TRUE")
-			rows1.AddRow("BACKUP_ADMIN", "TRUE")
-			rows1.AddRow("LOG_ADMIN", "TRUE")
-			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
-			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
-			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
-			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
-			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
-		case tt.name == "NoTraceAdmin":
-			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
-			rows1.AddRow("MONITORING", "TRUE")
-			rows1.AddRow("TRACE_ADMIN", "FALSE")
-			rows1.AddRow("BACKUP_ADMIN", "TRUE")
-			rows1.AddRow("LOG_ADMIN", "TRUE")
-			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
-			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
-			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
-			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+		case tt.name == "GoodMerge":
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", 50000000, 2000000, 100000000).AddRow("APP", "SMALL", 1000, 1000, 2000))
+			mock.ExpectExec(GetMergeDelta("APP", "ORDERS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetCsTableSize("APP", "ORDERS")).WillReturnRows(sqlmock.NewRows([]string{"SIZE"}).AddRow(50500000))
+		case tt.name == "GoodMergeAndCompress":
+			/*ORDERS is 80% of its uncompressed size and has a large delta, CUSTOMERS is well compressed with a small delta*/
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", 80000000, 2000000, 100000000).AddRow("APP", "CUSTOMERS", 20000000, 1000, 100000000))
+			mock.ExpectExec(GetMergeDelta("APP", "ORDERS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetOptimiseCompression("APP", "ORDERS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetCsTableSize("APP", "ORDERS")).WillReturnRows(sqlmock.NewRows([]string{"SIZE"}).AddRow(40000000))
+		case tt.name == "NothingToDo":
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "CUSTOMERS", 20000000, 1000, 100000000))
+		case tt.name == "DryRun":
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", 80000000, 2000000, 100000000))
+		case tt.name == "MaxTables":
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", 50000000, 2000000, 100000000).AddRow("APP", "ITEMS", 40000000, 2000000, 100000000))
+			mock.ExpectExec(GetMergeDelta("APP", "ORDERS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetCsTableSize("APP", "ORDERS")).WillReturnRows(sqlmock.NewRows([]string{"SIZE"}).AddRow(50500000))
+		case tt.name == "SchemaFilters":
+			mock.ExpectQuery(GetCsTables([]string{"APP"}, []string{"APP_ARCHIVE"})).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", 50000000, 2000000, 100000000))
+			mock.ExpectExec(GetMergeDelta("APP", "ORDERS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetCsTableSize("APP", "ORDERS")).WillReturnRows(sqlmock.NewRows([]string{"SIZE"}).AddRow(50500000))
+		case tt.name == "MergeFails":
+			/*The merge of ORDERS fails so its compression is not attempted, ITEMS is still optimised*/
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", 80000000, 2000000, 100000000).AddRow("APP", "ITEMS", 60000000, 2000000, 100000000))
+			mock.ExpectExec(GetMergeDelta("APP", "ORDERS")).WillReturnError(fmt.Errorf("some db error"))
+			mock.ExpectExec(GetMergeDelta("APP", "ITEMS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetOptimiseCompression("APP", "ITEMS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetCsTableSize("APP", "ITEMS")).WillReturnRows(sqlmock.NewRows([]string{"SIZE"}).AddRow(30000000))
+		case tt.name == "SizeCheckFails":
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", 50000000, 2000000, 100000000))
+			mock.ExpectExec(GetMergeDelta("APP", "ORDERS")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetCsTableSize("APP", "ORDERS")).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "QueryFail":
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "ScanError":
+			mock.ExpectQuery(GetCsTables(nil, nil)).WillReturnRows(sqlmock.NewRows([]string{"SCHEMA_NAME", "TABLE_NAME", "MAIN_SIZE", "DELTA_SIZE", "UNCOMPRESSED_SIZE"}).AddRow("APP", "ORDERS", "lots", 2000000, 100000000))
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.OptimiseTablesFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.OptimiseTablesFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tt.dbc.Results.TablesOptimised) != tt.optimised {
+				t.Errorf("DbConfig.OptimiseTablesFunc() optimised %d tables, want %d", len(tt.dbc.Results.TablesOptimised), tt.optimised)
+			}
+			if tt.dbc.Results.TablesDeferred != tt.deferred {
+				t.Errorf("DbConfig.OptimiseTablesFunc() deferred %d tables, want %d", tt.dbc.Results.TablesDeferred, tt.deferred)
+			}
+		})
+	}
+}
+
+func TestDbConfig_DisconnectIdleSessionsFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	/*args*/
+	type args struct {
+		lc     chan<- LogMessage
+		dryrun bool
+	}
+
+	/*tests*/
+	/*Configuration shared by the test cases*/
+	idle := func(c *DbConfig) { c.IdleSessions = IdleSessionConfig{Enabled: true, IdleMinutes: 60} }
+
+	tests := []struct {
+		name         string
+		dbc          *DbConfig
+		args         args
+		wantErr      bool
+		disconnected uint
+	}{
+		{"GoodDisconnect", testDbConfig(db1, testIdentity, idle), args{lc, false}, false, 2},
+		{"FilteredDisconnect", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.IdleSessions = IdleSessionConfig{Enabled: true, IdleMinutes: 120, Users: []string{"DEVUSER"}, Applications: []string{"HDBStudio"}, ClientHosts: []string{"devpc01"}, ProtectedUsers: []string{"SYSTEM"}}
+		}), args{lc, false}, false, 1},
+		{"NoIdleSessions", testDbConfig(db1, testIdentity, idle), args{lc, false}, false, 0},
+		{"DryRun", testDbConfig(db1, testIdentity, idle), args{lc, true}, false, 0},
+		{"DisconnectFails", testDbConfig(db1, testIdentity, idle), args{lc, false}, true, 1},
+		{"QueryFail", testDbConfig(db1, testIdentity, idle), args{lc, false}, true, 0},
+		{"ScanError", testDbConfig(db1, testIdentity, idle), args{lc, false}, true, 0},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		switch {
+		case tt.name == "GoodDisconnect":
+			mock.ExpectQuery(GetIdleSessions(60, nil, nil, nil, []string{"HCCADMIN"})).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID", "USER_NAME", "APPLICATION", "CLIENT_HOST", "IDLE_TIME"}).AddRow(300123, "DEVUSER", "HDBStudio", "devpc01", 7200000).AddRow(300456, "APPUSER", "", "apphost01", 3700000))
+			mock.ExpectExec(GetDisconnectSession(300123)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetDisconnectSession(300456)).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "FilteredDisconnect":
+			mock.ExpectQuery(GetIdleSessions(120, []string{"DEVUSER"}, []string{"HDBStudio"}, []string{"devpc01"}, []string{"HCCADMIN", "SYSTEM"})).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID", "USER_NAME", "APPLICATION", "CLIENT_HOST", "IDLE_TIME"}).AddRow(300123, "DEVUSER", "HDBStudio", "devpc01", 7500000))
+			mock.ExpectExec(GetDisconnectSession(300123)).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "NoIdleSessions":
+			mock.ExpectQuery(GetIdleSessions(60, nil, nil, nil, []string{"HCCADMIN"})).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID", "USER_NAME", "APPLICATION", "CLIENT_HOST", "IDLE_TIME"}))
+		case tt.name == "DryRun":
+			mock.ExpectQuery(GetIdleSessions(60, nil, nil, nil, []string{"HCCADMIN"})).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID", "USER_NAME", "APPLICATION", "CLIENT_HOST", "IDLE_TIME"}).AddRow(300123, "DEVUSER", "HDBStudio", "devpc01", 7200000))
+		case tt.name == "DisconnectFails":
+			/*The first session ended before it could be disconnected*/
+			mock.ExpectQuery(GetIdleSessions(60, nil, nil, nil, []string{"HCCADMIN"})).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID", "USER_NAME", "APPLICATION", "CLIENT_HOST", "IDLE_TIME"}).AddRow(300123, "DEVUSER", "HDBStudio", "devpc01", 7200000).AddRow(300456, "APPUSER", "", "apphost01", 3700000))
+			mock.ExpectExec(GetDisconnectSession(300123)).WillReturnError(fmt.Errorf("some db error"))
+			mock.ExpectExec(GetDisconnectSession(300456)).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "QueryFail":
+			mock.ExpectQuery(GetIdleSessions(60, nil, nil, nil, []string{"HCCADMIN"})).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "ScanError":
+			mock.ExpectQuery(GetIdleSessions(60, nil, nil, nil, []string{"HCCADMIN"})).WillReturnRows(sqlmock.NewRows([]string{"CONNECTION_ID", "USER_NAME", "APPLICATION", "CLIENT_HOST", "IDLE_TIME"}).AddRow("abc", "DEVUSER", "HDBStudio", "devpc01", 7200000))
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.DisconnectIdleSessionsFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.DisconnectIdleSessionsFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.dbc.Results.SessionsDisconnected != tt.disconnected {
+				t.Errorf("DbConfig.DisconnectIdleSessionsFunc() disconnected %d sessions, want %d", tt.dbc.Results.SessionsDisconnected, tt.disconnected)
+			}
+		})
+	}
+}
+
+func TestDbConfig_ResetMonitoringViewsFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	/*args*/
+	type args struct {
+		lc     chan<- LogMessage
+		dryrun bool
+	}
+
+	/*tests*/
+	/*Configuration shared by the test cases*/
+	reset := func(c *DbConfig) { c.MonitoringReset = MonitoringResetConfig{Enabled: true} }
+	/*Rows of GetMonitoringViewResetColumns for views that have a RESET_TIME column*/
+	withResetTime := func(views ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"VIEW_NAME", "HAS_RESET_TIME"})
+		for _, v := range views {
+			rows.AddRow(v, 1)
+		}
+		return rows
+	}
+	defaultViews := GetMonitoringViewResetColumns(DefaultMonitoringResetViews)
+
+	tests := []struct {
+		name    string
+		dbc     *DbConfig
+		args    args
+		wantErr bool
+		views   uint
+		rows    uint
+	}{
+		{"GoodResetDefaultViews", testDbConfig(db1, testIdentity, reset), args{lc, false}, false, 2, 3500},
+		{"Thresholds", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.MonitoringReset = MonitoringResetConfig{Enabled: true, Views: []string{"M_CS_UNLOADS_RESET", "M_OBJECT_LOCK_STATISTICS_RESET", "M_SQL_PLAN_STATISTICS_RESET"}, MinRows: 1000, MinAgeDays: 30}
+		}), args{lc, false}, false, 1, 2500},
+		{"EmptyView", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.MonitoringReset = MonitoringResetConfig{Enabled: true, Views: []string{"M_CS_UNLOADS_RESET"}}
+		}), args{lc, false}, false, 0, 0},
+		{"DryRun", testDbConfig(db1, testIdentity, reset), args{lc, true}, false, 0, 0},
+		{"ViewMissing", testDbConfig(db1, testIdentity, reset), args{lc, false}, true, 1, 1000},
+		{"ResetFails", testDbConfig(db1, testIdentity, reset), args{lc, false}, true, 1, 1000},
+		{"NoResetTime", testDbConfig(db1, testIdentity, reset), args{lc, false}, false, 1, 2500},
+		{"ColumnQueryFails", testDbConfig(db1, testIdentity, reset), args{lc, false}, true, 0, 0},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		switch {
+		case tt.name == "GoodResetDefaultViews":
+			mock.ExpectQuery(defaultViews).WillReturnRows(withResetTime(DefaultMonitoringResetViews...))
+			mock.ExpectQuery(GetMonitoringViewStats("M_CS_UNLOADS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(1000, 90))
+			mock.ExpectExec(GetResetMonitoringView("M_CS_UNLOADS_RESET")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetMonitoringViewStats("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(2500, 10))
+			mock.ExpectExec(GetResetMonitoringView("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "Thresholds":
+			/*Unloads are too recent and plan statistics too small to reset*/
+			mock.ExpectQuery(GetMonitoringViewResetColumns(tt.dbc.MonitoringReset.Views)).WillReturnRows(withResetTime(tt.dbc.MonitoringReset.Views...))
+			mock.ExpectQuery(GetMonitoringViewStats("M_CS_UNLOADS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(5000, 10))
+			mock.ExpectQuery(GetMonitoringViewStats("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(2500, 45))
+			mock.ExpectExec(GetResetMonitoringView("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(GetMonitoringViewStats("M_SQL_PLAN_STATISTICS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(200, 400))
+		case tt.name == "EmptyView":
+			mock.ExpectQuery(GetMonitoringViewResetColumns(tt.dbc.MonitoringReset.Views)).WillReturnRows(withResetTime("M_CS_UNLOADS_RESET"))
+			mock.ExpectQuery(GetMonitoringViewStats("M_CS_UNLOADS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(0, 0))
+		case tt.name == "DryRun":
+			mock.ExpectQuery(defaultViews).WillReturnRows(withResetTime(DefaultMonitoringResetViews...))
+			mock.ExpectQuery(GetMonitoringViewStats("M_CS_UNLOADS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(1000, 90))
+			mock.ExpectQuery(GetMonitoringViewStats("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(2500, 10))
+		case tt.name == "ViewMissing":
+			mock.ExpectQuery(defaultViews).WillReturnRows(withResetTime("M_OBJECT_LOCK_STATISTICS_RESET"))
+			mock.ExpectQuery(GetMonitoringViewStats("M_CS_UNLOADS_RESET")).WillReturnError(fmt.Errorf("invalid table name"))
+			mock.ExpectQuery(GetMonitoringViewStats("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(1000, 10))
+			mock.ExpectExec(GetResetMonitoringView("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "ResetFails":
+			mock.ExpectQuery(defaultViews).WillReturnRows(withResetTime(DefaultMonitoringResetViews...))
+			mock.ExpectQuery(GetMonitoringViewStats("M_CS_UNLOADS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(1000, 90))
+			mock.ExpectExec(GetResetMonitoringView("M_CS_UNLOADS_RESET")).WillReturnError(fmt.Errorf("some db error"))
+			mock.ExpectQuery(GetMonitoringViewStats("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(1000, 10))
+			mock.ExpectExec(GetResetMonitoringView("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "NoResetTime":
+			/*M_CS_UNLOADS_RESET has no RESET_TIME column so it is skipped without reading it*/
+			mock.ExpectQuery(defaultViews).WillReturnRows(sqlmock.NewRows([]string{"VIEW_NAME", "HAS_RESET_TIME"}).AddRow("M_CS_UNLOADS_RESET", 0).AddRow("M_OBJECT_LOCK_STATISTICS_RESET", 1))
+			mock.ExpectQuery(GetMonitoringViewStats("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnRows(sqlmock.NewRows([]string{"ROW_COUNT", "AGE_DAYS"}).AddRow(2500, 90))
+			mock.ExpectExec(GetResetMonitoringView("M_OBJECT_LOCK_STATISTICS_RESET")).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "ColumnQueryFails":
+			mock.ExpectQuery(defaultViews).WillReturnError(fmt.Errorf("some db error"))
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.ResetMonitoringViewsFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.ResetMonitoringViewsFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.dbc.Results.MonitoringViewsReset != tt.views || tt.dbc.Results.MonitoringRowsReset != tt.rows {
+				t.Errorf("DbConfig.ResetMonitoringViewsFunc() reset %d views and %d rows, want %d and %d", tt.dbc.Results.MonitoringViewsReset, tt.dbc.Results.MonitoringRowsReset, tt.views, tt.rows)
+			}
+		})
+	}
+}
+
+func TestDbConfig_ResetTraceLevelsFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	/*args*/
+	type args struct {
+		lc     chan<- LogMessage
+		dryrun bool
+	}
+
+	/*Settings found on each layer*/
+	system := TraceLevelSetting{"indexserver.ini", "SYSTEM", "", "api", "debug", -1}
+	database := TraceLevelSetting{"indexserver.ini", "DATABASE", "", "sqloptimizer", "debug", -1}
+	host := TraceLevelSetting{"nameserver.ini", "HOST", "hana01", "topology", "debug", -1}
+
+	/*tests*/
+	/*Configuration shared by the test cases*/
+	enabled := func(c *DbConfig) { c.TraceLevels = TraceLevelConfig{Enabled: true} }
+	baseline := func(c *DbConfig) {
+		c.TraceLevels = TraceLevelConfig{Enabled: true, Baseline: "warning", GraceHours: 24, Files: []string{"indexserver.ini"}}
+	}
+
+	tests := []struct {
+		name    string
+		dbc     *DbConfig
+		args    args
+		wantErr bool
+		reset   int
+	}{
+		{"GoodReset", testDbConfig(db1, testIdentity, enabled), args{lc, false}, false, 3},
+		{"GraceAndFiles", testDbConfig(db1, testIdentity, baseline), args{lc, false}, false, 2},
+		{"GraceOldVersion", testDbConfig(db1, testIdentity, baseline), args{lc, false}, true, 0},
+		{"NothingElevated", testDbConfig(db1, testIdentity, enabled), args{lc, false}, false, 0},
+		{"DryRun", testDbConfig(db1, testIdentity, enabled), args{lc, true}, false, 0},
+		{"UnsetFails", testDbConfig(db1, testIdentity, enabled), args{lc, false}, true, 1},
+		{"QueryFail", testDbConfig(db1, testIdentity, enabled), args{lc, false}, true, 0},
+		{"ScanError", testDbConfig(db1, testIdentity, enabled), args{lc, false}, true, 0},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		debug := []string{"debug"}
+		switch {
+		case tt.name == "GoodReset":
+			mock.ExpectQuery(GetElevatedTraceLevels(debug, nil, false)).WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "LAYER_NAME", "HOST", "KEY", "VALUE", "AGE_HOURS"}).AddRow("indexserver.ini", "SYSTEM", "", "api", "debug", -1).AddRow("indexserver.ini", "DATABASE", "", "sqloptimizer", "debug", -1).AddRow("nameserver.ini", "HOST", "hana01", "topology", "debug", -1))
+			mock.ExpectExec(GetUnsetTraceLevel(system)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetUnsetTraceLevel(database)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetUnsetTraceLevel(host)).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "GraceAndFiles":
+			/*api was changed 2 hours ago so is within the grace period, sqloptimizer has no history*/
+			mock.ExpectQuery(GetElevatedTraceLevels([]string{"info", "debug"}, []string{"indexserver.ini"}, true)).WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "LAYER_NAME", "HOST", "KEY", "VALUE", "AGE_HOURS"}).AddRow("indexserver.ini", "SYSTEM", "", "api", "debug", 2).AddRow("indexserver.ini", "SYSTEM", "", "join", "info", 48).AddRow("indexserver.ini", "DATABASE", "", "sqloptimizer", "debug", -1))
+			mock.ExpectExec(GetUnsetTraceLevel(TraceLevelSetting{"indexserver.ini", "SYSTEM", "", "join", "info", 48})).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetUnsetTraceLevel(database)).WillReturnResult(sqlmock.NewResult(0, 0))
+		case tt.name == "GraceOldVersion":
+			/*M_INIFILE_CONTENT_HISTORY is not available before HANA 2.0 SPS03 so nothing is queried*/
+			tt.dbc.Results.Version, _ = ParseHanaVersion("2.00.024.00.1539187200")
+		case tt.name == "NothingElevated":
+			mock.ExpectQuery(GetElevatedTraceLevels(debug, nil, false)).WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "LAYER_NAME", "HOST", "KEY", "VALUE", "AGE_HOURS"}))
+		case tt.name == "DryRun":
+			mock.ExpectQuery(GetElevatedTraceLevels(debug, nil, false)).WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "LAYER_NAME", "HOST", "KEY", "VALUE", "AGE_HOURS"}).AddRow("indexserver.ini", "SYSTEM", "", "api", "debug", -1))
+		case tt.name == "UnsetFails":
+			mock.ExpectQuery(GetElevatedTraceLevels(debug, nil, false)).WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "LAYER_NAME", "HOST", "KEY", "VALUE", "AGE_HOURS"}).AddRow("indexserver.ini", "SYSTEM", "", "api", "debug", -1).AddRow("nameserver.ini", "HOST", "hana01", "topology", "debug", -1))
+			mock.ExpectExec(GetUnsetTraceLevel(system)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetUnsetTraceLevel(host)).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "QueryFail":
+			mock.ExpectQuery(GetElevatedTraceLevels(debug, nil, false)).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "ScanError":
+			mock.ExpectQuery(GetElevatedTraceLevels(debug, nil, false)).WillReturnRows(sqlmock.NewRows([]string{"FILE_NAME", "LAYER_NAME", "HOST", "KEY", "VALUE", "AGE_HOURS"}).AddRow("indexserver.ini", "SYSTEM", "", "api", "debug", "recent"))
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.ResetTraceLevelsFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.ResetTraceLevelsFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(tt.dbc.Results.TraceLevelsReset) != tt.reset {
+				t.Errorf("DbConfig.ResetTraceLevelsFunc() reset %d trace levels, want %d", len(tt.dbc.Results.TraceLevelsReset), tt.reset)
+			}
+		})
+	}
+}
+
+func TestDbConfig_CleanPlanCacheFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	/*args*/
+	type args struct {
+		lc     chan<- LogMessage
+		dryrun bool
+	}
+
+	/*tests*/
+	/*Configuration shared by the test cases*/
+	maxUsage := func(c *DbConfig) { c.PlanCache = PlanCacheConfig{Enabled: true, MaxUsagePercent: 80} }
+
+	tests := []struct {
+		name    string
+		dbc     *DbConfig
+		args    args
+		wantErr bool
+		reason  string
+		removed uint
+		before  uint64
+		after   uint64
+	}{
+		{"ClearOverUsage", testDbConfig(db1, testIdentity, maxUsage), args{lc, false}, false, "hana01:30003 plan cache is 90% full", 0, 1800000000, 400000000},
+		{"ClearOverEvictions", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.PlanCache = PlanCacheConfig{Enabled: true, MaxEvictionsPerHour: 100, ClearResultCache: true}
+		}), args{lc, false}, false, "hana01:30003 is evicting 250 plans per hour", 0, 1000000000, 200000000},
+		{"WithinLimits", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.PlanCache = PlanCacheConfig{Enabled: true, MaxUsagePercent: 80, MaxSizeMiB: 2048, MaxEvictionsPerHour: 100}
+		}), args{lc, false}, false, "", 0, 1000000000, 0},
+		{"FilteredRemove", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.PlanCache = PlanCacheConfig{Enabled: true, MaxSizeMiB: 1024, Schemas: []string{"APP"}, Users: []string{"APPUSER"}}
+		}), args{lc, false}, false, "hana01:30003 plan cache is 1716.61MiB", 2, 1800000000, 1700000000},
+		{"DryRun", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.PlanCache = PlanCacheConfig{Enabled: true, MaxUsagePercent: 80, ClearResultCache: true}
+		}), args{lc, true}, false, "", 0, 1800000000, 0},
+		{"ClearFails", testDbConfig(db1, testIdentity, maxUsage), args{lc, false}, true, "", 0, 1800000000, 0},
+		{"AfterCheckFails", testDbConfig(db1, testIdentity, maxUsage), args{lc, false}, false, "hana01:30003 plan cache is 90% full", 0, 1800000000, 1800000000},
+		{"QueryFail", testDbConfig(db1, testIdentity, maxUsage), args{lc, false}, true, "", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		switch {
+		case tt.name == "ClearOverUsage":
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1800000000, 2000000000, 10))
+			mock.ExpectExec(QUERY_ClearPlanCache).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 400000000, 2000000000, 10))
+		case tt.name == "ClearOverEvictions":
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1000000000, 2000000000, 250))
+			mock.ExpectExec(QUERY_ClearPlanCache).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(QUERY_ClearResultCache).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 200000000, 2000000000, 250))
+		case tt.name == "WithinLimits":
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1000000000, 2000000000, 10))
+		case tt.name == "FilteredRemove":
+			/*Plan 1002 was evicted before it could be removed*/
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1800000000, 4000000000, 10))
+			mock.ExpectQuery(GetPlanCacheEntries([]string{"APP"}, []string{"APPUSER"})).WillReturnRows(sqlmock.NewRows([]string{"PLAN_ID"}).AddRow(1001).AddRow(1002).AddRow(1003))
+			mock.ExpectExec(GetRemovePlanCacheEntry(1001)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(GetRemovePlanCacheEntry(1002)).WillReturnError(fmt.Errorf("plan not found"))
+			mock.ExpectExec(GetRemovePlanCacheEntry(1003)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1700000000, 4000000000, 10))
+		case tt.name == "DryRun":
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1800000000, 2000000000, 10))
+		case tt.name == "ClearFails":
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1800000000, 2000000000, 10))
+			mock.ExpectExec(QUERY_ClearPlanCache).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "AfterCheckFails":
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnRows(sqlmock.NewRows([]string{"HOST", "PORT", "CACHED_PLAN_SIZE", "PLAN_CACHE_CAPACITY", "EVICTIONS_PER_HOUR"}).AddRow("hana01", 30003, 1800000000, 2000000000, 10))
+			mock.ExpectExec(QUERY_ClearPlanCache).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnError(fmt.Errorf("some db error"))
+		case tt.name == "QueryFail":
+			mock.ExpectQuery(QUERY_GetPlanCacheOverview).WillReturnError(fmt.Errorf("some db error"))
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.CleanPlanCacheFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.CleanPlanCacheFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			r := tt.dbc.Results
+			if r.PlanCacheReason != tt.reason || r.PlanCacheEntriesRemoved != tt.removed || r.PlanCacheBytesBefore != tt.before || r.PlanCacheBytesAfter != tt.after {
+				t.Errorf("DbConfig.CleanPlanCacheFunc() results = %q, %d, %d, %d, want %q, %d, %d, %d", r.PlanCacheReason, r.PlanCacheEntriesRemoved, r.PlanCacheBytesBefore, r.PlanCacheBytesAfter, tt.reason, tt.removed, tt.before, tt.after)
+			}
+		})
+	}
+}
+
+func TestDbConfig_CleanTableRetentionFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	/*args*/
+	type args struct {
+		lc     chan<- LogMessage
+		dryrun bool
+	}
+
+	/*tests*/
+	/*Configuration shared by the test cases*/
+	twoTables := func(c *DbConfig) {
+		c.TableRetention = TableRetentionConfig{Enabled: true, BatchSize: 5000, Tables: []RetentionTable{{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}, {Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14}}}
+	}
+	oneTable := func(c *DbConfig) {
+		c.TableRetention = TableRetentionConfig{Enabled: true, BatchSize: 5000, Tables: []RetentionTable{{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}}}
+	}
+
+	tests := []struct {
+		name    string
+		dbc     *DbConfig
+		args    args
+		wantErr bool
+		removed map[string]uint
+	}{
+		{"TwoTables", testDbConfig(db1, testIdentity, twoTables), args{lc, false}, false, map[string]uint{"Z_APP.LOG": 12000}},
+		{"StopAtCount", testDbConfig(db1, testIdentity, oneTable), args{lc, false}, false, map[string]uint{"Z_APP.LOG": 5000}},
+		{"DryRun", testDbConfig(db1, testIdentity, twoTables), args{lc, true}, false, map[string]uint{}},
+		{"DeleteFails", testDbConfig(db1, testIdentity, oneTable), args{lc, false}, true, map[string]uint{"Z_APP.LOG": 5000}},
+		{"CountFails", testDbConfig(db1, testIdentity, twoTables), args{lc, false}, true, map[string]uint{"Z_APP.REQUEST_LOG": 100}},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		switch {
+		case tt.name == "TwoTables":
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30})).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(12000))
+			for _, n := range []int64{5000, 5000, 2000} {
+				mock.ExpectBegin()
+				mock.ExpectExec(GetRetentionDeleteBatch(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}, 5000)).WillReturnResult(sqlmock.NewResult(0, n))
+				mock.ExpectCommit()
+			}
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14})).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(0))
+		case tt.name == "StopAtCount":
+			/*A full batch would normally be followed by another, but every row counted has been removed*/
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30})).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(5000))
+			mock.ExpectBegin()
+			mock.ExpectExec(GetRetentionDeleteBatch(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}, 5000)).WillReturnResult(sqlmock.NewResult(0, 5000))
+			mock.ExpectCommit()
+		case tt.name == "DryRun":
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30})).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(12000))
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14})).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(100))
+		case tt.name == "DeleteFails":
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30})).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(12000))
+			mock.ExpectBegin()
+			mock.ExpectExec(GetRetentionDeleteBatch(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}, 5000)).WillReturnResult(sqlmock.NewResult(0, 5000))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(GetRetentionDeleteBatch(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}, 5000)).WillReturnError(fmt.Errorf("lock wait timeout"))
+			mock.ExpectRollback()
+		case tt.name == "CountFails":
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30})).WillReturnError(fmt.Errorf("invalid column name"))
+			mock.ExpectQuery(GetRetentionCount(RetentionTable{Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14})).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(100))
+			mock.ExpectBegin()
+			mock.ExpectExec(GetRetentionDeleteBatch(RetentionTable{Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14}, 5000)).WillReturnResult(sqlmock.NewResult(0, 100))
+			mock.ExpectCommit()
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.CleanTableRetentionFunc(tt.args.lc, tt.args.dryrun); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.CleanTableRetentionFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := fmt.Sprint(tt.dbc.Results.TableRowsRemoved); got != fmt.Sprint(tt.removed) {
+				t.Errorf("DbConfig.CleanTableRetentionFunc() removed = %v, want %v", got, tt.removed)
+			}
+		})
+	}
+}
+
+func TestDbConfig_deleteInBatches(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	query := GetAlertDeleteSlice(14, AlertConfig{SliceHours: 24})
+	dbc := testDbConfig(db1, testIdentity)
+
+	/*tests*/
+	tests := []struct {
+		name     string
+		batch    uint
+		count    uint
+		deadline time.Time
+		removed  uint
+		batches  uint
+		complete bool
+		wantErr  bool
+	}{
+		{"BudgetReached", 0, 500, time.Now(), 200, 1, false, false},
+		{"EmptySlice", 0, 500, time.Time{}, 200, 2, true, false},
+		{"ShortBatch", 300, 500, time.Time{}, 200, 1, true, false},
+		{"BeginFails", 300, 500, time.Time{}, 0, 0, false, true},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		switch {
+		case tt.name == "BudgetReached":
+			/*The deadline has passed, so only the first batch runs*/
+			mock.ExpectBegin()
+			mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 200))
+			mock.ExpectCommit()
+		case tt.name == "EmptySlice":
+			mock.ExpectBegin()
+			mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 200))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+		case tt.name == "ShortBatch":
+			mock.ExpectBegin()
+			mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 200))
+			mock.ExpectCommit()
+		case tt.name == "BeginFails":
+			mock.ExpectBegin().WillReturnError(fmt.Errorf("connection reset"))
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			removed, batches, complete, err := dbc.deleteInBatches(lc, "TST:test", "the alert table", query, tt.batch, tt.count, tt.deadline)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.deleteInBatches() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if removed != tt.removed || batches != tt.batches || complete != tt.complete {
+				t.Errorf("DbConfig.deleteInBatches() = %d, %d, %v, want %d, %d, %v", removed, batches, complete, tt.removed, tt.batches, tt.complete)
+			}
+		})
+	}
+}
+
+func TestDbConfig_CheckPrivileges(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	type args struct {
+		lc chan<- LogMessage
+	}
+	tests := []struct {
+		name    string
+		dbc     *DbConfig
+		args    args
+		wantErr bool
+	}{
+		{"NothingMissing", testDbConfig(db1, testIdentity), args{lc}, false},
+		{"NoMonitoring", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoTraceAdmin", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoBackupAdmin", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoLogAdmin", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoAuditOperator", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoResourceAdmin", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoSessionAdmin", testDbConfig(db1, testIdentity, func(c *DbConfig) { c.IdleSessions = IdleSessionConfig{Enabled: true, IdleMinutes: 60} }), args{lc}, true},
+		{"NoIniFileAdmin", testDbConfig(db1, testIdentity, func(c *DbConfig) { c.TraceLevels = TraceLevelConfig{Enabled: true} }), args{lc}, true},
+		{"NoOptimizerAdmin", testDbConfig(db1, testIdentity, func(c *DbConfig) { c.PlanCache = PlanCacheConfig{Enabled: true, MaxUsagePercent: 80} }), args{lc}, true},
+		{"TableRetentionGranted", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.TableRetention = TableRetentionConfig{Enabled: true, BatchSize: 5000, Tables: []RetentionTable{{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}, {Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14}}}
+		}), args{lc}, false},
+		{"NoTableDelete", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.TableRetention = TableRetentionConfig{Enabled: true, BatchSize: 5000, Tables: []RetentionTable{{Schema: "Z_APP", Table: "LOG", TimestampColumn: "CREATED_AT", RetainDays: 30}, {Schema: "Z_APP", Table: "REQUEST_LOG", TimestampColumn: "TS", RetainDays: 14}}}
+		}), args{lc}, true},
+		{"TableOptimiseGranted", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.TableOptimise = TableOptimiseConfig{Enabled: true, IncludeSchemas: []string{"Z_APP", "Z_ARCHIVE"}}
+		}), args{lc}, false},
+		{"TableOptimiseAllSchemas", testDbConfig(db1, testIdentity, func(c *DbConfig) { c.TableOptimise = TableOptimiseConfig{Enabled: true} }), args{lc}, false},
+		{"NoSchemaUpdate", testDbConfig(db1, testIdentity, func(c *DbConfig) {
+			c.TableOptimise = TableOptimiseConfig{Enabled: true, IncludeSchemas: []string{"Z_APP", "Z_ARCHIVE"}}
+		}), args{lc}, true},
+		{"NoSelectAlerts", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoDeleteAlerts", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"NoRows", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"DbError", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"WrongBool", testDbConfig(db1, testIdentity), args{lc}, true},
+		{"MissingPriv", testDbConfig(db1, testIdentity), args{lc}, true},
+	}
+	for _, tt := range tests {
+		/*Set up per case mocking*/
+		switch {
+		case tt.name == "NothingMissing":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+		case tt.name == "NoMonitoring":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "FALSE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+		case tt.name == "NoTraceAdmin":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "FALSE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
 		case tt.name == "NoBackupAdmin":
 			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
@@ -712,6 +2280,9 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "TRUE")
 			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
 			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
@@ -723,6 +2294,9 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "FALSE")
 			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
 			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
@@ -734,6 +2308,9 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "TRUE")
 			rows1.AddRow("AUDIT_OPERATOR", "FALSE")
 			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
@@ -745,9 +2322,110 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "TRUE")
 			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
 			rows1.AddRow("RESOURCE_ADMIN", "FALSE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+		case tt.name == "NoSessionAdmin":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "FALSE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+		case tt.name == "NoIniFileAdmin":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "FALSE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+		case tt.name == "NoOptimizerAdmin":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "FALSE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+		case tt.name == "TableRetentionGranted":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRetentionPrivCheck(tt.dbc.TableRetention.Tables[0])).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(2))
+			mock.ExpectQuery(GetRetentionPrivCheck(tt.dbc.TableRetention.Tables[1])).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(2))
+		case tt.name == "NoTableDelete":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+			mock.ExpectQuery(GetRetentionPrivCheck(tt.dbc.TableRetention.Tables[0])).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(2))
+			/*SELECT only*/
+			mock.ExpectQuery(GetRetentionPrivCheck(tt.dbc.TableRetention.Tables[1])).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(1))
+		case tt.name == "TableOptimiseGranted", tt.name == "TableOptimiseAllSchemas", tt.name == "NoSchemaUpdate":
+			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
+			rows1.AddRow("MONITORING", "TRUE")
+			rows1.AddRow("TRACE_ADMIN", "TRUE")
+			rows1.AddRow("BACKUP_ADMIN", "TRUE")
+			rows1.AddRow("LOG_ADMIN", "TRUE")
+			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
+			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
+			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
+			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
+			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
+			if tt.name == "TableOptimiseAllSchemas" {
+				break
+			}
+			mock.ExpectQuery(GetOptimisePrivCheck("Z_APP")).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(1))
+			granted := 1
+			if tt.name == "NoSchemaUpdate" {
+				granted = 0
+			}
+			mock.ExpectQuery(GetOptimisePrivCheck("Z_ARCHIVE")).WillReturnRows(sqlmock.NewRows([]string{"COUNT"}).AddRow(granted))
 		case tt.name == "NoSelectAlerts":
 			rows1 := mock.NewRows([]string{"ROLE", "RESULT"})
 			rows1.AddRow("MONITORING", "TRUE")
@@ -756,6 +2434,9 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "TRUE")
 			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
 			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "FALSE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "TRUE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
@@ -767,6 +2448,9 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "TRUE")
 			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
 			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "FALSE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
@@ -782,6 +2466,9 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "TRUE")
 			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
 			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "FALSE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
@@ -792,6 +2479,9 @@ func TestDbConfig_CheckPrivileges(t *testing.T) {
 			rows1.AddRow("LOG_ADMIN", "TRUE")
 			rows1.AddRow("AUDIT_OPERATOR", "TRUE")
 			rows1.AddRow("RESOURCE_ADMIN", "TRUE")
+			rows1.AddRow("SESSION_ADMIN", "TRUE")
+			rows1.AddRow("INIFILE_ADMIN", "TRUE")
+			rows1.AddRow("OPTIMIZER_ADMIN", "TRUE")
 			rows1.AddRow("SELECT_STATISTICS_ALERTS_BASE", "TRUE")
 			rows1.AddRow("DELETE_STATISTICS_ALERTS_BASE", "FALSE")
 			mock.ExpectQuery(GetPrivCheck(tt.dbc.Username)).WillReturnRows(rows1)
@@ -825,9 +2515,9 @@ func TestDbConfig_CheckDataClean(t *testing.T) {
 		want    uint64
 		wantErr bool
 	}{
-		{"Good01", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{"localhost", 30040}, 30000, false},
-		{"DbError", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{"localhost", 30040}, 0, true},
-		{"NoRows", &DbConfig{"TST", "test-hostname", 30015, "hccadmin", "", true, 60, true, 60, true, true, 60, true, true, 60, true, db1, CleanResults{}}, args{"localhost", 30040}, 0, true},
+		{"Good01", testDbConfig(db1, testIdentity), args{"localhost", 30040}, 30000, false},
+		{"DbError", testDbConfig(db1, testIdentity), args{"localhost", 30040}, 0, true},
+		{"NoRows", testDbConfig(db1, testIdentity), args{"localhost", 30040}, 0, true},
 	}
 	for _, tt := range tests {
 		/*per case mocking*/
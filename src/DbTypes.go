@@ -1,5 +1,7 @@
 package main
 
+import "fmt"
+
 /*This file contains helper types for handling the results of database queries*/
 
 //Struct to hold information about tracefiles
@@ -12,9 +14,47 @@ type TraceFile struct {
 
 //Struct to hold information about backup file
 type BackupFiles struct {
-	EntryType string
-	FileCount uint
-	Bytes     uint64
+	EntryType       string
+	DestinationType string
+	FileCount       uint
+	Bytes           uint64
+}
+
+//Struct to hold a backup catalog entry and one of its files, used when exporting the catalog
+type BackupCatalogEntry struct {
+	BackupID        string
+	EntryType       string
+	State           string
+	StartTime       string
+	EndTime         string
+	Host            string
+	Service         string
+	DestinationType string
+	DestinationPath string
+	SizeBytes       string
+}
+
+//Column names used when a BackupCatalogEntry is written to an archive
+var BackupCatalogEntryHeader = []string{"BACKUP_ID", "ENTRY_TYPE_NAME", "STATE_NAME", "SYS_START_TIME", "SYS_END_TIME", "HOST", "SERVICE_TYPE_NAME", "DESTINATION_TYPE_NAME", "DESTINATION_PATH", "BACKUP_SIZE"}
+
+//Returns the entry as an archive record, in the order of BackupCatalogEntryHeader
+func (b BackupCatalogEntry) Record() []string {
+	return []string{b.BackupID, b.EntryType, b.State, b.StartTime, b.EndTime, b.Host, b.Service, b.DestinationType, b.DestinationPath, b.SizeBytes}
+}
+
+//Struct to hold the memory usage of a host
+type HostResources struct {
+	Host       string
+	UsedMemory uint64
+	FreeMemory uint64
+}
+
+//Returns the percentage of physical memory used
+func (h HostResources) MemoryPercent() float64 {
+	if h.UsedMemory+h.FreeMemory == 0 {
+		return 0
+	}
+	return float64(h.UsedMemory) / float64(h.UsedMemory+h.FreeMemory) * 100
 }
 
 //Struct to hold information about data volumes
@@ -25,6 +65,135 @@ type DataVolume struct {
 	TotalSizeBytes uint64
 }
 
+//Returns true if more than half of the data volume is free
 func (d DataVolume) CleanNeeded() bool {
-	return float32(d.UsedSizeBytes)/float32(d.TotalSizeBytes)*100 < 50
+	return d.ReclaimNeeded(DataVolumeConfig{}.ForVolume(d.Host, d.Port))
+}
+
+//Returns the number of unused bytes in the data volume
+func (d DataVolume) FreeBytes() uint64 {
+	if d.UsedSizeBytes > d.TotalSizeBytes {
+		return 0
+	}
+	return d.TotalSizeBytes - d.UsedSizeBytes
+}
+
+//Returns true if the free space in the data volume is over both thresholds
+func (d DataVolume) ReclaimNeeded(t DataVolumeConfig) bool {
+	if d.TotalSizeBytes == 0 {
+		return false
+	}
+	freePercent := float64(d.FreeBytes()) / float64(d.TotalSizeBytes) * 100
+	return freePercent > float64(t.FreeTriggerPercent) && d.FreeBytes() >= uint64(t.MinFreeMiB)*1024*1024
+}
+
+//Returns the number of bytes expected to be removed by reclaiming the data volume to the target percentage of its used size
+func (d DataVolume) PredictedSaving(targetPercent uint) uint64 {
+	target := d.UsedSizeBytes * uint64(targetPercent) / 100
+	if target >= d.TotalSizeBytes {
+		return 0
+	}
+	return d.TotalSizeBytes - target
+}
+
+//Struct to hold the memory sizes of a column store table, summed over its partitions
+type CsTable struct {
+	Schema            string
+	Table             string
+	MainBytes         uint64
+	DeltaBytes        uint64
+	UncompressedBytes uint64
+}
+
+//Returns the total memory size of the table
+func (t CsTable) TotalBytes() uint64 {
+	return t.MainBytes + t.DeltaBytes
+}
+
+//Returns true if the delta store is over the delta merge threshold
+func (t CsTable) MergeNeeded(c TableOptimiseConfig) bool {
+	return t.DeltaBytes > uint64(c.DeltaTriggerMiB)*1024*1024
+}
+
+//Returns true if the main store is large enough to be checked and is over the compression threshold
+func (t CsTable) CompressionNeeded(c TableOptimiseConfig) bool {
+	if c.CompressionTriggerPercent == 0 || t.UncompressedBytes == 0 {
+		return false
+	}
+	if t.MainBytes < uint64(c.MinTableMiB)*1024*1024 {
+		return false
+	}
+	return float64(t.MainBytes)/float64(t.UncompressedBytes)*100 > float64(c.CompressionTriggerPercent)
+}
+
+//Struct to hold the outcome of optimising a column store table
+type TableOptimiseResult struct {
+	Schema      string
+	Table       string
+	Merged      bool
+	Compressed  bool
+	BytesBefore uint64
+	BytesAfter  uint64 //Zero if the size could not be read after optimising
+}
+
+//Struct to hold information about an idle session
+type IdleSession struct {
+	ConnectionID uint
+	User         string
+	Application  string
+	ClientHost   string
+	IdleMs       uint64
+}
+
+//Struct to hold the size and age of a resettable monitoring view
+type MonitoringView struct {
+	Name    string
+	Rows    uint64
+	AgeDays uint //Days since the view was last reset
+}
+
+//Returns true if the view holds rows and is over both thresholds
+func (m MonitoringView) ResetNeeded(c MonitoringResetConfig) bool {
+	return m.Rows > 0 && m.Rows >= uint64(c.MinRows) && m.AgeDays >= c.MinAgeDays
+}
+
+//Struct to hold a trace level setting from M_INIFILE_CONTENTS
+type TraceLevelSetting struct {
+	File     string
+	Layer    string
+	Host     string
+	Key      string
+	Value    string
+	AgeHours int64 //Hours since the setting was last changed, -1 if unknown
+}
+
+//Returns the setting in the form file/layer[/host] [trace] key = value
+func (t TraceLevelSetting) String() string {
+	where := fmt.Sprintf("%s/%s", t.File, t.Layer)
+	if t.Host != "" {
+		where = fmt.Sprintf("%s/%s", where, t.Host)
+	}
+	return fmt.Sprintf("%s [trace] %s = %s", where, t.Key, t.Value)
+}
+
+//Struct to hold the plan cache usage of a service
+type PlanCacheService struct {
+	Host             string
+	Port             uint
+	CachedBytes      uint64
+	CapacityBytes    uint64
+	EvictionsPerHour uint64
+}
+
+//Returns the reason the service is over one of the plan cache limits, or an empty string if it is within them
+func (p PlanCacheService) OverLimit(c PlanCacheConfig) string {
+	switch {
+	case c.MaxUsagePercent > 0 && p.CapacityBytes > 0 && p.CachedBytes*100 > p.CapacityBytes*uint64(c.MaxUsagePercent):
+		return fmt.Sprintf("%s:%d plan cache is %d%% full", p.Host, p.Port, p.CachedBytes*100/p.CapacityBytes)
+	case c.MaxSizeMiB > 0 && p.CachedBytes > uint64(c.MaxSizeMiB)*1024*1024:
+		return fmt.Sprintf("%s:%d plan cache is %.2fMiB", p.Host, p.Port, float64(p.CachedBytes)/1024/1024)
+	case c.MaxEvictionsPerHour > 0 && p.EvictionsPerHour > uint64(c.MaxEvictionsPerHour):
+		return fmt.Sprintf("%s:%d is evicting %d plans per hour", p.Host, p.Port, p.EvictionsPerHour)
+	}
+	return ""
 }
@@ -20,3 +20,168 @@ func TestDataVolume_CleanNeeded(t *testing.T) {
 		})
 	}
 }
+
+func TestDataVolume_ReclaimNeeded(t *testing.T) {
+	tests := []struct {
+		name string
+		d    DataVolume
+		t    DataVolumeConfig
+		want bool
+	}{
+		{"DefaultOver", DataVolume{"hana01", 30040, 250, 1000}, DataVolumeConfig{50, 0, 120, nil, DataVolumeGates{}}, true},
+		{"DefaultUnder", DataVolume{"hana01", 30040, 750, 1000}, DataVolumeConfig{50, 0, 120, nil, DataVolumeGates{}}, false},
+		{"LowerTrigger", DataVolume{"hana01", 30040, 750, 1000}, DataVolumeConfig{20, 0, 120, nil, DataVolumeGates{}}, true},
+		{"BelowMinFree", DataVolume{"hana01", 30040, 250 * 1024 * 1024, 1000 * 1024 * 1024}, DataVolumeConfig{50, 1024, 120, nil, DataVolumeGates{}}, false},
+		{"AboveMinFree", DataVolume{"hana01", 30040, 250 * 1024 * 1024, 2000 * 1024 * 1024}, DataVolumeConfig{50, 1024, 120, nil, DataVolumeGates{}}, true},
+		{"EmptyVolume", DataVolume{"hana01", 30040, 0, 0}, DataVolumeConfig{50, 0, 120, nil, DataVolumeGates{}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.ReclaimNeeded(tt.t); got != tt.want {
+				t.Errorf("DataVolume.ReclaimNeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataVolume_PredictedSaving(t *testing.T) {
+	tests := []struct {
+		name   string
+		d      DataVolume
+		target uint
+		want   uint64
+	}{
+		{"Default", DataVolume{"hana01", 30040, 1000, 3000}, 120, 1800},
+		{"Larger", DataVolume{"hana01", 30040, 1000, 3000}, 200, 1000},
+		{"NoSaving", DataVolume{"hana01", 30040, 1000, 1100}, 120, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.d.PredictedSaving(tt.target); got != tt.want {
+				t.Errorf("DataVolume.PredictedSaving() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostResources_MemoryPercent(t *testing.T) {
+	tests := []struct {
+		name string
+		h    HostResources
+		want float64
+	}{
+		{"Half", HostResources{"hana01", 500, 500}, 50},
+		{"Empty", HostResources{"hana01", 0, 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.MemoryPercent(); got != tt.want {
+				t.Errorf("HostResources.MemoryPercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCsTable_MergeNeeded(t *testing.T) {
+	tests := []struct {
+		name string
+		t    CsTable
+		c    TableOptimiseConfig
+		want bool
+	}{
+		{"DefaultOver", CsTable{"APP", "ORDERS", 0, 2048 * 1024 * 1024, 0}, TableOptimiseConfig{}.WithDefaults(), true},
+		{"DefaultUnder", CsTable{"APP", "ORDERS", 0, 512 * 1024 * 1024, 0}, TableOptimiseConfig{}.WithDefaults(), false},
+		{"Configured", CsTable{"APP", "ORDERS", 0, 200 * 1024 * 1024, 0}, TableOptimiseConfig{DeltaTriggerMiB: 100}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.MergeNeeded(tt.c); got != tt.want {
+				t.Errorf("CsTable.MergeNeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCsTable_CompressionNeeded(t *testing.T) {
+	tests := []struct {
+		name string
+		t    CsTable
+		c    TableOptimiseConfig
+		want bool
+	}{
+		{"NotConfigured", CsTable{"APP", "ORDERS", 800, 0, 1000}, TableOptimiseConfig{}, false},
+		{"Over", CsTable{"APP", "ORDERS", 800, 0, 1000}, TableOptimiseConfig{CompressionTriggerPercent: 50}, true},
+		{"Under", CsTable{"APP", "ORDERS", 200, 0, 1000}, TableOptimiseConfig{CompressionTriggerPercent: 50}, false},
+		{"TooSmall", CsTable{"APP", "ORDERS", 800, 0, 1000}, TableOptimiseConfig{CompressionTriggerPercent: 50, MinTableMiB: 1}, false},
+		{"NoUncompressedSize", CsTable{"APP", "ORDERS", 800, 0, 0}, TableOptimiseConfig{CompressionTriggerPercent: 50}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.CompressionNeeded(tt.c); got != tt.want {
+				t.Errorf("CsTable.CompressionNeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonitoringView_ResetNeeded(t *testing.T) {
+	tests := []struct {
+		name string
+		m    MonitoringView
+		c    MonitoringResetConfig
+		want bool
+	}{
+		{"NoThresholds", MonitoringView{"M_CS_UNLOADS_RESET", 10, 0}, MonitoringResetConfig{}, true},
+		{"Empty", MonitoringView{"M_CS_UNLOADS_RESET", 0, 400}, MonitoringResetConfig{}, false},
+		{"TooFewRows", MonitoringView{"M_CS_UNLOADS_RESET", 10, 400}, MonitoringResetConfig{true, nil, 100, 30}, false},
+		{"TooRecent", MonitoringView{"M_CS_UNLOADS_RESET", 1000, 5}, MonitoringResetConfig{true, nil, 100, 30}, false},
+		{"OverBoth", MonitoringView{"M_CS_UNLOADS_RESET", 1000, 30}, MonitoringResetConfig{true, nil, 100, 30}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.ResetNeeded(tt.c); got != tt.want {
+				t.Errorf("MonitoringView.ResetNeeded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceLevelSetting_String(t *testing.T) {
+	tests := []struct {
+		name string
+		t    TraceLevelSetting
+		want string
+	}{
+		{"System", TraceLevelSetting{"indexserver.ini", "SYSTEM", "", "api", "debug", -1}, "indexserver.ini/SYSTEM [trace] api = debug"},
+		{"Host", TraceLevelSetting{"nameserver.ini", "HOST", "hana01", "topology", "debug", 12}, "nameserver.ini/HOST/hana01 [trace] topology = debug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.String(); got != tt.want {
+				t.Errorf("TraceLevelSetting.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanCacheService_OverLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		p    PlanCacheService
+		c    PlanCacheConfig
+		want string
+	}{
+		{"NoLimits", PlanCacheService{"hana01", 30003, 1900, 2000, 500}, PlanCacheConfig{}, ""},
+		{"Usage", PlanCacheService{"hana01", 30003, 1900, 2000, 0}, PlanCacheConfig{MaxUsagePercent: 90}, "hana01:30003 plan cache is 95% full"},
+		{"UsageWithin", PlanCacheService{"hana01", 30003, 1700, 2000, 0}, PlanCacheConfig{MaxUsagePercent: 90}, ""},
+		{"Size", PlanCacheService{"hana01", 30003, 3 * 1024 * 1024, 0, 0}, PlanCacheConfig{MaxSizeMiB: 2}, "hana01:30003 plan cache is 3.00MiB"},
+		{"Evictions", PlanCacheService{"hana01", 30003, 0, 0, 500}, PlanCacheConfig{MaxEvictionsPerHour: 100}, "hana01:30003 is evicting 500 plans per hour"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.OverLimit(tt.c); got != tt.want {
+				t.Errorf("PlanCacheService.OverLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
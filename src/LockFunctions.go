@@ -0,0 +1,205 @@
+package main
+
+/*This file contains the run lock that stops overlapping runs from working on the same database.  The lock is a file
+held on the host HCC runs on and, optionally, a check of the database for the sessions of another HCC run*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//The prefix of the name HCC gives its database sessions
+const HccSessionPrefix string = "HanaCleanCentral:"
+
+//Identifies this run as the host HCC runs on and its process ID
+var runOwner = func() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}()
+
+//Returns the name of this run's database sessions.  The name is set as the application of every connection
+func sessionName() string {
+	return HccSessionPrefix + runOwner
+}
+
+//The contents of a lock file
+type runLock struct {
+	Owner   string    // The host and process ID of the run holding the lock
+	Started time.Time // When the lock was taken
+}
+
+//Replaces the characters that should not be used in a file name
+func lockNamePart(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
+
+//Returns the lock file for the database, named after the configuration file and the database
+func (dbc DbConfig) lockFile(configFile string) string {
+	dir := dbc.Lock.Dir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	base := strings.TrimSuffix(filepath.Base(configFile), filepath.Ext(configFile))
+	return filepath.Join(dir, fmt.Sprintf("hcc-%s-%s.lock", lockNamePart(base), lockNamePart(dbc.Name)))
+}
+
+//Reads a lock file.  A lock file that can't be parsed, e.g. one left half written, is dated by its modification time.
+func readRunLock(path string) (runLock, error) {
+	var rl runLock
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rl, err
+	}
+	if json.Unmarshal(data, &rl) != nil || rl.Started.IsZero() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return rl, err
+		}
+		rl = runLock{"unknown", info.ModTime()}
+	}
+	return rl, nil
+}
+
+//Moves a lock file, replaced in tests to race another run
+var renameLock = os.Rename
+
+//Removes a stale lock file.  Another run may find the same lock stale, remove it and take the lock between the lock
+//being read and removed, so the lock file is first moved aside under a name of this run's own.  Only one run can
+//move a file, and when the file moved is not the stale lock that was read it is put back and an error is returned.
+func (dbc *DbConfig) removeStaleLock(lc chan<- LogMessage, path string, held runLock) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "RunLock")
+	aside := fmt.Sprintf("%s.stale.%s", path, lockNamePart(runOwner))
+	if err := renameLock(path, aside); err != nil {
+		return err
+	}
+
+	moved, err := readRunLock(aside)
+	if err == nil && moved.Owner == held.Owner && moved.Started.Equal(held.Started) {
+		return os.Remove(aside)
+	}
+
+	/*Link rather than rename so that a lock file taken in the meantime is never replaced*/
+	if lerr := os.Link(aside, path); lerr != nil {
+		lc <- LogMessage{fname, fmt.Sprintf("Could not put back lock file %s: %s", path, lerr.Error()), false}
+	}
+	os.Remove(aside)
+	return fmt.Errorf("lock file %s was taken over by another run while the stale lock was being removed", path)
+}
+
+//Takes the lock file for the database.  A lock file older than the TTL is stale, it is removed and the lock is taken.
+//An error is returned if another run holds the lock, in which case no tasks should be run.
+func (dbc *DbConfig) AcquireLockFunc(lc chan<- LogMessage, configFile string) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "RunLock")
+	if !dbc.Lock.File {
+		lc <- LogMessage{fname, "Lock file not enabled for this database", true}
+		return nil
+	}
+
+	path := dbc.lockFile(configFile)
+	data, err := json.Marshal(runLock{runOwner, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	/*A second attempt is only made after a stale lock file has been removed*/
+	for k := 0; k < 2; k++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return err
+			}
+			dbc.Results.LockFile = path
+			lc <- LogMessage{fname, fmt.Sprintf("Took lock file %s", path), true}
+			return nil
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		held, err := readRunLock(path)
+		if os.IsNotExist(err) {
+			continue /*released while it was being read*/
+		}
+		if err != nil {
+			return err
+		}
+		if time.Since(held.Started) <= dbc.Lock.TTL() {
+			return fmt.Errorf("lock file %s is held by %s since %s", path, held.Owner, held.Started.Format(time.RFC3339))
+		}
+		lc <- LogMessage{fname, fmt.Sprintf("Removing stale lock file %s held by %s since %s", path, held.Owner, held.Started.Format(time.RFC3339)), false}
+		if err := dbc.removeStaleLock(lc, path, held); err != nil {
+			if os.IsNotExist(err) {
+				continue /*another run removed it first*/
+			}
+			return err
+		}
+	}
+	return fmt.Errorf("could not take lock file %s", path)
+}
+
+//Removes the lock file taken by AcquireLockFunc.  A lock file that another run has taken over, after this run's lock
+//went stale, is left in place.
+func (dbc *DbConfig) ReleaseLockFunc(lc chan<- LogMessage) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "RunLock")
+	if dbc.Results.LockFile == "" {
+		return
+	}
+
+	held, err := readRunLock(dbc.Results.LockFile)
+	switch {
+	case os.IsNotExist(err):
+		return
+	case err != nil:
+		lc <- LogMessage{fname, fmt.Sprintf("Could not read lock file %s: %s", dbc.Results.LockFile, err.Error()), false}
+		return
+	case held.Owner != runOwner:
+		lc <- LogMessage{fname, fmt.Sprintf("Lock file %s is now held by %s, leaving it in place", dbc.Results.LockFile, held.Owner), false}
+		return
+	}
+
+	if err := os.Remove(dbc.Results.LockFile); err != nil && !os.IsNotExist(err) {
+		lc <- LogMessage{fname, fmt.Sprintf("Could not remove lock file %s: %s", dbc.Results.LockFile, err.Error()), false}
+		return
+	}
+	lc <- LogMessage{fname, fmt.Sprintf("Released lock file %s", dbc.Results.LockFile), true}
+}
+
+//Checks the database for the sessions of another HCC run, such as one started on the other host of an HA pair.
+//Sessions that connected longer ago than the TTL are ignored.  An error is returned if another run is connected.
+func (dbc *DbConfig) CheckDatabaseLockFunc(lc chan<- LogMessage) error {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "RunLock")
+	query := GetOtherHccSessions(sessionName(), uint(dbc.Lock.TTL()/time.Minute))
+	lc <- LogMessage{fname, fmt.Sprintf("Performing query: %s", query), true}
+
+	var sessions uint
+	err := dbc.db.QueryRow(query).Scan(&sessions)
+	if err != nil {
+		lc <- LogMessage{fname, "Query failed", false}
+		lc <- LogMessage{fname, err.Error(), true}
+		return err
+	}
+	if sessions > 0 {
+		return fmt.Errorf("%d sessions of another HCC run are connected to the database", sessions)
+	}
+	lc <- LogMessage{fname, "No other HCC run is connected to the database", true}
+	return nil
+}
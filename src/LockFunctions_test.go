@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDbConfig_lockFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		dbc        DbConfig
+		configFile string
+		want       string
+	}{
+		{"Plain", DbConfig{Name: "systemdb_TST", Hostname: "hana1", Port: 30015, Username: "admin", password: "password", CleanTrace: true, RetainTraceDays: 14, CleanBackupCatalog: true, RetainBackupCatalogDays: 14, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 30, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true, Dir: "/var/run/hcc"}}, "/etc/hcc/prod.json", "/var/run/hcc/hcc-prod-systemdb_TST.lock"},
+		{"Sanitised", DbConfig{Name: "Ten 01/TST", Hostname: "hana1", Port: 30015, Username: "admin", password: "password", CleanTrace: true, RetainTraceDays: 14, CleanBackupCatalog: true, RetainBackupCatalogDays: 14, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 30, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true, Dir: "/var/run/hcc"}}, "config (copy).json", "/var/run/hcc/hcc-config__copy_-Ten_01_TST.lock"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.dbc.lockFile(tt.configFile); got != tt.want {
+				t.Errorf("DbConfig.lockFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDbConfig_AcquireLockFunc(t *testing.T) {
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	tests := []struct {
+		name     string
+		file     bool
+		dir      string
+		existing string /*contents of a lock file left by another run*/
+		age      time.Duration
+		wantErr  bool
+	}{
+		{"NoLock", true, "", "", 0, false},
+		{"Disabled", false, "", "", 0, false},
+		{"HeldByOtherRun", true, "", "held", time.Minute, true},
+		{"StaleLock", true, "", "held", 7 * time.Hour, false},
+		{"UnreadableRecentLock", true, "", "{not json", time.Minute, true},
+		{"UnreadableStaleLock", true, "", "{not json", 7 * time.Hour, false},
+		{"MissingDir", true, "missing", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.dir != "" {
+				dir = filepath.Join(dir, tt.dir)
+			}
+			dbc := DbConfig{Name: "systemdb_TST", Hostname: "hana1", Port: 30015, Username: "admin", password: "password", CleanTrace: true, RetainTraceDays: 14, CleanBackupCatalog: true, RetainBackupCatalogDays: 14, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 30, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: tt.file, Dir: dir}}
+			path := dbc.lockFile("config.json")
+
+			/*Leave a lock file from another run*/
+			if tt.existing != "" {
+				data := []byte(tt.existing)
+				if tt.existing == "held" {
+					data, _ = json.Marshal(runLock{"otherhost:1234", time.Now().Add(-tt.age)})
+				}
+				if err := os.WriteFile(path, data, 0644); err != nil {
+					t.Fatalf("could not write lock file: %v", err)
+				}
+				mtime := time.Now().Add(-tt.age)
+				os.Chtimes(path, mtime, mtime)
+			}
+
+			err := dbc.AcquireLockFunc(lc, "config.json")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.AcquireLockFunc() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr || !tt.file {
+				if dbc.Results.LockFile != "" {
+					t.Errorf("DbConfig.AcquireLockFunc() recorded lock file %s when no lock was taken", dbc.Results.LockFile)
+				}
+				return
+			}
+
+			held, err := readRunLock(path)
+			if err != nil || held.Owner != runOwner {
+				t.Errorf("DbConfig.AcquireLockFunc() lock file owner = %s, %v, want %s", held.Owner, err, runOwner)
+			}
+			if aside, _ := filepath.Glob(path + ".stale.*"); len(aside) > 0 {
+				t.Errorf("DbConfig.AcquireLockFunc() left stale lock files %v", aside)
+			}
+
+			/*A second run must not get the lock while it is held*/
+			other := dbc
+			other.Results = CleanResults{}
+			if err := other.AcquireLockFunc(lc, "config.json"); err == nil {
+				t.Errorf("DbConfig.AcquireLockFunc() took a lock that is already held")
+			}
+
+			dbc.ReleaseLockFunc(lc)
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("DbConfig.ReleaseLockFunc() left the lock file in place")
+			}
+		})
+	}
+}
+
+func TestDbConfig_AcquireLockFunc_StaleRace(t *testing.T) {
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	dbc := DbConfig{Name: "systemdb_TST", Hostname: "hana1", Port: 30015, Lock: LockConfig{File: true, Dir: t.TempDir()}}
+	path := dbc.lockFile("config.json")
+	data, _ := json.Marshal(runLock{"otherhost:1234", time.Now().Add(-7 * time.Hour)})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("could not write lock file: %v", err)
+	}
+
+	/*Another run removes the stale lock and takes the lock after this run has read it*/
+	defer func(f func(string, string) error) { renameLock = f }(renameLock)
+	renameLock = func(from, to string) error {
+		fresh, _ := json.Marshal(runLock{"otherhost:5678", time.Now()})
+		if err := os.WriteFile(from, fresh, 0644); err != nil {
+			return err
+		}
+		return os.Rename(from, to)
+	}
+
+	if err := dbc.AcquireLockFunc(lc, "config.json"); err == nil {
+		t.Errorf("DbConfig.AcquireLockFunc() took a lock that another run took over")
+	}
+	if dbc.Results.LockFile != "" {
+		t.Errorf("DbConfig.AcquireLockFunc() recorded lock file %s when no lock was taken", dbc.Results.LockFile)
+	}
+	if held, err := readRunLock(path); err != nil || held.Owner != "otherhost:5678" {
+		t.Errorf("DbConfig.AcquireLockFunc() lock file owner = %s, %v, want otherhost:5678", held.Owner, err)
+	}
+	if aside, _ := filepath.Glob(path + ".stale.*"); len(aside) > 0 {
+		t.Errorf("DbConfig.AcquireLockFunc() left stale lock files %v", aside)
+	}
+}
+
+func TestDbConfig_ReleaseLockFunc_TakenOver(t *testing.T) {
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	dbc := DbConfig{Name: "systemdb_TST", Hostname: "hana1", Port: 30015, Username: "admin", password: "password", CleanTrace: true, RetainTraceDays: 14, CleanBackupCatalog: true, RetainBackupCatalogDays: 14, DeleteOldBackups: true, CleanAlerts: true, RetainAlertsDays: 30, CleanLogVolume: true, CleanAudit: true, RetainAuditDays: 60, CleanDataVolume: true, Lock: LockConfig{File: true, Dir: t.TempDir()}}
+	if err := dbc.AcquireLockFunc(lc, "config.json"); err != nil {
+		t.Fatalf("DbConfig.AcquireLockFunc() error = %v", err)
+	}
+
+	/*Another run found the lock stale and took it over*/
+	data, _ := json.Marshal(runLock{"otherhost:1234", time.Now()})
+	if err := os.WriteFile(dbc.Results.LockFile, data, 0644); err != nil {
+		t.Fatalf("could not write lock file: %v", err)
+	}
+
+	dbc.ReleaseLockFunc(lc)
+	if held, err := readRunLock(dbc.Results.LockFile); err != nil || held.Owner != "otherhost:1234" {
+		t.Errorf("DbConfig.ReleaseLockFunc() removed a lock held by another run")
+	}
+}
+
+func TestDbConfig_CheckDatabaseLockFunc(t *testing.T) {
+	/*Test Setup*/
+	/*Mock DB*/
+	db1, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Errorf("an error '%s' was not expected when opening mock database connection", err)
+	}
+	defer db1.Close()
+
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	tests := []struct {
+		name    string
+		dbc     *DbConfig
+		wantErr bool
+	}{
+		{"NoOtherRun", testDbConfig(db1, func(c *DbConfig) { c.Lock = LockConfig{File: true, Database: true} }), false},
+		{"OtherRunConnected", testDbConfig(db1, func(c *DbConfig) { c.Lock = LockConfig{File: true, Database: true, TTLMinutes: 60} }), true},
+		{"QueryFails", testDbConfig(db1, func(c *DbConfig) { c.Lock = LockConfig{File: true, Database: true} }), true},
+	}
+	for _, tt := range tests {
+
+		/*Set up per case mocking*/
+		query := GetOtherHccSessions(sessionName(), uint(tt.dbc.Lock.TTL()/time.Minute))
+		switch tt.name {
+		case "NoOtherRun":
+			mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"SESSIONS"}).AddRow(0))
+		case "OtherRunConnected":
+			mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"SESSIONS"}).AddRow(2))
+		case "QueryFails":
+			mock.ExpectQuery(query).WillReturnError(fmt.Errorf("some DB error"))
+		default:
+			t.Errorf("Couldn't find DB mocking for test \"%s\"\n", tt.name)
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.dbc.CheckDatabaseLockFunc(lc); (err != nil) != tt.wantErr {
+				t.Errorf("DbConfig.CheckDatabaseLockFunc() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
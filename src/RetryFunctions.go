@@ -0,0 +1,78 @@
+package main
+
+/*This file contains the classification of database errors and the retry of statements that fail with a transient error*/
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"syscall"
+	"time"
+
+	hdbdriver "github.com/SAP/go-hdb/driver"
+)
+
+//The unit of the retry backoff, shortened in tests
+var retryBackoffUnit = time.Second
+
+//HANA error codes that are worth retrying, the statement failed because of other work on the database
+var retryableCodes = map[int]string{
+	131: "transaction rolled back by lock wait timeout",
+	133: "transaction rolled back by detected deadlock",
+	146: "resource busy and NOWAIT specified",
+	613: "execution aborted by timeout",
+}
+
+//Returns true if the error is transient and the statement can be tried again.  Lost connections and network timeouts
+//are transient, as are the HANA errors in retryableCodes.  Every other error, including all other SQL errors, is fatal.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var hdbErr hdbdriver.Error
+	if errors.As(err, &hdbErr) {
+		_, ok := retryableCodes[hdbErr.Code()]
+		return ok
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE), errors.Is(err, syscall.ETIMEDOUT):
+		return true
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return true
+	}
+	return false
+}
+
+//Adds up to half of the wait again at random so that retries from several runs don't line up
+func withJitter(wait time.Duration) time.Duration {
+	if wait <= 1 {
+		return wait
+	}
+	return wait + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+//Runs op, a read or a statement that is safe to repeat, and runs it again while it fails with a transient error.  The
+//retry policy is the one for task.  Every retry is logged and counted in the results, the last error is returned.
+func (dbc *DbConfig) withRetry(lc chan<- LogMessage, fname, task, what string, op func() error) error {
+	policy := dbc.Retry.ForTask(task)
+	attempts := policy.Attempts()
+	for k := uint(1); ; k++ {
+		err := op()
+		if err == nil || k >= attempts || !IsRetryable(err) {
+			return err
+		}
+		wait := withJitter(policy.Backoff(k))
+		lc <- LogMessage{fname, fmt.Sprintf("%s failed with a transient error, retry %d of %d in %s", what, k, attempts-1, wait.Round(time.Millisecond)), false}
+		lc <- LogMessage{fname, err.Error(), true}
+		dbc.Results.Retries++
+		time.Sleep(wait)
+	}
+}
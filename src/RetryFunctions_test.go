@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+)
+
+//A HANA server error as returned by go-hdb
+type testHdbError struct {
+	code int
+}
+
+func (e testHdbError) Error() string   { return fmt.Sprintf("SQL Error %d", e.code) }
+func (e testHdbError) NumError() int   { return 1 }
+func (e testHdbError) SetIdx(idx int)  {}
+func (e testHdbError) StmtNo() int     { return 0 }
+func (e testHdbError) Code() int       { return e.code }
+func (e testHdbError) Position() int   { return 0 }
+func (e testHdbError) Level() int      { return 1 }
+func (e testHdbError) Text() string    { return e.Error() }
+func (e testHdbError) IsWarning() bool { return false }
+func (e testHdbError) IsError() bool   { return true }
+func (e testHdbError) IsFatal() bool   { return false }
+
+//A network timeout
+type testTimeoutError struct{}
+
+func (e testTimeoutError) Error() string   { return "i/o timeout" }
+func (e testTimeoutError) Timeout() bool   { return true }
+func (e testTimeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Nil", nil, false},
+		{"Plain", fmt.Errorf("some DB error"), false},
+		{"NoRows", sql.ErrNoRows, false},
+		{"ConnectionReset", fmt.Errorf("read tcp: %w", syscall.ECONNRESET), true},
+		{"BrokenPipe", fmt.Errorf("write tcp: %w", syscall.EPIPE), true},
+		{"BadConn", driver.ErrBadConn, true},
+		{"EOF", io.EOF, true},
+		{"NetworkTimeout", fmt.Errorf("dial: %w", testTimeoutError{}), true},
+		{"LockWaitTimeout", testHdbError{131}, true},
+		{"Deadlock", fmt.Errorf("exec: %w", testHdbError{133}), true},
+		{"InsufficientPrivilege", testHdbError{258}, false},
+		{"SyntaxError", testHdbError{257}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryConfig_ForTask(t *testing.T) {
+	tests := []struct {
+		name         string
+		r            RetryConfig
+		task         string
+		wantAttempts uint
+		wantBackoff  []time.Duration
+	}{
+		{"Defaults", RetryConfig{}, RetryTaskCleanTrace, 3, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{"Capped", RetryConfig{6, 10, 25, nil}, RetryTaskCleanTrace, 6, []time.Duration{10 * time.Second, 20 * time.Second, 25 * time.Second}},
+		{"TaskOverride", RetryConfig{6, 10, 25, map[string]RetryConfig{RetryTaskCleanTrace: {2, 0, 40, nil}}}, RetryTaskCleanTrace, 2, []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second}},
+		{"OtherTask", RetryConfig{6, 10, 25, map[string]RetryConfig{RetryTaskCleanTrace: {2, 0, 40, nil}}}, RetryTaskCleanBackupCatalog, 6, []time.Duration{10 * time.Second, 20 * time.Second, 25 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.r.ForTask(tt.task)
+			if got := p.Attempts(); got != tt.wantAttempts {
+				t.Errorf("RetryConfig.Attempts() = %d, want %d", got, tt.wantAttempts)
+			}
+			for k, want := range tt.wantBackoff {
+				if got := p.Backoff(uint(k + 1)); got != want {
+					t.Errorf("RetryConfig.Backoff(%d) = %s, want %s", k+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	for _, wait := range []time.Duration{0, time.Second, 30 * time.Second} {
+		for k := 0; k < 20; k++ {
+			if got := withJitter(wait); got < wait || got > wait+wait/2 {
+				t.Errorf("withJitter(%s) = %s, want between %s and %s", wait, got, wait, wait+wait/2)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+/*This file contains the HANA version parsing, the minimum version check, the feature checks for the few queries that
+differ by version and the HANA Cloud task profile*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//The oldest HANA version HCC supports, as the major version and support package stack
+const (
+	MinHanaMajor uint = 1
+	MinHanaSPS   uint = 12
+)
+
+//A HANA version parsed from M_DATABASE.VERSION, e.g. 2.00.045.00.1575639312.  HANA Cloud reports major version 4.
+type HanaVersion struct {
+	Raw      string
+	Major    uint
+	Minor    uint
+	Revision uint
+	Patch    uint
+	Build    int64 //Build time as seconds since the epoch, used to work out the HANA Cloud release
+}
+
+//Parses the version string returned by HanaVersionFunc
+func ParseHanaVersion(v string) (HanaVersion, error) {
+	hv := HanaVersion{Raw: v}
+	parts := strings.Split(strings.TrimSpace(v), ".")
+	if len(parts) < 4 {
+		return hv, fmt.Errorf("version '%s' does not have the form major.minor.revision.patch", v)
+	}
+	nums := make([]uint, 4)
+	for k := range nums {
+		n, err := strconv.ParseUint(parts[k], 10, 32)
+		if err != nil {
+			return hv, fmt.Errorf("version '%s' has a part that is not a number", v)
+		}
+		nums[k] = uint(n)
+	}
+	hv.Major, hv.Minor, hv.Revision, hv.Patch = nums[0], nums[1], nums[2], nums[3]
+	if hv.Major == 0 {
+		return hv, fmt.Errorf("version '%s' has no major version", v)
+	}
+	if len(parts) > 4 {
+		if b, err := strconv.ParseInt(parts[4], 10, 64); err == nil {
+			hv.Build = b
+		}
+	}
+	return hv, nil
+}
+
+//Returns true if the version was parsed
+func (v HanaVersion) Known() bool {
+	return v.Major > 0
+}
+
+//Returns true for HANA Cloud
+func (v HanaVersion) Cloud() bool {
+	return v.Major >= 4
+}
+
+//Returns the support package stack, revision 122 is SPS12 and revision 045 is SPS04
+func (v HanaVersion) SPS() uint {
+	return v.Revision / 10
+}
+
+//Returns the HANA Cloud quarterly release worked out from the build time, e.g. "QRC 3/2022", or an empty string
+//when the build time is not known
+func (v HanaVersion) QRC() string {
+	if !v.Cloud() || v.Build <= 0 {
+		return ""
+	}
+	t := time.Unix(v.Build, 0).UTC()
+	return fmt.Sprintf("QRC %d/%d", (int(t.Month())-1)/3+1, t.Year())
+}
+
+func (v HanaVersion) String() string {
+	switch {
+	case !v.Known():
+		return v.Raw
+	case v.Cloud() && v.QRC() != "":
+		return fmt.Sprintf("HANA Cloud %s (%s)", v.QRC(), v.Raw)
+	case v.Cloud():
+		return fmt.Sprintf("HANA Cloud (%s)", v.Raw)
+	default:
+		return fmt.Sprintf("HANA %d.0 SPS%02d revision %d.%02d", v.Major, v.SPS(), v.Revision, v.Patch)
+	}
+}
+
+//Returns true if the version is the given on premise major version and SPS or later.  HANA Cloud and versions that
+//could not be parsed are treated as current.
+func (v HanaVersion) AtLeast(major, sps uint) bool {
+	if !v.Known() || v.Cloud() {
+		return true
+	}
+	if v.Major != major {
+		return v.Major > major
+	}
+	return v.SPS() >= sps
+}
+
+//Returns an error if HCC does not support the version
+func (v HanaVersion) CheckSupported() error {
+	if !v.AtLeast(MinHanaMajor, MinHanaSPS) {
+		return fmt.Errorf("%s is not supported, HCC needs HANA %d.0 SPS%02d or later", v, MinHanaMajor, MinHanaSPS)
+	}
+	return nil
+}
+
+//Database features that are not available on every supported HANA version.  Only the features listed here are
+//checked, the other views HCC reads are expected on every supported version and are not gated.
+type Feature uint
+
+const (
+	FeatureSystemReplicationView Feature = iota //M_SYSTEM_REPLICATION
+	FeatureIniFileHistory                       //M_INIFILE_CONTENT_HISTORY
+	FeatureLoadHistory                          //M_LOAD_HISTORY_HOST
+)
+
+//The on premise version that introduced a feature
+type featureRequirement struct {
+	name  string
+	major uint
+	sps   uint
+}
+
+var featureRequirements = map[Feature]featureRequirement{
+	FeatureSystemReplicationView: {"M_SYSTEM_REPLICATION", 2, 0},
+	FeatureIniFileHistory:        {"M_INIFILE_CONTENT_HISTORY", 2, 3},
+	FeatureLoadHistory:           {"M_LOAD_HISTORY_HOST", 2, 0},
+}
+
+//Returns true if the feature is available on the version
+func (v HanaVersion) Supports(f Feature) bool {
+	r := featureRequirements[f]
+	return v.AtLeast(r.major, r.sps)
+}
+
+//Returns an explicit error for a task that needs a feature the version does not have, what describes the task or
+//the setting that needs the feature
+func (v HanaVersion) Require(f Feature, what string) error {
+	if v.Supports(f) {
+		return nil
+	}
+	r := featureRequirements[f]
+	return fmt.Errorf("%s needs %s which is only available from HANA %d.0 SPS%02d, the database is %s", what, r.name, r.major, r.sps, v)
+}
+
+//Applies the HANA Cloud task profile.  Tasks that use statements HANA Cloud does not allow are disabled.  When
+//'Cloud.EnableTasks' is set the audit, alert and table retention tasks are also enabled with their configured
+//retention, unless they are set to false or their retention is 0 days.  Table retention is only enabled when
+//tables are configured.  Each change is logged and recorded in the results.
+func (dbc *DbConfig) ApplyCloudProfile(lc chan<- LogMessage) {
+	fname := fmt.Sprintf("%s:%s", dbc.Name, "CloudProfile")
+	lc <- LogMessage{fname, "Starting", false}
+
+	record := func(change string) {
+		lc <- LogMessage{fname, change, false}
+		dbc.Results.CloudProfile = append(dbc.Results.CloudProfile, change)
+	}
+	disable := func(setting *bool, task string) {
+		if *setting {
+			*setting = false
+			record(fmt.Sprintf("%s disabled, it is managed by HANA Cloud", task))
+		}
+	}
+	enable := func(setting *bool, path string, retainDays uint, task string) {
+		switch {
+		case *setting:
+			return
+		case dbc.setOff[path]:
+			lc <- LogMessage{fname, fmt.Sprintf("%s not enabled, '%s' is set to false", task, path), true}
+		case retainDays == 0:
+			record(fmt.Sprintf("%s not enabled, its retention is 0 days", task))
+		default:
+			*setting = true
+			record(fmt.Sprintf("%s enabled", task))
+		}
+	}
+
+	disable(&dbc.CleanTrace, "Trace file cleanup")
+	disable(&dbc.TraceQuota.Enabled, "Trace quota")
+	disable(&dbc.CleanBackupCatalog, "Backup catalog cleanup")
+	dbc.DeleteOldBackups = false
+	disable(&dbc.CleanLogVolume, "Log volume cleanup")
+	disable(&dbc.CleanDataVolume, "Data volume reclaim")
+	if !dbc.Cloud.EnableTasks {
+		return
+	}
+	enable(&dbc.CleanAudit, "CleanAudit", dbc.RetainAuditDays, "Audit log cleanup")
+	enable(&dbc.CleanAlerts, "CleanAlerts", dbc.RetainAlertsDays, "Alert cleanup")
+	if len(dbc.TableRetention.Tables) > 0 {
+		/*Every table must keep at least one day, see getRetentionTables*/
+		enable(&dbc.TableRetention.Enabled, "TableRetention.Enabled", 1, "Table retention")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseHanaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+		cloud   bool
+		sps     uint
+		wantErr bool
+	}{
+		{"HanaOneSPS12", "1.00.122.33.1604661230", "HANA 1.0 SPS12 revision 122.33", false, 12, false},
+		{"HanaTwoSPS04", "2.00.045.00.1575639312", "HANA 2.0 SPS04 revision 45.00", false, 4, false},
+		{"HanaTwoSPS07", "2.00.070.00.1679989823", "HANA 2.0 SPS07 revision 70.00", false, 7, false},
+		{"NoBuild", "2.00.059.04", "HANA 2.0 SPS05 revision 59.04", false, 5, false},
+		{"Cloud", "4.00.000.00.1660640318", "HANA Cloud QRC 3/2022 (4.00.000.00.1660640318)", true, 0, false},
+		{"CloudNoBuild", "4.00.000.00", "HANA Cloud (4.00.000.00)", true, 0, false},
+		{"TooShort", "2.00.045", "", false, 0, true},
+		{"NotNumeric", "2.00.SPS5.00", "", false, 0, true},
+		{"NoMajor", "0.00.045.00", "", false, 0, true},
+		{"Empty", "", "", false, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHanaVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseHanaVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.String() != tt.want || got.Cloud() != tt.cloud || got.SPS() != tt.sps {
+				t.Errorf("ParseHanaVersion() = %s, %v, %d, want %s, %v, %d", got, got.Cloud(), got.SPS(), tt.want, tt.cloud, tt.sps)
+			}
+		})
+	}
+}
+
+func TestHanaVersion_Supports(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		supported   bool
+		replication bool
+		history     bool
+		load        bool
+	}{
+		{"HanaOneSPS11", "1.00.112.07.1490098931", false, false, false, false},
+		{"HanaOneSPS12", "1.00.122.33.1604661230", true, false, false, false},
+		{"HanaTwoSPS02", "2.00.024.00.1539187200", true, true, false, true},
+		{"HanaTwoSPS03", "2.00.037.00.1546942549", true, true, true, true},
+		{"Cloud", "4.00.000.00.1660640318", true, true, true, true},
+		{"Unknown", "", true, true, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, _ := ParseHanaVersion(tt.version)
+			if got := v.CheckSupported() == nil; got != tt.supported {
+				t.Errorf("HanaVersion.CheckSupported() supported = %v, want %v", got, tt.supported)
+			}
+			if got := v.Supports(FeatureSystemReplicationView); got != tt.replication {
+				t.Errorf("HanaVersion.Supports(FeatureSystemReplicationView) = %v, want %v", got, tt.replication)
+			}
+			if got := v.Supports(FeatureIniFileHistory); got != tt.history {
+				t.Errorf("HanaVersion.Supports(FeatureIniFileHistory) = %v, want %v", got, tt.history)
+			}
+			if got := v.Supports(FeatureLoadHistory); got != tt.load {
+				t.Errorf("HanaVersion.Supports(FeatureLoadHistory) = %v, want %v", got, tt.load)
+			}
+			if got := v.Require(FeatureIniFileHistory, "test") == nil; got != tt.history {
+				t.Errorf("HanaVersion.Require(FeatureIniFileHistory) passed = %v, want %v", got, tt.history)
+			}
+		})
+	}
+}
+
+func TestDbConfig_ApplyCloudProfile(t *testing.T) {
+	/*Logger*/
+	lc := make(chan LogMessage)
+	quit := make(chan bool)
+
+	defer close(lc)
+	defer close(quit)
+
+	go Logger(AppConfig{"file", true, false, false}, lc, quit)
+
+	tests := []struct {
+		name          string
+		dbc           DbConfig
+		wantAudit     bool
+		wantAlerts    bool
+		wantRetention bool
+		wantChanges   int
+	}{
+		{"DisableOnly", DbConfig{Name: "test", Hostname: "localhost", Port: 30015, Username: "admin", password: "password", CleanTrace: true, RetainTraceDays: 14, CleanBackupCatalog: true, RetainBackupCatalogDays: 14, DeleteOldBackups: true, RetainAlertsDays: 30, CleanLogVolume: true, RetainAuditDays: 60, CleanDataVolume: true, TraceQuota: TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 1024}}, false, false, false, 5},
+		{"EnableTasks", DbConfig{Name: "test", Hostname: "localhost", Port: 30015, Username: "admin", password: "password", CleanTrace: true, RetainTraceDays: 14, CleanBackupCatalog: true, RetainBackupCatalogDays: 14, DeleteOldBackups: true, RetainAlertsDays: 30, CleanLogVolume: true, RetainAuditDays: 60, CleanDataVolume: true, TraceQuota: TraceQuotaConfig{Enabled: true, MaxMiBPerHost: 1024}, Cloud: CloudConfig{EnableTasks: true}}, true, true, false, 7},
+		{"RetentionTables", DbConfig{Name: "test", Hostname: "localhost", Port: 30015, Username: "admin", password: "password", RetainTraceDays: 14, RetainBackupCatalogDays: 14, CleanAlerts: true, RetainAlertsDays: 30, CleanAudit: true, RetainAuditDays: 60, TableRetention: TableRetentionConfig{Tables: []RetentionTable{{Schema: "APP", Table: "EVENTS", TimestampColumn: "CREATED", RetainDays: 30}}}, Cloud: CloudConfig{EnableTasks: true}}, true, true, true, 1},
+		{"RetentionTablesNotEnabled", DbConfig{Name: "test", Hostname: "localhost", Port: 30015, Username: "admin", password: "password", RetainTraceDays: 14, RetainBackupCatalogDays: 14, CleanAlerts: true, RetainAlertsDays: 30, CleanAudit: true, RetainAuditDays: 60, TableRetention: TableRetentionConfig{Tables: []RetentionTable{{Schema: "APP", Table: "EVENTS", TimestampColumn: "CREATED", RetainDays: 30}}}}, true, true, false, 0},
+		{"SetToFalse", DbConfig{Name: "test", Hostname: "localhost", Port: 30015, Username: "admin", password: "password", CleanTrace: true, RetainTraceDays: 14, RetainAlertsDays: 30, RetainAuditDays: 60, TableRetention: TableRetentionConfig{Tables: []RetentionTable{{Schema: "APP", Table: "EVENTS", TimestampColumn: "CREATED", RetainDays: 30}}}, Cloud: CloudConfig{EnableTasks: true}, setOff: map[string]bool{"CleanAudit": true, "TableRetention.Enabled": true}}, false, true, false, 2},
+		{"ZeroRetention", DbConfig{Name: "test", Hostname: "localhost", Port: 30015, Username: "admin", password: "password", RetainTraceDays: 14, RetainAlertsDays: 30, Cloud: CloudConfig{EnableTasks: true}}, false, true, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dbc.ApplyCloudProfile(lc)
+			if tt.dbc.CleanTrace || tt.dbc.TraceQuota.Enabled || tt.dbc.CleanBackupCatalog || tt.dbc.DeleteOldBackups || tt.dbc.CleanLogVolume || tt.dbc.CleanDataVolume {
+				t.Errorf("DbConfig.ApplyCloudProfile() left a task enabled that HANA Cloud does not allow")
+			}
+			if tt.dbc.CleanAudit != tt.wantAudit || tt.dbc.CleanAlerts != tt.wantAlerts || tt.dbc.TableRetention.Enabled != tt.wantRetention {
+				t.Errorf("DbConfig.ApplyCloudProfile() audit, alerts, retention = %v, %v, %v, want %v, %v, %v", tt.dbc.CleanAudit, tt.dbc.CleanAlerts, tt.dbc.TableRetention.Enabled, tt.wantAudit, tt.wantAlerts, tt.wantRetention)
+			}
+			if len(tt.dbc.Results.CloudProfile) != tt.wantChanges {
+				t.Errorf("DbConfig.ApplyCloudProfile() recorded %d changes, want %d", len(tt.dbc.Results.CloudProfile), tt.wantChanges)
+			}
+		})
+	}
+}
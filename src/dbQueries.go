@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -22,10 +23,71 @@ const QUERY_GetFeeLogSegments string = "SELECT COUNT(STATE) AS COUNT, COALESCE(S
 //Requires LOG ADMIN privilege
 const QUERY_ReclaimLog string = "ALTER SYSTEM RECLAIM LOG"
 
+//Query to get the total size of the log volumes in bytes
+//Requires MONITORING role
+const QUERY_GetLogVolumeSize string = "SELECT COALESCE(SUM(TOTAL_SIZE),0) AS BYTES FROM SYS.M_VOLUME_FILES WHERE FILE_TYPE = 'LOG'"
+
+//Query to get the log mode in effect, the database layer overrides the system layer which overrides the default.  No
+//rows are returned when the log mode is not set, in which case HANA uses normal
+const QUERY_GetLogMode string = "SELECT TOP 1 VALUE FROM \"SYS\".\"M_INIFILE_CONTENTS\" WHERE FILE_NAME = 'global.ini' AND SECTION = 'persistence' AND KEY = 'log_mode' ORDER BY CASE LAYER_NAME WHEN 'DATABASE' THEN 1 WHEN 'SYSTEM' THEN 2 ELSE 3 END"
+
+//Query to get the state and age in seconds of the latest log backup that has finished
+const QUERY_GetLatestLogBackup string = "SELECT TOP 1 STATE_NAME, SECONDS_BETWEEN(SYS_START_TIME, CURRENT_TIMESTAMP) AS AGE FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE ENTRY_TYPE_NAME = 'log backup' AND STATE_NAME <> 'running' ORDER BY SYS_START_TIME DESC"
+
+//Query for the system replication pre-flight.  Returns the replication mode in effect for this site, the number of
+//secondary sites replicated from this site and the number of replicated services that are not active
+const QUERY_GetReplicationRole string = "SELECT COALESCE((SELECT TOP 1 VALUE FROM \"SYS\".\"M_INIFILE_CONTENTS\" WHERE FILE_NAME = 'global.ini' AND SECTION = 'system_replication' AND KEY = 'actual_mode' ORDER BY CASE LAYER_NAME WHEN 'DATABASE' THEN 1 WHEN 'SYSTEM' THEN 2 ELSE 3 END), '') AS MODE, (SELECT COUNT(*) FROM \"SYS\".\"M_SYSTEM_REPLICATION\") AS SECONDARIES, (SELECT COUNT(*) FROM \"SYS\".\"M_SERVICE_REPLICATION\" WHERE REPLICATION_STATUS <> 'ACTIVE') AS INACTIVE FROM DUMMY"
+
+//Variant of QUERY_GetReplicationRole for versions without M_SYSTEM_REPLICATION, the secondary sites are counted
+//from M_SERVICE_REPLICATION instead
+const QUERY_GetReplicationRoleV1 string = "SELECT COALESCE((SELECT TOP 1 VALUE FROM \"SYS\".\"M_INIFILE_CONTENTS\" WHERE FILE_NAME = 'global.ini' AND SECTION = 'system_replication' AND KEY = 'actual_mode' ORDER BY CASE LAYER_NAME WHEN 'DATABASE' THEN 1 WHEN 'SYSTEM' THEN 2 ELSE 3 END), '') AS MODE, (SELECT COUNT(DISTINCT SECONDARY_SITE_ID) FROM \"SYS\".\"M_SERVICE_REPLICATION\") AS SECONDARIES, (SELECT COUNT(*) FROM \"SYS\".\"M_SERVICE_REPLICATION\" WHERE REPLICATION_STATUS <> 'ACTIVE') AS INACTIVE FROM DUMMY"
+
+//Returns the replication role query for the version
+func GetReplicationRole(v HanaVersion) string {
+	if v.Supports(FeatureSystemReplicationView) {
+		return QUERY_GetReplicationRole
+	}
+	return QUERY_GetReplicationRoleV1
+}
+
+//Returns a query that counts the sessions of other HCC runs connected to the database within the last ttl minutes.
+//HCC sessions set the APPLICATION session variable to a name that starts with HccSessionPrefix, own is this run's name
+func GetOtherHccSessions(own string, ttlMinutes uint) string {
+	return fmt.Sprintf("SELECT COUNT(DISTINCT S.CONNECTION_ID) AS SESSIONS FROM \"SYS\".\"M_SESSION_CONTEXT\" S INNER JOIN \"SYS\".\"M_CONNECTIONS\" C ON C.HOST = S.HOST AND C.PORT = S.PORT AND C.CONNECTION_ID = S.CONNECTION_ID WHERE S.KEY = 'APPLICATION' AND S.VALUE LIKE %s AND S.VALUE <> %s AND C.CONNECTION_STATUS <> '' AND C.START_TIME > ADD_SECONDS(CURRENT_TIMESTAMP, -%d)", sqlStringList([]string{HccSessionPrefix + "%"}), sqlStringList([]string{own}), ttlMinutes*60)
+}
+
+//Query to get the number and size of the free log segments of each service
+const QUERY_GetFreeLogSegmentsByService string = "SELECT HOST, PORT, COUNT(STATE) AS COUNT, COALESCE(SUM(TOTAL_SIZE),0) AS BYTES FROM SYS.M_LOG_SEGMENTS WHERE STATE = 'Free' GROUP BY HOST, PORT ORDER BY HOST, PORT"
+
 //Query to get the used and total space from each data volume.
 //Requires MONITORING role
 const QUERY_GetDataVolume string = "SELECT HOST, PORT, USED_SIZE, TOTAL_SIZE FROM SYS.M_VOLUME_FILES WHERE FILE_TYPE = 'DATA'"
 
+//Query to get the time of day on the database as HH:MM
+const QUERY_GetDbTimeOfDay string = "SELECT TO_VARCHAR(CURRENT_TIME, 'HH24:MI') AS NOW FROM DUMMY"
+
+//Query to get the memory usage of each host
+const QUERY_GetHostResources string = "SELECT HOST, USED_PHYSICAL_MEMORY, FREE_PHYSICAL_MEMORY FROM \"SYS\".\"M_HOST_RESOURCE_UTILIZATION\""
+
+//Query to get the latest CPU usage percentage of each host recorded in the load history within the last five minutes
+const QUERY_GetHostCPULoad string = "SELECT HOST, CPU FROM (SELECT HOST, CPU, ROW_NUMBER() OVER (PARTITION BY HOST ORDER BY TIME DESC) AS RN FROM \"SYS\".\"M_LOAD_HISTORY_HOST\" WHERE TIME > ADD_SECONDS(CURRENT_TIMESTAMP, -300)) WHERE RN = 1"
+
+//Query to count the data backups that are currently running
+const QUERY_GetRunningDataBackups string = "SELECT COUNT(BACKUP_ID) AS COUNT FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'running' AND ENTRY_TYPE_NAME <> 'log backup'"
+
+//Query to get the longest savepoint of each service in the last hour in microseconds
+const QUERY_GetMaxSavepointDuration string = "SELECT HOST, PORT, MAX(DURATION) AS DURATION FROM \"SYS\".\"M_SAVEPOINTS\" WHERE START_TIME > ADD_SECONDS(NOW(), -3600) GROUP BY HOST, PORT"
+
+//Query returns the plan cache size and capacity of each service, and the average number of plans evicted per hour
+//since the service started
+const QUERY_GetPlanCacheOverview string = "SELECT P.HOST, P.PORT, P.CACHED_PLAN_SIZE, P.PLAN_CACHE_CAPACITY, COALESCE(P.EVICTED_PLAN_COUNT * 3600 / NULLIF(SECONDS_BETWEEN(S.START_TIME, NOW()), 0), 0) AS EVICTIONS_PER_HOUR FROM \"SYS\".\"M_SQL_PLAN_CACHE_OVERVIEW\" AS P JOIN \"SYS\".\"M_SERVICE_STATISTICS\" AS S ON S.HOST = P.HOST AND S.PORT = P.PORT"
+
+//Statement clears the whole SQL plan cache
+const QUERY_ClearPlanCache string = "ALTER SYSTEM CLEAR SQL PLAN CACHE"
+
+//Statement clears the result cache
+const QUERY_ClearResultCache string = "ALTER SYSTEM CLEAR RESULT CACHE"
+
 func GetSpecificDataVolume(host string, port uint) string {
 	return fmt.Sprintf("SELECT TOTAL_SIZE FROM SYS.M_VOLUME_FILES WHERE FILE_TYPE = 'DATA' AND HOST = '%s' AND PORT = '%d'", host, port)
 }
@@ -43,6 +105,17 @@ func GetTraceFileQuery(days uint) string {
 	return fmt.Sprintf("SELECT HOST, FILE_NAME, FILE_SIZE, FILE_MTIME FROM \"SYS\".\"M_TRACEFILES\" WHERE FILE_MTIME < (SELECT ADD_DAYS(NOW(), -%d) FROM DUMMY) AND RIGHT(FILE_NAME, 3) = 'trc' OR FILE_MTIME < (SELECT ADD_DAYS(NOW(), -%d) FROM DUMMY) AND RIGHT(FILE_NAME, 2) = 'gz'", days, days)
 }
 
+//Query to get the total size of all trace files on each host
+//Requires MONITORING role
+const QUERY_GetTraceSizePerHost string = "SELECT HOST, COALESCE(SUM(FILE_SIZE),0) AS BYTES FROM \"SYS\".\"M_TRACEFILES\" GROUP BY HOST"
+
+//The function returns a string which is used to find trace files that may be removed to enforce the trace quota.  Only "trc" and "gz"
+//files that have not been modified in the given number of hours are returned.  Files are ordered by host and then oldest first.
+//Requires MONITORING role
+func GetTraceQuotaCandidates(hours uint) string {
+	return fmt.Sprintf("SELECT HOST, FILE_NAME, FILE_SIZE, FILE_MTIME FROM \"SYS\".\"M_TRACEFILES\" WHERE FILE_MTIME < (SELECT ADD_SECONDS(NOW(), -%d) FROM DUMMY) AND (RIGHT(FILE_NAME, 3) = 'trc' OR RIGHT(FILE_NAME, 2) = 'gz') ORDER BY HOST, FILE_MTIME ASC", hours*3600)
+}
+
 //Requires MONITORING role
 func GetCheckTracePresent(filename string) string {
 	/*trace file names should always be unique as they contain hostnames, rotation numbers etc*/
@@ -55,20 +128,112 @@ func GetRemoveTrace(hostname, filename string) string {
 	return fmt.Sprintf("ALTER SYSTEM REMOVE TRACES('%s', '%s')", hostname, filename)
 }
 
-//Returns a string query that is used to find the backup ID of the most recent full backup that is older than the days given in the argument
+//Returns a string query that is used to find the backup ID and end time of the most recent full backup that is older than the days given in the argument
 func GetLatestFullBackupID(days uint) string {
-	return fmt.Sprintf("SELECT BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-%d) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1", days)
+	return fmt.Sprintf("SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-%d) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1", days)
+}
+
+//Returns a string query that is used to find the backup ID and end time of the nth most recent successful full backup
+func GetNthLatestFullBackupID(n uint) string {
+	if n == 0 { /*n is 1 based, treat 0 as the latest*/
+		n = 1
+	}
+	return fmt.Sprintf("SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' ORDER BY SYS_END_TIME DESC LIMIT 1 OFFSET %d", n-1)
+}
+
+//Returns a string query that is used to find the most recent successful full backup taken after the given backup ID
+//that finished within the given number of hours
+func GetRecentFullBackupAfter(backupid string, hours uint) string {
+	return fmt.Sprintf("SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND BACKUP_ID > %s AND SYS_END_TIME > ADD_SECONDS(NOW(), -%d) ORDER BY SYS_END_TIME DESC LIMIT 1", backupid, hours*3600)
+}
+
+//Returns a string query that checks the chain of successful log backups taken after the given complete data backup.
+//The first column is the number of log backups, the second the number of gaps, where a log backup of a volume starts
+//after the redo log position the previous log backup of that volume ended at.  The first log backup of each volume is
+//checked against the position the data backup of the volume ended at.  Failed attempts that were retried are ignored.
+func GetLogBackupChainAfter(backupid string) string {
+	return fmt.Sprintf("SELECT COUNT(DISTINCT CASE WHEN IS_LOG = 1 THEN BACKUP_ID END), COALESCE(SUM(GAP), 0) FROM ("+
+		"SELECT BACKUP_ID, IS_LOG, "+
+		"CASE WHEN IS_LOG = 1 AND FIRST_POSITION > LAG(LAST_POSITION) OVER (PARTITION BY SOURCE_ID ORDER BY IS_LOG, FIRST_POSITION) THEN 1 ELSE 0 END AS GAP "+
+		"FROM ("+
+		"SELECT F.BACKUP_ID, F.SOURCE_ID, F.LAST_REDO_LOG_POSITION AS FIRST_POSITION, F.LAST_REDO_LOG_POSITION AS LAST_POSITION, 0 AS IS_LOG "+
+		"FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F WHERE F.BACKUP_ID = %[1]s "+
+		"UNION ALL "+
+		"SELECT F.BACKUP_ID, F.SOURCE_ID, F.FIRST_REDO_LOG_POSITION AS FIRST_POSITION, F.LAST_REDO_LOG_POSITION AS LAST_POSITION, 1 AS IS_LOG "+
+		"FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F "+
+		"INNER JOIN \"SYS\".\"M_BACKUP_CATALOG\" AS B ON B.BACKUP_ID = F.BACKUP_ID "+
+		"WHERE B.ENTRY_TYPE_NAME = 'log backup' AND B.STATE_NAME = 'successful' AND B.BACKUP_ID > %[1]s))", backupid)
 }
 
+//Returns a string query that summarises the backup catalog entries before the given backup ID by entry type and destination type
 func GetBackupFileData(backupid string) string {
 	return fmt.Sprintf("SELECT "+
 		"B.ENTRY_TYPE_NAME AS ENTRY, "+
-		"COUNT(B.BACKUP_ID) AS COUNT, "+
-		"SUM(F.BACKUP_SIZE) AS BYTES "+
+		"COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION, "+
+		"COUNT(DISTINCT B.BACKUP_ID) AS COUNT, "+
+		"COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES "+
 		"FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B "+
 		"LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID "+
 		"WHERE B.BACKUP_ID < %s "+
-		"GROUP BY B.ENTRY_TYPE_NAME", backupid)
+		"GROUP BY B.ENTRY_TYPE_NAME, F.DESTINATION_TYPE_NAME", backupid)
+}
+
+//Returns a string query that lists the backups before the given backup ID whose files are all held in one of the
+//given destination types and whose entry type is one of the given entry types.  An empty list matches everything.
+//There is a row for each backup and destination type with the bytes the backup holds there.
+func GetBackupIDsToDelete(backupid string, destinationTypes, entryTypes []string) string {
+	ids := "SELECT B.BACKUP_ID " +
+		"FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B " +
+		"INNER JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID " +
+		fmt.Sprintf("WHERE B.BACKUP_ID < %s ", backupid)
+	if len(entryTypes) > 0 {
+		ids += fmt.Sprintf("AND B.ENTRY_TYPE_NAME IN (%s) ", sqlStringList(entryTypes))
+	}
+	ids += "GROUP BY B.BACKUP_ID"
+	if len(destinationTypes) > 0 {
+		ids += fmt.Sprintf(" HAVING SUM(CASE WHEN F.DESTINATION_TYPE_NAME IN (%s) THEN 0 ELSE 1 END) = 0", sqlStringList(destinationTypes))
+	}
+	return fmt.Sprintf("SELECT "+
+		"F.BACKUP_ID, "+
+		"F.DESTINATION_TYPE_NAME, "+
+		"COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES "+
+		"FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F "+
+		"WHERE F.BACKUP_ID IN (%s) "+
+		"GROUP BY F.BACKUP_ID, F.DESTINATION_TYPE_NAME "+
+		"ORDER BY F.BACKUP_ID", ids)
+}
+
+//Returns a string query that removes a single backup from the catalog and physically deletes its files
+func GetBackupDeleteIDComplete(backupid string) string {
+	return fmt.Sprintf("BACKUP CATALOG DELETE BACKUP_ID %s COMPLETE", backupid)
+}
+
+//Returns the values as a comma separated list of quoted SQL strings
+func sqlStringList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''"))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+//Returns a string query that lists every backup catalog entry before the given backup ID with its files
+func GetBackupCatalogExport(backupid string) string {
+	return fmt.Sprintf("SELECT "+
+		"B.BACKUP_ID, "+
+		"B.ENTRY_TYPE_NAME, "+
+		"B.STATE_NAME, "+
+		"B.SYS_START_TIME, "+
+		"B.SYS_END_TIME, "+
+		"COALESCE(F.HOST, '') AS HOST, "+
+		"COALESCE(F.SERVICE_TYPE_NAME, '') AS SERVICE_TYPE_NAME, "+
+		"COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION_TYPE_NAME, "+
+		"COALESCE(F.DESTINATION_PATH, '') AS DESTINATION_PATH, "+
+		"COALESCE(F.BACKUP_SIZE, 0) AS BACKUP_SIZE "+
+		"FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B "+
+		"LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID "+
+		"WHERE B.BACKUP_ID < %s "+
+		"ORDER BY B.BACKUP_ID", backupid)
 }
 
 func GetBackupDelete(backupid string) string {
@@ -79,18 +244,97 @@ func GetBackupDeleteComplete(backupid string) string {
 	return fmt.Sprintf("BACKUP CATALOG DELETE ALL BEFORE BACKUP_ID %s COMPLETE", backupid)
 }
 
-func GetAlertCount(days uint) string {
-	return fmt.Sprintf("SELECT COUNT(SNAPSHOT_ID) AS COUNT FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -%d) LIMIT 1", days)
+//Returns the condition matching alerts past their retention.  Retention set for the alert ID is used first, then
+//retention set for the alert rating and finally the given number of days.
+func alertExpired(days uint, a AlertConfig) string {
+	if len(a.RetainDaysByID) == 0 && len(a.RetainDaysByRating) == 0 {
+		return fmt.Sprintf("ALERT_TIMESTAMP < ADD_DAYS(NOW(), -%d)", days)
+	}
+	cases := []string{}
+	for _, id := range sortedKeys(a.RetainDaysByID) {
+		cases = append(cases, fmt.Sprintf("WHEN ALERT_ID = %d THEN %d", id, a.RetainDaysByID[id]))
+	}
+	for _, rating := range sortedKeys(a.RetainDaysByRating) {
+		cases = append(cases, fmt.Sprintf("WHEN ALERT_RATING = %d THEN %d", rating, a.RetainDaysByRating[rating]))
+	}
+	return fmt.Sprintf("ALERT_TIMESTAMP < ADD_DAYS(NOW(), -1 * (CASE %s ELSE %d END))", strings.Join(cases, " "), days)
+}
+
+//Returns the keys of the map in ascending order
+func sortedKeys(m map[uint]uint) []uint {
+	keys := make([]uint, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
 }
 
-func GetAlertDelete(days uint) string {
-	return fmt.Sprintf("DELETE FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -%d)", days)
+func GetAlertCount(days uint, a AlertConfig) string {
+	return fmt.Sprintf("SELECT COUNT(SNAPSHOT_ID) AS COUNT FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE %s LIMIT 1", alertExpired(days, a))
+}
+
+//Function that returns a query that counts the alerts past their retention for each alert ID
+func GetAlertCountByID(days uint, a AlertConfig) string {
+	return fmt.Sprintf("SELECT ALERT_ID, COUNT(SNAPSHOT_ID) AS COUNT FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE %s GROUP BY ALERT_ID ORDER BY ALERT_ID", alertExpired(days, a))
+}
+
+func GetAlertDelete(days uint, a AlertConfig) string {
+	return fmt.Sprintf("DELETE FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE %s", alertExpired(days, a))
+}
+
+//Function that returns a statement that deletes one batch of at most BatchRows alerts past their retention, oldest
+//first.  Alerts sharing the timestamp of the last alert in the batch are all removed.
+func GetAlertDeleteBatch(days uint, a AlertConfig) string {
+	expired := alertExpired(days, a)
+	return fmt.Sprintf("DELETE FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE %s AND ALERT_TIMESTAMP <= (SELECT MAX(ALERT_TIMESTAMP) FROM (SELECT TOP %d ALERT_TIMESTAMP FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE %s ORDER BY ALERT_TIMESTAMP))", expired, a.BatchRows, expired)
+}
+
+//Function that returns a statement that deletes the alerts past their retention that were raised within SliceHours of
+//the oldest alert past its retention.  Older alerts that are kept by a longer retention don't start the slice.
+func GetAlertDeleteSlice(days uint, a AlertConfig) string {
+	expired := alertExpired(days, a)
+	return fmt.Sprintf("DELETE FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE %s AND ALERT_TIMESTAMP < (SELECT ADD_SECONDS(MIN(ALERT_TIMESTAMP), %d) FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE %s)", expired, a.SliceHours*3600, expired)
 }
 
 func GetAuditCount(days uint) string {
 	return fmt.Sprintf("SELECT COUNT(TIMESTAMP) AS COUNT FROM \"SYS\".\"AUDIT_LOG\" WHERE TIMESTAMP < (SELECT ADD_DAYS(NOW(), -%d) FROM DUMMY)", days)
 }
 
+//Returns a string query that counts the audit records up to and including the given datetime, the cutoff used to archive
+//and clear them.  CLEAR AUDIT LOG UNTIL also clears the records at the cutoff.
+func GetAuditCountBefore(datetime string) string {
+	return fmt.Sprintf("SELECT COUNT(TIMESTAMP) AS COUNT FROM \"SYS\".\"AUDIT_LOG\" WHERE TIMESTAMP <= '%s'", datetime)
+}
+
+//Columns of SYS.AUDIT_LOG written to audit archives
+var AuditArchiveColumns = []string{"TIMESTAMP", "HOST", "PORT", "SERVICE_NAME", "CONNECTION_ID", "CLIENT_HOST", "CLIENT_IP", "USER_NAME", "APPLICATION_NAME", "APPLICATION_USER_NAME", "AUDIT_POLICY_NAME", "EVENT_STATUS", "EVENT_LEVEL", "EVENT_ACTION", "SCHEMA_NAME", "OBJECT_NAME", "PRIVILEGE_NAME", "ROLE_NAME", "GRANTEE", "STATEMENT_STRING", "COMMENT"}
+
+//Returns a string query that selects the audit records up to and including the given datetime in time order, the same
+//records CLEAR AUDIT LOG UNTIL clears
+func GetAuditArchive(datetime string) string {
+	return fmt.Sprintf("SELECT %s FROM \"SYS\".\"AUDIT_LOG\" WHERE TIMESTAMP <= '%s' ORDER BY TIMESTAMP", strings.Join(AuditArchiveColumns, ", "), datetime)
+}
+
+//Returns a string query that counts the audit records matching the rule.  EXPIRED is the number of records older
+//than the rule's retention, DEFAULT_EXPIRED is the number older than the default retention given in days.
+func GetAuditRuleCount(rule AuditRule, days uint) string {
+	filters := []string{}
+	if rule.PolicyName != "" {
+		filters = append(filters, fmt.Sprintf("AUDIT_POLICY_NAME = %s", sqlStringList([]string{rule.PolicyName})))
+	}
+	if rule.EventAction != "" {
+		filters = append(filters, fmt.Sprintf("EVENT_ACTION = %s", sqlStringList([]string{rule.EventAction})))
+	}
+	if rule.EventLevel != "" {
+		filters = append(filters, fmt.Sprintf("EVENT_LEVEL = %s", sqlStringList([]string{rule.EventLevel})))
+	}
+	return fmt.Sprintf("SELECT "+
+		"COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -%d) THEN 1 ELSE 0 END), 0) AS EXPIRED, "+
+		"COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -%d) THEN 1 ELSE 0 END), 0) AS DEFAULT_EXPIRED "+
+		"FROM \"SYS\".\"AUDIT_LOG\" WHERE %s", rule.RetainDays, days, strings.Join(filters, " AND "))
+}
+
 //Function a string query the will return the historic datetime for now minus the number of days given in the argument
 //Be aware, the returned string need to have subsecond element removed
 func GetDatetime(days uint) string {
@@ -103,9 +347,186 @@ func GetTruncateAuditLog(datetime string) string {
 	return fmt.Sprintf("ALTER SYSTEM CLEAR AUDIT LOG UNTIL '%s'", datetime)
 }
 
-//Function that returns a query that is used to clean (defragment) HANA data volumes.  Must specify hostname and port
-func GetCleanDataVolume(host string, port uint) string {
-	return fmt.Sprintf("ALTER SYSTEM RECLAIM DATAVOLUME '%s:%d' 120 DEFRAGMENT", host, port)
+//Function that returns a query that is used to clean (defragment) HANA data volumes.  Must specify hostname, port and
+//the target size as a percentage of the used size
+func GetCleanDataVolume(host string, port uint, targetPercent uint) string {
+	return fmt.Sprintf("ALTER SYSTEM RECLAIM DATAVOLUME '%s:%d' %d DEFRAGMENT", host, port, targetPercent)
+}
+
+//Function that returns a query that reads the memory sizes of column store tables, largest first.  Tables are limited to
+//the include list of schemas when it is not empty and tables in the exclude list of schemas are left out
+func GetCsTables(include, exclude []string) string {
+	filters := []string{}
+	if len(include) > 0 {
+		filters = append(filters, fmt.Sprintf("SCHEMA_NAME IN (%s)", sqlStringList(include)))
+	}
+	if len(exclude) > 0 {
+		filters = append(filters, fmt.Sprintf("SCHEMA_NAME NOT IN (%s)", sqlStringList(exclude)))
+	}
+	where := ""
+	if len(filters) > 0 {
+		where = " WHERE " + strings.Join(filters, " AND ")
+	}
+	return "SELECT SCHEMA_NAME, TABLE_NAME, " +
+		"SUM(MEMORY_SIZE_IN_MAIN) AS MAIN_SIZE, " +
+		"SUM(MEMORY_SIZE_IN_DELTA) AS DELTA_SIZE, " +
+		"COALESCE(SUM(UNCOMPRESSED_SIZE), 0) AS UNCOMPRESSED_SIZE " +
+		"FROM \"SYS\".\"M_CS_TABLES\"" + where + " " +
+		"GROUP BY SCHEMA_NAME, TABLE_NAME ORDER BY SUM(MEMORY_SIZE_IN_TOTAL) DESC"
+}
+
+//Function that returns a query that reads the total memory size of a column store table
+func GetCsTableSize(schema, table string) string {
+	return fmt.Sprintf("SELECT COALESCE(SUM(MEMORY_SIZE_IN_TOTAL), 0) AS SIZE FROM \"SYS\".\"M_CS_TABLES\" WHERE SCHEMA_NAME = %s AND TABLE_NAME = %s", sqlStringList([]string{schema}), sqlStringList([]string{table}))
+}
+
+//Function that returns a statement that merges the delta store of a column store table into its main store
+func GetMergeDelta(schema, table string) string {
+	return fmt.Sprintf("MERGE DELTA OF %s", sqlTableName(schema, table))
+}
+
+//Function that returns a statement that forces HANA to re-evaluate the compression of a column store table
+func GetOptimiseCompression(schema, table string) string {
+	return fmt.Sprintf("UPDATE %s WITH PARAMETERS ('OPTIMIZE_COMPRESSION' = 'FORCE')", sqlTableName(schema, table))
+}
+
+//Returns the schema and table as a quoted SQL identifier, escaping any double quotes
+func sqlTableName(schema, table string) string {
+	return fmt.Sprintf("%s.%s", sqlIdentifier(schema), sqlIdentifier(table))
+}
+
+//Returns the identifier in double quotes, with any double quotes in it escaped
+func sqlIdentifier(name string) string {
+	return fmt.Sprintf("\"%s\"", strings.ReplaceAll(name, "\"", "\"\""))
+}
+
+//Function that returns a query that finds remote sessions that have been idle for longer than the given number of
+//minutes.  Sessions of the protected users are never returned, the other lists are ignored when empty
+func GetIdleSessions(idleMinutes uint, users, applications, clientHosts, protected []string) string {
+	filters := []string{
+		"C.CONNECTION_STATUS = 'IDLE'",
+		"C.CONNECTION_TYPE = 'Remote'",
+		"C.OWN = 'FALSE'",
+		fmt.Sprintf("C.IDLE_TIME > %d", uint64(idleMinutes)*60*1000),
+	}
+	if len(protected) > 0 {
+		filters = append(filters, fmt.Sprintf("C.USER_NAME NOT IN (%s)", sqlStringList(protected)))
+	}
+	if len(users) > 0 {
+		filters = append(filters, fmt.Sprintf("C.USER_NAME IN (%s)", sqlStringList(users)))
+	}
+	if len(applications) > 0 {
+		filters = append(filters, fmt.Sprintf("COALESCE(S.VALUE, '') IN (%s)", sqlStringList(applications)))
+	}
+	if len(clientHosts) > 0 {
+		filters = append(filters, fmt.Sprintf("C.CLIENT_HOST IN (%s)", sqlStringList(clientHosts)))
+	}
+	return "SELECT C.CONNECTION_ID, C.USER_NAME, COALESCE(S.VALUE, '') AS APPLICATION, COALESCE(C.CLIENT_HOST, '') AS CLIENT_HOST, C.IDLE_TIME " +
+		"FROM \"SYS\".\"M_CONNECTIONS\" AS C LEFT JOIN \"SYS\".\"M_SESSION_CONTEXT\" AS S " +
+		"ON S.HOST = C.HOST AND S.PORT = C.PORT AND S.CONNECTION_ID = C.CONNECTION_ID AND S.KEY = 'APPLICATION' " +
+		"WHERE " + strings.Join(filters, " AND ") + " ORDER BY C.IDLE_TIME DESC"
+}
+
+//Function that returns a statement that disconnects a session
+func GetDisconnectSession(connectionID uint) string {
+	return fmt.Sprintf("ALTER SYSTEM DISCONNECT SESSION '%d'", connectionID)
+}
+
+//Function that returns a query that reads which of the given SYS views have a RESET_TIME column.  Each view that
+//exists is returned with HAS_RESET_TIME set to 1 or 0, views that don't exist are not returned.
+func GetMonitoringViewResetColumns(views []string) string {
+	return fmt.Sprintf("SELECT VIEW_NAME, MAX(CASE WHEN COLUMN_NAME = 'RESET_TIME' THEN 1 ELSE 0 END) AS HAS_RESET_TIME FROM \"SYS\".\"VIEW_COLUMNS\" WHERE SCHEMA_NAME = 'SYS' AND VIEW_NAME IN (%s) GROUP BY VIEW_NAME", sqlStringList(views))
+}
+
+//Function that returns a query that reads the number of rows in a resettable monitoring view and the number of days
+//since it was last reset
+func GetMonitoringViewStats(view string) string {
+	return fmt.Sprintf("SELECT COUNT(*) AS ROW_COUNT, COALESCE(DAYS_BETWEEN(MIN(RESET_TIME), NOW()), 0) AS AGE_DAYS FROM \"SYS\".\"%s\"", view)
+}
+
+//Function that returns a statement that resets a monitoring view
+func GetResetMonitoringView(view string) string {
+	return fmt.Sprintf("ALTER SYSTEM RESET MONITORING VIEW \"SYS\".\"%s\"", view)
+}
+
+//Function that returns a query that finds trace section keys on the SYSTEM, DATABASE and HOST layers set to one of the
+//given levels.  When withHistory is true the hours since each key was last changed are read from
+//M_INIFILE_CONTENT_HISTORY, otherwise AGE_HOURS is always -1.  Keys on the SYSTEM and DATABASE layers have no host, so
+//an empty and a NULL host are treated as the same.
+func GetElevatedTraceLevels(levels, files []string, withHistory bool) string {
+	age := "-1 AS AGE_HOURS"
+	join := ""
+	if withHistory {
+		age = "COALESCE(SECONDS_BETWEEN(H.CHANGED, NOW()) / 3600, -1) AS AGE_HOURS"
+		join = " LEFT JOIN (SELECT FILE_NAME, LAYER_NAME, HOST, KEY, MAX(TIME) AS CHANGED " +
+			"FROM \"SYS\".\"M_INIFILE_CONTENT_HISTORY\" WHERE SECTION = 'trace' GROUP BY FILE_NAME, LAYER_NAME, HOST, KEY) AS H " +
+			"ON H.FILE_NAME = C.FILE_NAME AND H.LAYER_NAME = C.LAYER_NAME AND COALESCE(H.HOST, '') = COALESCE(C.HOST, '') AND H.KEY = C.KEY"
+	}
+	filters := []string{
+		"C.SECTION = 'trace'",
+		"C.LAYER_NAME IN ('SYSTEM', 'DATABASE', 'HOST')",
+		fmt.Sprintf("LOWER(C.VALUE) IN (%s)", sqlStringList(levels)),
+	}
+	if len(files) > 0 {
+		filters = append(filters, fmt.Sprintf("C.FILE_NAME IN (%s)", sqlStringList(files)))
+	}
+	return "SELECT C.FILE_NAME, C.LAYER_NAME, COALESCE(C.HOST, '') AS HOST, C.KEY, C.VALUE, " + age + " " +
+		"FROM \"SYS\".\"M_INIFILE_CONTENTS\" AS C" + join + " " +
+		"WHERE " + strings.Join(filters, " AND ") + " ORDER BY C.FILE_NAME, C.LAYER_NAME, C.HOST, C.KEY"
+}
+
+//Function that returns a statement that unsets a trace section key on the layer it was found on
+func GetUnsetTraceLevel(t TraceLevelSetting) string {
+	layer := fmt.Sprintf("%s, '%s'", sqlStringList([]string{t.File}), t.Layer)
+	if t.Layer == "HOST" {
+		layer = fmt.Sprintf("%s, %s", layer, sqlStringList([]string{t.Host}))
+	}
+	return fmt.Sprintf("ALTER SYSTEM ALTER CONFIGURATION (%s) UNSET ('trace', %s) WITH RECONFIGURE", layer, sqlStringList([]string{t.Key}))
+}
+
+//Function that returns a query that finds the plans in the SQL plan cache that are not in use and match all of the non
+//empty lists of schemas and users
+func GetPlanCacheEntries(schemas, users []string) string {
+	filters := []string{"REFERENCE_COUNT = 0"}
+	if len(schemas) > 0 {
+		filters = append(filters, fmt.Sprintf("SCHEMA_NAME IN (%s)", sqlStringList(schemas)))
+	}
+	if len(users) > 0 {
+		filters = append(filters, fmt.Sprintf("USER_NAME IN (%s)", sqlStringList(users)))
+	}
+	return "SELECT PLAN_ID FROM \"SYS\".\"M_SQL_PLAN_CACHE\" WHERE " + strings.Join(filters, " AND ")
+}
+
+//Function that returns a statement that removes a single plan from the SQL plan cache
+func GetRemovePlanCacheEntry(planID uint64) string {
+	return fmt.Sprintf("ALTER SYSTEM REMOVE SQL PLAN CACHE ENTRY %d", planID)
+}
+
+//Function that returns a query that counts the rows of a retention table older than its retention
+func GetRetentionCount(t RetentionTable) string {
+	return fmt.Sprintf("SELECT COUNT(*) AS COUNT FROM %s WHERE %s < ADD_DAYS(NOW(), -%d)", sqlTableName(t.Schema, t.Table), sqlIdentifier(t.TimestampColumn), t.RetainDays)
+}
+
+//Function that returns a statement that deletes one batch of rows older than the retention from a retention table.
+//HANA's DELETE has no row limit, so the batch is bounded by the timestamp of the newest of the oldest batchSize rows.
+//Rows sharing that timestamp are all removed, so a batch may be slightly larger than batchSize.
+func GetRetentionDeleteBatch(t RetentionTable, batchSize uint) string {
+	table := sqlTableName(t.Schema, t.Table)
+	column := sqlIdentifier(t.TimestampColumn)
+	return fmt.Sprintf("DELETE FROM %s WHERE %s < ADD_DAYS(NOW(), -%d) AND %s <= (SELECT MAX(%s) FROM (SELECT TOP %d %s FROM %s WHERE %s < ADD_DAYS(NOW(), -%d) ORDER BY %s))",
+		table, column, t.RetainDays, column, column, batchSize, column, table, column, t.RetainDays, column)
+}
+
+//Function that returns a query that checks the connected user can select and delete from a retention table, either
+//through a privilege on the table or on its schema.  Returns the number of the two privileges held.
+func GetRetentionPrivCheck(t RetentionTable) string {
+	return fmt.Sprintf("SELECT COUNT(DISTINCT PRIVILEGE) AS COUNT FROM \"SYS\".\"EFFECTIVE_PRIVILEGES\" WHERE USER_NAME = CURRENT_USER AND PRIVILEGE IN ('SELECT', 'DELETE') AND SCHEMA_NAME = %s AND (OBJECT_NAME = %s OR OBJECT_TYPE = 'SCHEMA')", sqlStringList([]string{t.Schema}), sqlStringList([]string{t.Table}))
+}
+
+//Function that returns a query that checks the connected user can merge and optimise the compression of the column
+//store tables of a schema, through the UPDATE privilege on the schema.  Returns the number of matching privileges.
+func GetOptimisePrivCheck(schema string) string {
+	return fmt.Sprintf("SELECT COUNT(*) AS COUNT FROM \"SYS\".\"EFFECTIVE_PRIVILEGES\" WHERE USER_NAME = CURRENT_USER AND PRIVILEGE = 'UPDATE' AND SCHEMA_NAME = %s AND OBJECT_TYPE = 'SCHEMA'", sqlStringList([]string{schema}))
 }
 
 //Function that returns a query that is used to determine if required privileges are in place. Requires a username as input
@@ -167,6 +588,33 @@ func GetPrivCheck(username string) string {
 		"WHERE GRANTEE = '%s' AND PRIVILEGE = 'RESOURCE ADMIN' "+
 		"UNION ALL "+
 		"SELECT "+
+		"    'SESSION_ADMIN' AS ROLE,  "+
+		"    CASE  "+
+		"        WHEN COUNT(GRANTEE) = '0' THEN 'FALSE' "+
+		"        ELSE 'TRUE' "+
+		"    END AS RESULT "+
+		"FROM GRANTED_PRIVILEGES "+
+		"WHERE GRANTEE = '%s' AND PRIVILEGE = 'SESSION ADMIN' "+
+		"UNION ALL "+
+		"SELECT "+
+		"    'INIFILE_ADMIN' AS ROLE,  "+
+		"    CASE  "+
+		"        WHEN COUNT(GRANTEE) = '0' THEN 'FALSE' "+
+		"        ELSE 'TRUE' "+
+		"    END AS RESULT "+
+		"FROM GRANTED_PRIVILEGES "+
+		"WHERE GRANTEE = '%s' AND PRIVILEGE = 'INIFILE ADMIN' "+
+		"UNION ALL "+
+		"SELECT "+
+		"    'OPTIMIZER_ADMIN' AS ROLE,  "+
+		"    CASE  "+
+		"        WHEN COUNT(GRANTEE) = '0' THEN 'FALSE' "+
+		"        ELSE 'TRUE' "+
+		"    END AS RESULT "+
+		"FROM GRANTED_PRIVILEGES "+
+		"WHERE GRANTEE = '%s' AND PRIVILEGE = 'OPTIMIZER ADMIN' "+
+		"UNION ALL "+
+		"SELECT "+
 		"    'SELECT_STATISTICS_ALERTS_BASE' AS ROLE, "+
 		"    CASE  "+
 		"        WHEN COUNT(GRANTEE) = '0' THEN 'FALSE' "+
@@ -190,5 +638,5 @@ func GetPrivCheck(username string) string {
 		"    AND OBJECT_TYPE = 'TABLE' "+
 		"    AND SCHEMA_NAME = '_SYS_STATISTICS' "+
 		"    AND OBJECT_NAME = 'STATISTICS_ALERTS_BASE' "+
-		"    AND PRIVILEGE = 'DELETE'", username, username, username, username, username, username, username, username)
+		"    AND PRIVILEGE = 'DELETE'", username, username, username, username, username, username, username, username, username, username, username)
 }
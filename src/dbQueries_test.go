@@ -76,7 +76,7 @@ func TestGetAlertCount(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GetAlertCount(tt.args.days); got != tt.want {
+			if got := GetAlertCount(tt.args.days, AlertConfig{}); got != tt.want {
 				t.Errorf("GetAlertCount() = %v, want %v", got, tt.want)
 			}
 		})
@@ -114,11 +114,11 @@ func TestGetLatestFullBackupID(t *testing.T) {
 		args args
 		want string
 	}{
-		{"tc1", args{7}, "SELECT BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-7) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
-		{"tc2", args{14}, "SELECT BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-14) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
-		{"tc3", args{21}, "SELECT BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-21) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
-		{"tc4", args{28}, "SELECT BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-28) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
-		{"tc5", args{60}, "SELECT BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-60) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
+		{"tc1", args{7}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-7) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
+		{"tc2", args{14}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-14) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
+		{"tc3", args{21}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-21) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
+		{"tc4", args{28}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-28) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
+		{"tc5", args{60}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND SYS_END_TIME < (SELECT ADD_DAYS(NOW(),-60) FROM DUMMY) ORDER BY SYS_END_TIME DESC LIMIT 1"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -129,6 +129,77 @@ func TestGetLatestFullBackupID(t *testing.T) {
 	}
 }
 
+func TestGetNthLatestFullBackupID(t *testing.T) {
+	type args struct {
+		n uint
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"Zero", args{0}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' ORDER BY SYS_END_TIME DESC LIMIT 1 OFFSET 0"},
+		{"One", args{1}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' ORDER BY SYS_END_TIME DESC LIMIT 1 OFFSET 0"},
+		{"Five", args{5}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' ORDER BY SYS_END_TIME DESC LIMIT 1 OFFSET 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetNthLatestFullBackupID(tt.args.n); got != tt.want {
+				t.Errorf("GetNthLatestFullBackupID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRecentFullBackupAfter(t *testing.T) {
+	type args struct {
+		backupid string
+		hours    uint
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"OneDay", args{"1000", 24}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND BACKUP_ID > 1000 AND SYS_END_TIME > ADD_SECONDS(NOW(), -86400) ORDER BY SYS_END_TIME DESC LIMIT 1"},
+		{"OneWeek", args{"1234567890", 168}, "SELECT BACKUP_ID, SYS_END_TIME FROM \"SYS\".\"M_BACKUP_CATALOG\" WHERE STATE_NAME = 'successful' AND ENTRY_TYPE_NAME = 'complete data backup' AND BACKUP_ID > 1234567890 AND SYS_END_TIME > ADD_SECONDS(NOW(), -604800) ORDER BY SYS_END_TIME DESC LIMIT 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetRecentFullBackupAfter(tt.args.backupid, tt.args.hours); got != tt.want {
+				t.Errorf("GetRecentFullBackupAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetLogBackupChainAfter(t *testing.T) {
+	type args struct {
+		backupid string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"tc1", args{"1000"}, "SELECT COUNT(DISTINCT CASE WHEN IS_LOG = 1 THEN BACKUP_ID END), COALESCE(SUM(GAP), 0) FROM (SELECT BACKUP_ID, IS_LOG, CASE WHEN IS_LOG = 1 AND FIRST_POSITION > LAG(LAST_POSITION) OVER (PARTITION BY SOURCE_ID ORDER BY IS_LOG, FIRST_POSITION) THEN 1 ELSE 0 END AS GAP FROM (SELECT F.BACKUP_ID, F.SOURCE_ID, F.LAST_REDO_LOG_POSITION AS FIRST_POSITION, F.LAST_REDO_LOG_POSITION AS LAST_POSITION, 0 AS IS_LOG FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F WHERE F.BACKUP_ID = 1000 UNION ALL SELECT F.BACKUP_ID, F.SOURCE_ID, F.FIRST_REDO_LOG_POSITION AS FIRST_POSITION, F.LAST_REDO_LOG_POSITION AS LAST_POSITION, 1 AS IS_LOG FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F INNER JOIN \"SYS\".\"M_BACKUP_CATALOG\" AS B ON B.BACKUP_ID = F.BACKUP_ID WHERE B.ENTRY_TYPE_NAME = 'log backup' AND B.STATE_NAME = 'successful' AND B.BACKUP_ID > 1000))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetLogBackupChainAfter(tt.args.backupid); got != tt.want {
+				t.Errorf("GetLogBackupChainAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBackupCatalogExport(t *testing.T) {
+	want := "SELECT B.BACKUP_ID, B.ENTRY_TYPE_NAME, B.STATE_NAME, B.SYS_START_TIME, B.SYS_END_TIME, COALESCE(F.HOST, '') AS HOST, COALESCE(F.SERVICE_TYPE_NAME, '') AS SERVICE_TYPE_NAME, COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION_TYPE_NAME, COALESCE(F.DESTINATION_PATH, '') AS DESTINATION_PATH, COALESCE(F.BACKUP_SIZE, 0) AS BACKUP_SIZE FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1000 ORDER BY B.BACKUP_ID"
+	if got := GetBackupCatalogExport("1000"); got != want {
+		t.Errorf("GetBackupCatalogExport() = %v, want %v", got, want)
+	}
+}
+
 func TestGetBackupFileData(t *testing.T) {
 	type args struct {
 		backupid string
@@ -138,11 +209,11 @@ func TestGetBackupFileData(t *testing.T) {
 		args args
 		want string
 	}{
-		{"tc1", args{"1234567890"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COUNT(B.BACKUP_ID) AS COUNT, SUM(F.BACKUP_SIZE) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1234567890 GROUP BY B.ENTRY_TYPE_NAME"},
-		{"tc2", args{"1234567890"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COUNT(B.BACKUP_ID) AS COUNT, SUM(F.BACKUP_SIZE) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1234567890 GROUP BY B.ENTRY_TYPE_NAME"},
-		{"tc3", args{"5555555555"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COUNT(B.BACKUP_ID) AS COUNT, SUM(F.BACKUP_SIZE) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 5555555555 GROUP BY B.ENTRY_TYPE_NAME"},
-		{"tc4", args{"1346798520"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COUNT(B.BACKUP_ID) AS COUNT, SUM(F.BACKUP_SIZE) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1346798520 GROUP BY B.ENTRY_TYPE_NAME"},
-		{"tc5", args{"9632587410"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COUNT(B.BACKUP_ID) AS COUNT, SUM(F.BACKUP_SIZE) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 9632587410 GROUP BY B.ENTRY_TYPE_NAME"},
+		{"tc1", args{"1234567890"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION, COUNT(DISTINCT B.BACKUP_ID) AS COUNT, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1234567890 GROUP BY B.ENTRY_TYPE_NAME, F.DESTINATION_TYPE_NAME"},
+		{"tc2", args{"1234567890"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION, COUNT(DISTINCT B.BACKUP_ID) AS COUNT, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1234567890 GROUP BY B.ENTRY_TYPE_NAME, F.DESTINATION_TYPE_NAME"},
+		{"tc3", args{"5555555555"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION, COUNT(DISTINCT B.BACKUP_ID) AS COUNT, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 5555555555 GROUP BY B.ENTRY_TYPE_NAME, F.DESTINATION_TYPE_NAME"},
+		{"tc4", args{"1346798520"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION, COUNT(DISTINCT B.BACKUP_ID) AS COUNT, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1346798520 GROUP BY B.ENTRY_TYPE_NAME, F.DESTINATION_TYPE_NAME"},
+		{"tc5", args{"9632587410"}, "SELECT B.ENTRY_TYPE_NAME AS ENTRY, COALESCE(F.DESTINATION_TYPE_NAME, '') AS DESTINATION, COUNT(DISTINCT B.BACKUP_ID) AS COUNT, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B LEFT JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 9632587410 GROUP BY B.ENTRY_TYPE_NAME, F.DESTINATION_TYPE_NAME"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -153,6 +224,53 @@ func TestGetBackupFileData(t *testing.T) {
 	}
 }
 
+func TestGetBackupIDsToDelete(t *testing.T) {
+	type args struct {
+		backupid         string
+		destinationTypes []string
+		entryTypes       []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"NoFilter", args{"1000", nil, nil}, "SELECT F.BACKUP_ID, F.DESTINATION_TYPE_NAME, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F WHERE F.BACKUP_ID IN (SELECT B.BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B INNER JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1000 GROUP BY B.BACKUP_ID) GROUP BY F.BACKUP_ID, F.DESTINATION_TYPE_NAME ORDER BY F.BACKUP_ID"},
+		{"FileOnly", args{"1000", []string{"file"}, nil}, "SELECT F.BACKUP_ID, F.DESTINATION_TYPE_NAME, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F WHERE F.BACKUP_ID IN (SELECT B.BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B INNER JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1000 GROUP BY B.BACKUP_ID HAVING SUM(CASE WHEN F.DESTINATION_TYPE_NAME IN ('file') THEN 0 ELSE 1 END) = 0) GROUP BY F.BACKUP_ID, F.DESTINATION_TYPE_NAME ORDER BY F.BACKUP_ID"},
+		{"LogOnly", args{"1000", nil, []string{"log backup"}}, "SELECT F.BACKUP_ID, F.DESTINATION_TYPE_NAME, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F WHERE F.BACKUP_ID IN (SELECT B.BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B INNER JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1000 AND B.ENTRY_TYPE_NAME IN ('log backup') GROUP BY B.BACKUP_ID) GROUP BY F.BACKUP_ID, F.DESTINATION_TYPE_NAME ORDER BY F.BACKUP_ID"},
+		{"Both", args{"1000", []string{"file", "backint"}, []string{"log backup", "complete data backup"}}, "SELECT F.BACKUP_ID, F.DESTINATION_TYPE_NAME, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F WHERE F.BACKUP_ID IN (SELECT B.BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B INNER JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1000 AND B.ENTRY_TYPE_NAME IN ('log backup', 'complete data backup') GROUP BY B.BACKUP_ID HAVING SUM(CASE WHEN F.DESTINATION_TYPE_NAME IN ('file', 'backint') THEN 0 ELSE 1 END) = 0) GROUP BY F.BACKUP_ID, F.DESTINATION_TYPE_NAME ORDER BY F.BACKUP_ID"},
+		{"Quoted", args{"1000", []string{"it's"}, nil}, "SELECT F.BACKUP_ID, F.DESTINATION_TYPE_NAME, COALESCE(SUM(F.BACKUP_SIZE), 0) AS BYTES FROM \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F WHERE F.BACKUP_ID IN (SELECT B.BACKUP_ID FROM \"SYS\".\"M_BACKUP_CATALOG\" AS B INNER JOIN \"SYS\".\"M_BACKUP_CATALOG_FILES\" AS F ON B.BACKUP_ID = F.BACKUP_ID WHERE B.BACKUP_ID < 1000 GROUP BY B.BACKUP_ID HAVING SUM(CASE WHEN F.DESTINATION_TYPE_NAME IN ('it''s') THEN 0 ELSE 1 END) = 0) GROUP BY F.BACKUP_ID, F.DESTINATION_TYPE_NAME ORDER BY F.BACKUP_ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetBackupIDsToDelete(tt.args.backupid, tt.args.destinationTypes, tt.args.entryTypes); got != tt.want {
+				t.Errorf("GetBackupIDsToDelete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBackupDeleteIDComplete(t *testing.T) {
+	type args struct {
+		backupid string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"tc1", args{"1234567890"}, "BACKUP CATALOG DELETE BACKUP_ID 1234567890 COMPLETE"},
+		{"tc2", args{"5555555555"}, "BACKUP CATALOG DELETE BACKUP_ID 5555555555 COMPLETE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetBackupDeleteIDComplete(tt.args.backupid); got != tt.want {
+				t.Errorf("GetBackupDeleteIDComplete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetBackupDelete(t *testing.T) {
 	type args struct {
 		backupid string
@@ -220,7 +338,7 @@ func TestGetAlertDelete(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GetAlertDelete(tt.args.days); got != tt.want {
+			if got := GetAlertDelete(tt.args.days, AlertConfig{}); got != tt.want {
 				t.Errorf("GetAlertDelete() = %v, want %v", got, tt.want)
 			}
 		})
@@ -251,6 +369,43 @@ func TestGetAuditCount(t *testing.T) {
 	}
 }
 
+func TestGetAuditCountBefore(t *testing.T) {
+	want := "SELECT COUNT(TIMESTAMP) AS COUNT FROM \"SYS\".\"AUDIT_LOG\" WHERE TIMESTAMP <= '2022-01-01 10:00:00'"
+	if got := GetAuditCountBefore("2022-01-01 10:00:00"); got != want {
+		t.Errorf("GetAuditCountBefore() = %v, want %v", got, want)
+	}
+}
+
+func TestGetAuditArchive(t *testing.T) {
+	want := "SELECT TIMESTAMP, HOST, PORT, SERVICE_NAME, CONNECTION_ID, CLIENT_HOST, CLIENT_IP, USER_NAME, APPLICATION_NAME, APPLICATION_USER_NAME, AUDIT_POLICY_NAME, EVENT_STATUS, EVENT_LEVEL, EVENT_ACTION, SCHEMA_NAME, OBJECT_NAME, PRIVILEGE_NAME, ROLE_NAME, GRANTEE, STATEMENT_STRING, COMMENT FROM \"SYS\".\"AUDIT_LOG\" WHERE TIMESTAMP <= '2022-01-01 10:00:00' ORDER BY TIMESTAMP"
+	if got := GetAuditArchive("2022-01-01 10:00:00"); got != want {
+		t.Errorf("GetAuditArchive() = %v, want %v", got, want)
+	}
+}
+
+func TestGetAuditRuleCount(t *testing.T) {
+	type args struct {
+		rule AuditRule
+		days uint
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"Policy", args{AuditRule{"Logins", "LOGIN_FAILURES", "", "", 365}, 60}, "SELECT COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -365) THEN 1 ELSE 0 END), 0) AS EXPIRED, COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -60) THEN 1 ELSE 0 END), 0) AS DEFAULT_EXPIRED FROM \"SYS\".\"AUDIT_LOG\" WHERE AUDIT_POLICY_NAME = 'LOGIN_FAILURES'"},
+		{"ActionAndLevel", args{AuditRule{"Selects", "", "SELECT", "INFO", 7}, 30}, "SELECT COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -7) THEN 1 ELSE 0 END), 0) AS EXPIRED, COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -30) THEN 1 ELSE 0 END), 0) AS DEFAULT_EXPIRED FROM \"SYS\".\"AUDIT_LOG\" WHERE EVENT_ACTION = 'SELECT' AND EVENT_LEVEL = 'INFO'"},
+		{"Quoted", args{AuditRule{"Q", "Bob's policy", "", "", 7}, 30}, "SELECT COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -7) THEN 1 ELSE 0 END), 0) AS EXPIRED, COALESCE(SUM(CASE WHEN TIMESTAMP < ADD_DAYS(NOW(), -30) THEN 1 ELSE 0 END), 0) AS DEFAULT_EXPIRED FROM \"SYS\".\"AUDIT_LOG\" WHERE AUDIT_POLICY_NAME = 'Bob''s policy'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetAuditRuleCount(tt.args.rule, tt.args.days); got != tt.want {
+				t.Errorf("GetAuditRuleCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetDatetime(t *testing.T) {
 	type args struct {
 		days uint
@@ -298,3 +453,303 @@ func TestGetTruncateAuditLog(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTraceQuotaCandidates(t *testing.T) {
+	type args struct {
+		hours uint
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"Good01", args{0}, "SELECT HOST, FILE_NAME, FILE_SIZE, FILE_MTIME FROM \"SYS\".\"M_TRACEFILES\" WHERE FILE_MTIME < (SELECT ADD_SECONDS(NOW(), -0) FROM DUMMY) AND (RIGHT(FILE_NAME, 3) = 'trc' OR RIGHT(FILE_NAME, 2) = 'gz') ORDER BY HOST, FILE_MTIME ASC"},
+		{"Good02", args{1}, "SELECT HOST, FILE_NAME, FILE_SIZE, FILE_MTIME FROM \"SYS\".\"M_TRACEFILES\" WHERE FILE_MTIME < (SELECT ADD_SECONDS(NOW(), -3600) FROM DUMMY) AND (RIGHT(FILE_NAME, 3) = 'trc' OR RIGHT(FILE_NAME, 2) = 'gz') ORDER BY HOST, FILE_MTIME ASC"},
+		{"Good03", args{24}, "SELECT HOST, FILE_NAME, FILE_SIZE, FILE_MTIME FROM \"SYS\".\"M_TRACEFILES\" WHERE FILE_MTIME < (SELECT ADD_SECONDS(NOW(), -86400) FROM DUMMY) AND (RIGHT(FILE_NAME, 3) = 'trc' OR RIGHT(FILE_NAME, 2) = 'gz') ORDER BY HOST, FILE_MTIME ASC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetTraceQuotaCandidates(tt.args.hours); got != tt.want {
+				t.Errorf("GetTraceQuotaCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCleanDataVolume(t *testing.T) {
+	type args struct {
+		host          string
+		port          uint
+		targetPercent uint
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"Default", args{"hana01", 30040, 120}, "ALTER SYSTEM RECLAIM DATAVOLUME 'hana01:30040' 120 DEFRAGMENT"},
+		{"Target150", args{"hana02", 30044, 150}, "ALTER SYSTEM RECLAIM DATAVOLUME 'hana02:30044' 150 DEFRAGMENT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetCleanDataVolume(tt.args.host, tt.args.port, tt.args.targetPercent); got != tt.want {
+				t.Errorf("GetCleanDataVolume() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetCsTables(t *testing.T) {
+	type args struct {
+		include []string
+		exclude []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"AllSchemas", args{nil, nil}, "SELECT SCHEMA_NAME, TABLE_NAME, SUM(MEMORY_SIZE_IN_MAIN) AS MAIN_SIZE, SUM(MEMORY_SIZE_IN_DELTA) AS DELTA_SIZE, COALESCE(SUM(UNCOMPRESSED_SIZE), 0) AS UNCOMPRESSED_SIZE FROM \"SYS\".\"M_CS_TABLES\" GROUP BY SCHEMA_NAME, TABLE_NAME ORDER BY SUM(MEMORY_SIZE_IN_TOTAL) DESC"},
+		{"Include", args{[]string{"APP", "ERP"}, nil}, "SELECT SCHEMA_NAME, TABLE_NAME, SUM(MEMORY_SIZE_IN_MAIN) AS MAIN_SIZE, SUM(MEMORY_SIZE_IN_DELTA) AS DELTA_SIZE, COALESCE(SUM(UNCOMPRESSED_SIZE), 0) AS UNCOMPRESSED_SIZE FROM \"SYS\".\"M_CS_TABLES\" WHERE SCHEMA_NAME IN ('APP', 'ERP') GROUP BY SCHEMA_NAME, TABLE_NAME ORDER BY SUM(MEMORY_SIZE_IN_TOTAL) DESC"},
+		{"IncludeAndExclude", args{[]string{"APP"}, []string{"SYS"}}, "SELECT SCHEMA_NAME, TABLE_NAME, SUM(MEMORY_SIZE_IN_MAIN) AS MAIN_SIZE, SUM(MEMORY_SIZE_IN_DELTA) AS DELTA_SIZE, COALESCE(SUM(UNCOMPRESSED_SIZE), 0) AS UNCOMPRESSED_SIZE FROM \"SYS\".\"M_CS_TABLES\" WHERE SCHEMA_NAME IN ('APP') AND SCHEMA_NAME NOT IN ('SYS') GROUP BY SCHEMA_NAME, TABLE_NAME ORDER BY SUM(MEMORY_SIZE_IN_TOTAL) DESC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetCsTables(tt.args.include, tt.args.exclude); got != tt.want {
+				t.Errorf("GetCsTables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMergeDelta(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		table  string
+		want   string
+	}{
+		{"Simple", "APP", "ORDERS", "MERGE DELTA OF \"APP\".\"ORDERS\""},
+		{"Quoted", "APP", "MY\"TABLE", "MERGE DELTA OF \"APP\".\"MY\"\"TABLE\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetMergeDelta(tt.schema, tt.table); got != tt.want {
+				t.Errorf("GetMergeDelta() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOptimiseCompression(t *testing.T) {
+	want := "UPDATE \"APP\".\"ORDERS\" WITH PARAMETERS ('OPTIMIZE_COMPRESSION' = 'FORCE')"
+	if got := GetOptimiseCompression("APP", "ORDERS"); got != want {
+		t.Errorf("GetOptimiseCompression() = %v, want %v", got, want)
+	}
+}
+
+func TestGetCsTableSize(t *testing.T) {
+	want := "SELECT COALESCE(SUM(MEMORY_SIZE_IN_TOTAL), 0) AS SIZE FROM \"SYS\".\"M_CS_TABLES\" WHERE SCHEMA_NAME = 'APP' AND TABLE_NAME = 'O''NEIL'"
+	if got := GetCsTableSize("APP", "O'NEIL"); got != want {
+		t.Errorf("GetCsTableSize() = %v, want %v", got, want)
+	}
+}
+
+func TestGetIdleSessions(t *testing.T) {
+	type args struct {
+		idleMinutes  uint
+		users        []string
+		applications []string
+		clientHosts  []string
+		protected    []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"ProtectedOnly", args{60, nil, nil, nil, []string{"HCCADMIN"}}, "SELECT C.CONNECTION_ID, C.USER_NAME, COALESCE(S.VALUE, '') AS APPLICATION, COALESCE(C.CLIENT_HOST, '') AS CLIENT_HOST, C.IDLE_TIME FROM \"SYS\".\"M_CONNECTIONS\" AS C LEFT JOIN \"SYS\".\"M_SESSION_CONTEXT\" AS S ON S.HOST = C.HOST AND S.PORT = C.PORT AND S.CONNECTION_ID = C.CONNECTION_ID AND S.KEY = 'APPLICATION' WHERE C.CONNECTION_STATUS = 'IDLE' AND C.CONNECTION_TYPE = 'Remote' AND C.OWN = 'FALSE' AND C.IDLE_TIME > 3600000 AND C.USER_NAME NOT IN ('HCCADMIN') ORDER BY C.IDLE_TIME DESC"},
+		{"AllFilters", args{120, []string{"DEVUSER"}, []string{"HDBStudio"}, []string{"devpc01", "devpc02"}, []string{"HCCADMIN", "SYSTEM"}}, "SELECT C.CONNECTION_ID, C.USER_NAME, COALESCE(S.VALUE, '') AS APPLICATION, COALESCE(C.CLIENT_HOST, '') AS CLIENT_HOST, C.IDLE_TIME FROM \"SYS\".\"M_CONNECTIONS\" AS C LEFT JOIN \"SYS\".\"M_SESSION_CONTEXT\" AS S ON S.HOST = C.HOST AND S.PORT = C.PORT AND S.CONNECTION_ID = C.CONNECTION_ID AND S.KEY = 'APPLICATION' WHERE C.CONNECTION_STATUS = 'IDLE' AND C.CONNECTION_TYPE = 'Remote' AND C.OWN = 'FALSE' AND C.IDLE_TIME > 7200000 AND C.USER_NAME NOT IN ('HCCADMIN', 'SYSTEM') AND C.USER_NAME IN ('DEVUSER') AND COALESCE(S.VALUE, '') IN ('HDBStudio') AND C.CLIENT_HOST IN ('devpc01', 'devpc02') ORDER BY C.IDLE_TIME DESC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetIdleSessions(tt.args.idleMinutes, tt.args.users, tt.args.applications, tt.args.clientHosts, tt.args.protected); got != tt.want {
+				t.Errorf("GetIdleSessions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDisconnectSession(t *testing.T) {
+	want := "ALTER SYSTEM DISCONNECT SESSION '300123'"
+	if got := GetDisconnectSession(300123); got != want {
+		t.Errorf("GetDisconnectSession() = %v, want %v", got, want)
+	}
+}
+
+func TestGetMonitoringViewStats(t *testing.T) {
+	want := "SELECT COUNT(*) AS ROW_COUNT, COALESCE(DAYS_BETWEEN(MIN(RESET_TIME), NOW()), 0) AS AGE_DAYS FROM \"SYS\".\"M_CS_UNLOADS_RESET\""
+	if got := GetMonitoringViewStats("M_CS_UNLOADS_RESET"); got != want {
+		t.Errorf("GetMonitoringViewStats() = %v, want %v", got, want)
+	}
+}
+
+func TestGetResetMonitoringView(t *testing.T) {
+	want := "ALTER SYSTEM RESET MONITORING VIEW \"SYS\".\"M_OBJECT_LOCK_STATISTICS_RESET\""
+	if got := GetResetMonitoringView("M_OBJECT_LOCK_STATISTICS_RESET"); got != want {
+		t.Errorf("GetResetMonitoringView() = %v, want %v", got, want)
+	}
+}
+
+func TestGetElevatedTraceLevels(t *testing.T) {
+	type args struct {
+		levels      []string
+		files       []string
+		withHistory bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"NoHistory", args{[]string{"debug"}, nil, false}, "SELECT C.FILE_NAME, C.LAYER_NAME, COALESCE(C.HOST, '') AS HOST, C.KEY, C.VALUE, -1 AS AGE_HOURS FROM \"SYS\".\"M_INIFILE_CONTENTS\" AS C WHERE C.SECTION = 'trace' AND C.LAYER_NAME IN ('SYSTEM', 'DATABASE', 'HOST') AND LOWER(C.VALUE) IN ('debug') ORDER BY C.FILE_NAME, C.LAYER_NAME, C.HOST, C.KEY"},
+		{"HistoryAndFiles", args{[]string{"info", "debug"}, []string{"indexserver.ini"}, true}, "SELECT C.FILE_NAME, C.LAYER_NAME, COALESCE(C.HOST, '') AS HOST, C.KEY, C.VALUE, COALESCE(SECONDS_BETWEEN(H.CHANGED, NOW()) / 3600, -1) AS AGE_HOURS FROM \"SYS\".\"M_INIFILE_CONTENTS\" AS C LEFT JOIN (SELECT FILE_NAME, LAYER_NAME, HOST, KEY, MAX(TIME) AS CHANGED FROM \"SYS\".\"M_INIFILE_CONTENT_HISTORY\" WHERE SECTION = 'trace' GROUP BY FILE_NAME, LAYER_NAME, HOST, KEY) AS H ON H.FILE_NAME = C.FILE_NAME AND H.LAYER_NAME = C.LAYER_NAME AND COALESCE(H.HOST, '') = COALESCE(C.HOST, '') AND H.KEY = C.KEY WHERE C.SECTION = 'trace' AND C.LAYER_NAME IN ('SYSTEM', 'DATABASE', 'HOST') AND LOWER(C.VALUE) IN ('info', 'debug') AND C.FILE_NAME IN ('indexserver.ini') ORDER BY C.FILE_NAME, C.LAYER_NAME, C.HOST, C.KEY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetElevatedTraceLevels(tt.args.levels, tt.args.files, tt.args.withHistory); got != tt.want {
+				t.Errorf("GetElevatedTraceLevels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUnsetTraceLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting TraceLevelSetting
+		want    string
+	}{
+		{"System", TraceLevelSetting{"indexserver.ini", "SYSTEM", "", "api", "debug", -1}, "ALTER SYSTEM ALTER CONFIGURATION ('indexserver.ini', 'SYSTEM') UNSET ('trace', 'api') WITH RECONFIGURE"},
+		{"Database", TraceLevelSetting{"indexserver.ini", "DATABASE", "", "sqloptimizer", "debug", -1}, "ALTER SYSTEM ALTER CONFIGURATION ('indexserver.ini', 'DATABASE') UNSET ('trace', 'sqloptimizer') WITH RECONFIGURE"},
+		{"Host", TraceLevelSetting{"nameserver.ini", "HOST", "hana01", "topology", "debug", -1}, "ALTER SYSTEM ALTER CONFIGURATION ('nameserver.ini', 'HOST', 'hana01') UNSET ('trace', 'topology') WITH RECONFIGURE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetUnsetTraceLevel(tt.setting); got != tt.want {
+				t.Errorf("GetUnsetTraceLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPlanCacheEntries(t *testing.T) {
+	type args struct {
+		schemas []string
+		users   []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"Schemas", args{[]string{"APP"}, nil}, "SELECT PLAN_ID FROM \"SYS\".\"M_SQL_PLAN_CACHE\" WHERE REFERENCE_COUNT = 0 AND SCHEMA_NAME IN ('APP')"},
+		{"SchemasAndUsers", args{[]string{"APP"}, []string{"APPUSER", "BATCH"}}, "SELECT PLAN_ID FROM \"SYS\".\"M_SQL_PLAN_CACHE\" WHERE REFERENCE_COUNT = 0 AND SCHEMA_NAME IN ('APP') AND USER_NAME IN ('APPUSER', 'BATCH')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetPlanCacheEntries(tt.args.schemas, tt.args.users); got != tt.want {
+				t.Errorf("GetPlanCacheEntries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRemovePlanCacheEntry(t *testing.T) {
+	want := "ALTER SYSTEM REMOVE SQL PLAN CACHE ENTRY 1001"
+	if got := GetRemovePlanCacheEntry(1001); got != want {
+		t.Errorf("GetRemovePlanCacheEntry() = %v, want %v", got, want)
+	}
+}
+
+func TestGetRetentionCount(t *testing.T) {
+	want := "SELECT COUNT(*) AS COUNT FROM \"Z_APP\".\"LOG\" WHERE \"CREATED_AT\" < ADD_DAYS(NOW(), -30)"
+	if got := GetRetentionCount(RetentionTable{"Z_APP", "LOG", "CREATED_AT", 30}); got != want {
+		t.Errorf("GetRetentionCount() = %v, want %v", got, want)
+	}
+}
+
+func TestGetRetentionDeleteBatch(t *testing.T) {
+	want := "DELETE FROM \"Z_APP\".\"LOG\" WHERE \"CREATED_AT\" < ADD_DAYS(NOW(), -30) AND \"CREATED_AT\" <= (SELECT MAX(\"CREATED_AT\") FROM (SELECT TOP 5000 \"CREATED_AT\" FROM \"Z_APP\".\"LOG\" WHERE \"CREATED_AT\" < ADD_DAYS(NOW(), -30) ORDER BY \"CREATED_AT\"))"
+	if got := GetRetentionDeleteBatch(RetentionTable{"Z_APP", "LOG", "CREATED_AT", 30}, 5000); got != want {
+		t.Errorf("GetRetentionDeleteBatch() = %v, want %v", got, want)
+	}
+}
+
+func TestGetRetentionPrivCheck(t *testing.T) {
+	want := "SELECT COUNT(DISTINCT PRIVILEGE) AS COUNT FROM \"SYS\".\"EFFECTIVE_PRIVILEGES\" WHERE USER_NAME = CURRENT_USER AND PRIVILEGE IN ('SELECT', 'DELETE') AND SCHEMA_NAME = 'Z_APP' AND (OBJECT_NAME = 'O''BRIEN_LOG' OR OBJECT_TYPE = 'SCHEMA')"
+	if got := GetRetentionPrivCheck(RetentionTable{"Z_APP", "O'BRIEN_LOG", "TS", 30}); got != want {
+		t.Errorf("GetRetentionPrivCheck() = %v, want %v", got, want)
+	}
+}
+
+func TestGetOptimisePrivCheck(t *testing.T) {
+	want := "SELECT COUNT(*) AS COUNT FROM \"SYS\".\"EFFECTIVE_PRIVILEGES\" WHERE USER_NAME = CURRENT_USER AND PRIVILEGE = 'UPDATE' AND SCHEMA_NAME = 'Z_APP' AND OBJECT_TYPE = 'SCHEMA'"
+	if got := GetOptimisePrivCheck("Z_APP"); got != want {
+		t.Errorf("GetOptimisePrivCheck() = %v, want %v", got, want)
+	}
+}
+
+func TestGetAlertDeleteBatch(t *testing.T) {
+	want := "DELETE FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -14) AND ALERT_TIMESTAMP <= (SELECT MAX(ALERT_TIMESTAMP) FROM (SELECT TOP 10000 ALERT_TIMESTAMP FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -14) ORDER BY ALERT_TIMESTAMP))"
+	if got := GetAlertDeleteBatch(14, AlertConfig{10000, 0, 0, nil, nil}); got != want {
+		t.Errorf("GetAlertDeleteBatch() = %v, want %v", got, want)
+	}
+}
+
+func TestGetAlertDeleteSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		a    AlertConfig
+		want string
+	}{
+		{"Default", AlertConfig{SliceHours: 24}, "DELETE FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -14) AND ALERT_TIMESTAMP < (SELECT ADD_SECONDS(MIN(ALERT_TIMESTAMP), 86400) FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -14))"},
+		{"Rules", AlertConfig{SliceHours: 24, RetainDaysByRating: map[uint]uint{5: 3650}}, "DELETE FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -1 * (CASE WHEN ALERT_RATING = 5 THEN 3650 ELSE 14 END)) AND ALERT_TIMESTAMP < (SELECT ADD_SECONDS(MIN(ALERT_TIMESTAMP), 86400) FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -1 * (CASE WHEN ALERT_RATING = 5 THEN 3650 ELSE 14 END)))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetAlertDeleteSlice(14, tt.a); got != tt.want {
+				t.Errorf("GetAlertDeleteSlice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAlertCountByID(t *testing.T) {
+	type args struct {
+		days uint
+		a    AlertConfig
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{"NoRules", args{14, AlertConfig{}}, "SELECT ALERT_ID, COUNT(SNAPSHOT_ID) AS COUNT FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -14) GROUP BY ALERT_ID ORDER BY ALERT_ID"},
+		{"Rules", args{14, AlertConfig{0, 0, 0, map[uint]uint{45: 30, 21: 365}, map[uint]uint{1: 7}}}, "SELECT ALERT_ID, COUNT(SNAPSHOT_ID) AS COUNT FROM \"_SYS_STATISTICS\".\"STATISTICS_ALERTS_BASE\" WHERE ALERT_TIMESTAMP < ADD_DAYS(NOW(), -1 * (CASE WHEN ALERT_ID = 21 THEN 365 WHEN ALERT_ID = 45 THEN 30 WHEN ALERT_RATING = 1 THEN 7 ELSE 14 END)) GROUP BY ALERT_ID ORDER BY ALERT_ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetAlertCountByID(tt.args.days, tt.args.a); got != tt.want {
+				t.Errorf("GetAlertCountByID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOtherHccSessions(t *testing.T) {
+	want := "SELECT COUNT(DISTINCT S.CONNECTION_ID) AS SESSIONS FROM \"SYS\".\"M_SESSION_CONTEXT\" S INNER JOIN \"SYS\".\"M_CONNECTIONS\" C ON C.HOST = S.HOST AND C.PORT = S.PORT AND C.CONNECTION_ID = S.CONNECTION_ID WHERE S.KEY = 'APPLICATION' AND S.VALUE LIKE 'HanaCleanCentral:%' AND S.VALUE <> 'HanaCleanCentral:hcc''01:4321' AND C.CONNECTION_STATUS <> '' AND C.START_TIME > ADD_SECONDS(CURRENT_TIMESTAMP, -21600)"
+	if got := GetOtherHccSessions("HanaCleanCentral:hcc'01:4321", 360); got != want {
+		t.Errorf("GetOtherHccSessions() = %v, want %v", got, want)
+	}
+}
@@ -64,117 +64,253 @@ func main() {
 			}
 		}
 
-		/*Initialise and test connection*/
-		err := cnf.Databases[index].NewDb()
+		/*Take the run lock before connecting so that overlapping runs never work on the same database*/
+		err = cnf.Databases[index].AcquireLockFunc(lc, ac.ConfigFile)
+		if err != nil {
+			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Could not take the run lock, another run may be working on this database"), false}
+			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
+			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Will not process any tasks for this databases"), false}
+			continue
+		}
+
+		/*Initialise and test connection, finding the replication primary when candidate hosts are set*/
+		err = cnf.Databases[index].ConnectPrimaryFunc(lc)
 		if err != nil {
 			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Could not connect to configured database"), false}
 			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Check the configuration details and try again.  Full error message:"), false}
 			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
 			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Cannot process any tasks for this databases"), false}
+			cnf.Databases[index].ReleaseLockFunc(lc)
 			continue
 		}
 
-		/*Get and print version - this may be used in later versions to test compatability*/
-		v, err := cnf.Databases[index].HanaVersionFunc(lc)
+		/*Run the tasks and always close the connection and release the lock, whether or not the tasks succeed*/
+		ProcessDatabase(lc, ac, &cnf.Databases[index])
+		cnf.Databases[index].CloseDb(lc)
+		cnf.Databases[index].ReleaseLockFunc(lc)
+	}
+
+	/*Print Results*/
+	if !ac.DryRun {
+		for _, dbc := range cnf.Databases { /*Can use range here*/
+			dbc.PrintResults()
+		}
+	} else {
+		fmt.Printf("Dry run enabled: No report data\n")
+		for _, dbc := range cnf.Databases {
+			dbc.PrintPlan()
+		}
+	}
+
+	quit <- true
+}
+
+//Runs the enabled tasks against a connected database.  Tasks are skipped when the version is not supported, another
+//HCC run is connected or the privileges cannot be checked.  On a system replication secondary only dry runs are made.
+func ProcessDatabase(lc chan<- LogMessage, ac AppConfig, dbc *DbConfig) {
+	/*The version is read while connecting, refuse versions that HCC does not support*/
+	lc <- LogMessage{dbc.Name, fmt.Sprintf("Hana Version found %s", dbc.Results.Version), false}
+	err := dbc.Results.Version.CheckSupported()
+	if err != nil {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("The HANA version of configured database is not supported"), false}
+		lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("Will not process any tasks for this databases"), false}
+		return
+	}
+
+	/*HANA Cloud manages trace files, backups and volumes itself so use the Cloud task profile*/
+	if dbc.Results.Version.Cloud() {
+		dbc.ApplyCloudProfile(lc)
+	}
+
+	/*Every task below changes the database when it is not a dry run, so on a system replication secondary each task is
+	skipped unless it only reads the database and reports the changes it would make.  The checks before the tasks only
+	read the database and always run*/
+	secondary := dbc.Results.ReplicationRole == ReplicationSecondary
+	if secondary {
+		lc <- LogMessage{dbc.Name, fmt.Sprintf("Connected host %s is a system replication secondary", dbc.Results.ConnectedHost), false}
+	}
+	changesAllowed := func(task string) bool {
+		if secondary && !ac.DryRun {
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s skipped, it changes the database and the connected host is a system replication secondary", task), false}
+			dbc.Results.SecondarySkipped = append(dbc.Results.SecondarySkipped, task)
+			return false
+		}
+		return true
+	}
+
+	/*The lock file only covers runs on this host, check the database for runs on other hosts*/
+	if dbc.Lock.Database {
+		err = dbc.CheckDatabaseLockFunc(lc)
 		if err != nil {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Could not get HANA version of configured database"), false}
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Full error message:"), false}
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Will not process any tasks for this databases"), false}
-			continue
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Could not confirm that no other run is working on this database"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Will not process any tasks for this databases"), false}
+			return
 		}
-		lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("Hana Version found %s", v), false}
+	}
+
+	err = dbc.CheckPrivileges(lc)
+	if err != nil {
+		lc <- LogMessage{dbc.Name, fmt.Sprint("There was a problem checking privileges for this database"), false}
+		lc <- LogMessage{dbc.Name, fmt.Sprint("Full error message:"), false}
+		lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+		return
+	}
 
-		err = cnf.Databases[index].CheckPrivileges(lc)
+	/*Clean trace files*/
+	if dbc.CleanTrace && changesAllowed("CleanTrace") {
+		err = dbc.CleanTraceFilesFunc(lc, dbc.RetainTraceDays, ac.DryRun)
 		if err != nil {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprint("There was a problem checking privileges for this database"), false}
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprint("Full error message:"), false}
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			continue
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to clean trace files"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
+	} else if !dbc.CleanTrace {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("CleanTrace not enabled for this database"), false}
+	}
 
-		/*Clean trace files*/
-		if cnf.Databases[index].CleanTrace {
-			err = cnf.Databases[index].CleanTraceFilesFunc(lc, cnf.Databases[index].RetainTraceDays, ac.DryRun)
-			if err != nil {
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("An error occurred trying to clean trace files"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Full error message:"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			}
-		} else {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("CleanTrace not enabled for this database"), false}
+	/*Enforce trace quota*/
+	if dbc.TraceQuota.Enabled && changesAllowed("TraceQuota") {
+		err = dbc.CleanTraceQuotaFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to enforce the trace quota"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
+	} else if !dbc.TraceQuota.Enabled {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("TraceQuota not enabled for this database"), false}
+	}
 
-		/*Clean backup catalog*/
-		if cnf.Databases[index].CleanBackupCatalog {
-			err = cnf.Databases[index].CleanBackupFunc(lc, cnf.Databases[index].RetainBackupCatalogDays, cnf.Databases[index].DeleteOldBackups, ac.DryRun)
-			if err != nil {
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("An error occurred trying clean backup catalog"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Full error message:"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			}
-		} else {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("CleanBackupCatalog not enabled for this database"), false}
+	/*Clean backup catalog*/
+	if dbc.CleanBackupCatalog && changesAllowed("CleanBackupCatalog") {
+		err = dbc.CleanBackupFunc(lc, dbc.RetainBackupCatalogDays, dbc.DeleteOldBackups, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying clean backup catalog"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
+	} else if !dbc.CleanBackupCatalog {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("CleanBackupCatalog not enabled for this database"), false}
+	}
 
-		/*Clean Alerts*/
-		if cnf.Databases[index].CleanAlerts {
-			err = cnf.Databases[index].CleanAlertFunc(lc, cnf.Databases[index].RetainAlertsDays, ac.DryRun)
-			if err != nil {
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("An error occurred trying clean alerts"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Full error message:"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			}
-		} else {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("CleanAlerts not enabled for this database"), false}
+	/*Clean Alerts*/
+	if dbc.CleanAlerts && changesAllowed("CleanAlerts") {
+		err = dbc.CleanAlertFunc(lc, dbc.RetainAlertsDays, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying clean alerts"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
+	} else if !dbc.CleanAlerts {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("CleanAlerts not enabled for this database"), false}
+	}
 
-		/*Clean Log Volume*/
-		if cnf.Databases[index].CleanLogVolume {
-			err = cnf.Databases[index].CleanLogFunc(lc, ac.DryRun)
-			if err != nil {
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("An error occurred trying clean log volume"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Full error message:"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			}
-		} else {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("CleanLogVolume not enabled for this database"), false}
+	/*Clean Log Volume*/
+	if dbc.CleanLogVolume && changesAllowed("CleanLogVolume") {
+		err = dbc.CleanLogFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying clean log volume"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
+	} else if !dbc.CleanLogVolume {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("CleanLogVolume not enabled for this database"), false}
+	}
 
-		/*Clean Log Volume*/
-		if cnf.Databases[index].CleanAudit {
-			err = cnf.Databases[index].CleanAuditFunc(lc, cnf.Databases[index].RetainAuditDays, ac.DryRun)
-			if err != nil {
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("An error occurred trying clean audit log"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Full error message:"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			}
-		} else {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("CleanAudit not enabled for this database"), false}
+	/*Clean Log Volume*/
+	if dbc.CleanAudit && changesAllowed("CleanAudit") {
+		err = dbc.CleanAuditFunc(lc, dbc.RetainAuditDays, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying clean audit log"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
+	} else if !dbc.CleanAudit {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("CleanAudit not enabled for this database"), false}
+	}
 
-		/*Clean Data Volume*/
-		if cnf.Databases[index].CleanDataVolume {
-			err = cnf.Databases[index].CleanDataVolumeFunc(lc, ac.DryRun)
-			if err != nil {
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("An error occurred trying clean data volume log"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("Full error message:"), false}
-				lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintf("%s", err.Error()), false}
-			}
-		} else {
-			lc <- LogMessage{cnf.Databases[index].Name, fmt.Sprintln("CleanDataVolume not enabled for this database"), false}
+	/*Clean Data Volume*/
+	if dbc.CleanDataVolume && changesAllowed("CleanDataVolume") {
+		err = dbc.CleanDataVolumeFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying clean data volume log"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+		}
+	} else if !dbc.CleanDataVolume {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("CleanDataVolume not enabled for this database"), false}
+	}
+
+	/*Optimise column store tables*/
+	if dbc.TableOptimise.Enabled && changesAllowed("TableOptimise") {
+		err = dbc.OptimiseTablesFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to optimise tables"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
+	} else if !dbc.TableOptimise.Enabled {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("TableOptimise not enabled for this database"), false}
+	}
 
+	/*Disconnect idle sessions*/
+	if dbc.IdleSessions.Enabled && changesAllowed("IdleSessions") {
+		err = dbc.DisconnectIdleSessionsFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to disconnect idle sessions"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+		}
+	} else if !dbc.IdleSessions.Enabled {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("IdleSessions not enabled for this database"), false}
 	}
 
-	/*Print Results*/
-	if !ac.DryRun {
-		for _, dbc := range cnf.Databases { /*Can use range here*/
-			dbc.PrintResults()
+	/*Reset monitoring views*/
+	if dbc.MonitoringReset.Enabled && changesAllowed("MonitoringReset") {
+		err = dbc.ResetMonitoringViewsFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to reset monitoring views"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
 		}
-	} else {
-		fmt.Printf("Dry run enabled: No report data\n")
+	} else if !dbc.MonitoringReset.Enabled {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("MonitoringReset not enabled for this database"), false}
 	}
 
-	quit <- true
+	/*Reset trace levels*/
+	if dbc.TraceLevels.Enabled && changesAllowed("TraceLevels") {
+		err = dbc.ResetTraceLevelsFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to reset trace levels"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+		}
+	} else if !dbc.TraceLevels.Enabled {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("TraceLevels not enabled for this database"), false}
+	}
+
+	/*Clean plan cache*/
+	if dbc.PlanCache.Enabled && changesAllowed("PlanCache") {
+		err = dbc.CleanPlanCacheFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to clean the plan cache"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+		}
+	} else if !dbc.PlanCache.Enabled {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("PlanCache not enabled for this database"), false}
+	}
+
+	/*Clean retention tables*/
+	if dbc.TableRetention.Enabled && changesAllowed("TableRetention") {
+		err = dbc.CleanTableRetentionFunc(lc, ac.DryRun)
+		if err != nil {
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("An error occurred trying to remove old rows from retention tables"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintln("Full error message:"), false}
+			lc <- LogMessage{dbc.Name, fmt.Sprintf("%s", err.Error()), false}
+		}
+	} else if !dbc.TableRetention.Enabled {
+		lc <- LogMessage{dbc.Name, fmt.Sprintln("TableRetention not enabled for this database"), false}
+	}
 }